@@ -0,0 +1,193 @@
+package Malt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/taluos/Malt/api/metadata"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/log"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// adminServer 应用级运维端口，统一提供健康检查、实例信息、pprof 和指标
+type adminServer struct {
+	app      *App
+	server   *http.Server
+	listener net.Listener
+}
+
+type serverHealth struct {
+	Type     string `json:"type"`
+	Ready    bool   `json:"ready"`
+	Inflight int64  `json:"inflight"`
+}
+
+type healthReply struct {
+	Status  string         `json:"status"`
+	Servers []serverHealth `json:"servers"`
+}
+
+type buildInfoReply struct {
+	Release   string            `json:"release"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Metadata  map[string]string `json:"metadata"`
+	GoVersion string            `json:"goVersion"`
+	Module    string            `json:"module,omitempty"`
+	ModuleVer string            `json:"moduleVersion,omitempty"`
+}
+
+type grpcServicesReply struct {
+	Services []string `json:"services"`
+	Methods  []string `json:"methods"`
+}
+
+func newAdminServer(app *App) *adminServer {
+	a := &adminServer{app: app}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", a.health)
+	mux.HandleFunc("/instances", a.instances)
+	mux.HandleFunc("/log/level", a.logLevel)
+	mux.HandleFunc("/buildinfo", a.buildInfo)
+	mux.HandleFunc("/grpc/services", a.grpcServices)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	a.server = &http.Server{Handler: mux}
+	return a
+}
+
+// Start 监听运维端口，返回后端口已经可以访问
+func (a *adminServer) Start(address string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Errorf("[Malt] admin server listen failed: %s", err)
+		return err
+	}
+	a.listener = lis
+
+	log.Infof("[Malt] admin server listening at %s", lis.Addr())
+
+	go func() {
+		if err := a.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("[Malt] admin server serve failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+func (a *adminServer) Stop(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
+// Addr 返回运维端口实际监听的地址
+func (a *adminServer) Addr() string {
+	if a.listener == nil {
+		return ""
+	}
+	return a.listener.Addr().String()
+}
+
+func (a *adminServer) health(w http.ResponseWriter, _ *http.Request) {
+	reply := healthReply{
+		Status:  "SERVING",
+		Servers: make([]serverHealth, 0, len(a.app.opts.server)),
+	}
+
+	for _, srv := range a.app.opts.server {
+		h := serverHealth{Type: srv.Type()}
+		select {
+		case <-srv.Ready():
+			h.Ready = true
+		default:
+			reply.Status = "NOT_SERVING"
+		}
+		if d, ok := srv.(drainer); ok {
+			h.Inflight = d.Inflight()
+		}
+		reply.Servers = append(reply.Servers, h)
+	}
+
+	if a.app.draining.Load() {
+		reply.Status = "NOT_SERVING"
+	}
+
+	status := http.StatusOK
+	if reply.Status != "SERVING" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, reply)
+}
+
+func (a *adminServer) instances(w http.ResponseWriter, _ *http.Request) {
+	a.app.mu.RLock()
+	defer a.app.mu.RUnlock()
+	writeJSON(w, http.StatusOK, a.app.instances)
+}
+
+func (a *adminServer) logLevel(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"level": log.GetLevel().String()})
+}
+
+func (a *adminServer) buildInfo(w http.ResponseWriter, _ *http.Request) {
+	reply := buildInfoReply{
+		Release:   Release,
+		ID:        a.app.ID(),
+		Name:      a.app.Name(),
+		Version:   a.app.Version(),
+		Metadata:  a.app.Metadata(),
+		GoVersion: runtime.Version(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		reply.Module = info.Main.Path
+		reply.ModuleVer = info.Main.Version
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+func (a *adminServer) grpcServices(w http.ResponseWriter, r *http.Request) {
+	reply := grpcServicesReply{Services: []string{}, Methods: []string{}}
+
+	for _, srv := range a.app.opts.server {
+		e, ok := srv.(interface{ Engine() any })
+		if !ok {
+			continue
+		}
+		gs, ok := e.Engine().(*grpc.Server)
+		if !ok {
+			continue
+		}
+		list, err := metadata.NewServer(gs).ListServices(r.Context(), &metadata.ListServicesRequest{})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		reply.Services = append(reply.Services, list.Services...)
+		reply.Methods = append(reply.Methods, list.Methods...)
+	}
+
+	writeJSON(w, http.StatusOK, reply)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("[Malt] admin server write response failed: %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package Malt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/taluos/Malt/server"
+	rpcserver "github.com/taluos/Malt/server/rpc"
+	grpcServer "github.com/taluos/Malt/server/rpc/rpc-grpc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSON(t *testing.T, url string, v any) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	return resp.StatusCode
+}
+
+func TestAdminServer(t *testing.T) {
+	rpcSrv, err := server.NewServer(server.RPCServerType, server.RPCConfig{
+		Method:  "grpc",
+		Options: []rpcserver.ServerOptions{grpcServer.WithAddress("127.0.0.1:0")},
+	})
+	require.NoError(t, err)
+
+	var app *App
+	app = New(
+		WithId("admin-id"),
+		WithName("admin"),
+		WithServer(rpcSrv),
+		WithAdminAddress("127.0.0.1:0"),
+		WithAfterStart(func(ctx context.Context) error {
+			defer func() { go func() { _ = app.Stop() }() }()
+
+			base := "http://" + app.admin.Addr()
+
+			var health healthReply
+			assert.Equal(t, http.StatusOK, getJSON(t, base+"/health", &health))
+			assert.Equal(t, "SERVING", health.Status)
+			require.Len(t, health.Servers, 1)
+			assert.True(t, health.Servers[0].Ready)
+
+			var info buildInfoReply
+			assert.Equal(t, http.StatusOK, getJSON(t, base+"/buildinfo", &info))
+			assert.Equal(t, Release, info.Release)
+			assert.Equal(t, "admin-id", info.ID)
+
+			var instances []map[string]any
+			assert.Equal(t, http.StatusOK, getJSON(t, base+"/instances", &instances))
+			assert.Len(t, instances, 1)
+
+			var services grpcServicesReply
+			assert.Equal(t, http.StatusOK, getJSON(t, base+"/grpc/services", &services))
+			assert.Contains(t, services.Services, "grpc.health.v1.Health")
+
+			var level map[string]string
+			assert.Equal(t, http.StatusOK, getJSON(t, base+"/log/level", &level))
+			assert.NotEmpty(t, level["level"])
+
+			resp, err := http.Get(base + "/metrics")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			return nil
+		}),
+	)
+
+	require.NoError(t, app.Run())
+}
@@ -2,10 +2,13 @@ package Malt
 
 import (
 	"context"
+	"net/url"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/taluos/Malt/core/registry"
 	"github.com/taluos/Malt/pkg/errors"
@@ -29,8 +32,12 @@ type App struct {
 	cancel context.CancelFunc
 
 	// 服务实例
-	instances []*registry.ServiceInstance
-	mu        sync.RWMutex
+	instances  []*registry.ServiceInstance
+	registered []*registry.ServiceInstance
+	mu         sync.RWMutex
+
+	admin    *adminServer
+	draining atomic.Bool
 
 	opts options
 }
@@ -79,7 +86,17 @@ func (a *App) Metadata() map[string]string {
 }
 
 func (a *App) Endpoint() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	var endpoints []string
+	// 服务启动后返回实际注册的端点
+	if a.instances != nil {
+		for _, instance := range a.instances {
+			endpoints = append(endpoints, instance.Endpoints...)
+		}
+		return endpoints
+	}
 	for _, endpoint := range a.opts.endpoints {
 		endpoints = append(endpoints, endpoint.String())
 	}
@@ -88,6 +105,38 @@ func (a *App) Endpoint() []string {
 
 // 服务启动
 func (app *App) Run() error {
+	sctx := NewContext(app.ctx, app)
+
+	// 服务器启动之前失败时，释放通过 Endpoint 提前监听的端口
+	started := false
+	defer func() {
+		if !started {
+			app.closeListeners()
+		}
+	}()
+
+	// 启动前钩子：任意一个失败都直接终止启动
+	for _, fn := range app.opts.beforeStart {
+		if err := fn(sctx); err != nil {
+			log.Errorf("[Malt] before start hook error: %s", err)
+			return err
+		}
+	}
+
+	// 运维端口先于业务服务器启动，便于观察启动过程
+	if app.opts.adminAddress != "" {
+		app.admin = newAdminServer(app)
+		if err := app.admin.Start(app.opts.adminAddress); err != nil {
+			return err
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), app.opts.stopTimeout)
+			defer cancel()
+			if err := app.admin.Stop(ctx); err != nil {
+				log.Errorf("[Malt] admin server stop error: %s", err)
+			}
+		}()
+	}
 
 	// 获取注册信息
 	instances, err := app.buildInstance()
@@ -100,7 +149,7 @@ func (app *App) Run() error {
 	app.instances = instances
 	app.mu.Unlock()
 
-	sctx := NewContext(app.ctx, app)
+	started = true
 	eg, ctx := errgroup.WithContext(sctx)
 	wg := sync.WaitGroup{}
 
@@ -124,20 +173,22 @@ func (app *App) Run() error {
 	}
 	defer wg.Wait()
 
+	// 等待所有服务器开始监听之后再注册
+	if err = app.waitReady(ctx); err != nil {
+		return app.abort(eg, err)
+	}
+
 	// register service
-	if app.opts.registrar != nil {
-		rctx, cancel := context.WithTimeout(ctx, app.opts.registrarTimeout)
-		defer cancel()
-		for _, instance := range app.instances {
-			err = app.opts.registrar.Register(rctx, instance)
-			if err != nil {
-				log.Errorf("[Malt] register service error: %s", err)
-				return err
-			}
+	if err = app.register(ctx); err != nil {
+		return app.abort(eg, err)
+	}
+
+	// 启动后钩子：失败时回滚已经注册的服务并停止服务器
+	for _, fn := range app.opts.afterStart {
+		if err = fn(sctx); err != nil {
+			log.Errorf("[Malt] after start hook error: %s", err)
+			return app.abort(eg, err)
 		}
-		log.Infof("[Malt] register %d service success", len(app.instances))
-	} else {
-		log.Infof("[Malt] register service skip")
 	}
 
 	// 监听退出信号
@@ -151,53 +202,257 @@ func (app *App) Run() error {
 			return app.Stop()
 		}
 	})
-	if err = eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		return err
+	if err = eg.Wait(); err != nil && errors.Is(err, context.Canceled) {
+		err = nil
 	}
 
-	return nil
+	// 停止后钩子：停止失败时同样执行，根上下文已经取消，使用不带取消信号的上下文
+	herr := app.runHooks(NewContext(context.WithoutCancel(app.ctx), app), app.opts.afterStop, "after stop")
+	return errors.Join(err, herr)
 }
 
 // 服务停止
 func (app *App) Stop() error {
+	sctx := NewContext(app.ctx, app)
+
+	app.draining.Store(true)
+
+	err := app.runHooks(sctx, app.opts.beforeStop, "before stop")
+
+	if derr := app.deregister(sctx); derr != nil && err == nil {
+		err = derr
+	}
+
+	// 注销之后等待注册中心和客户端缓存感知，再停止服务器
+	app.drain()
+
+	if app.cancel != nil {
+		app.cancel()
+	}
+
+	return err
+}
+
+// waitReady 等待所有服务器就绪，最长等待 registrarTimeout
+func (app *App) waitReady(ctx context.Context) error {
+	timer := time.NewTimer(app.opts.registrarTimeout)
+	defer timer.Stop()
+
+	for _, srv := range app.opts.server {
+		select {
+		case <-srv.Ready():
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			log.Errorf("[Malt] wait server %s ready timeout", srv.Type())
+			return errors.New("[Malt] wait server ready timeout")
+		}
+	}
+	log.Infof("[Malt] %d server ready", len(app.opts.server))
+
+	return nil
+}
+
+// drainer 支持下线排空的服务器
+type drainer interface {
+	Drain()
+	Inflight() int64
+}
+
+// drain 将服务器标记为不健康，并在 drainDelay 内周期性输出正在处理的请求数
+func (app *App) drain() {
+	drainers := make([]drainer, 0, len(app.opts.server))
+	for _, srv := range app.opts.server {
+		if d, ok := srv.(drainer); ok {
+			d.Drain()
+			drainers = append(drainers, d)
+		}
+	}
+
+	if app.opts.drainDelay <= 0 {
+		return
+	}
+
+	inflight := func() int64 {
+		var n int64
+		for _, d := range drainers {
+			n += d.Inflight()
+		}
+		return n
+	}
+
+	log.Infof("[Malt] draining for %s, %d requests in flight", app.opts.drainDelay, inflight())
+
+	timer := time.NewTimer(app.opts.drainDelay)
+	defer timer.Stop()
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			log.Infof("[Malt] drain finished, %d requests in flight", inflight())
+			return
+		case <-ticker.C:
+			log.Infof("[Malt] draining, %d requests in flight", inflight())
+		}
+	}
+}
+
+// register 注册所有服务实例，失败时回滚已经注册成功的实例
+func (app *App) register(ctx context.Context) error {
+	if app.opts.registrar == nil {
+		log.Infof("[Malt] register service skip")
+		return nil
+	}
+
 	app.mu.Lock()
-	instances := app.instances
+	defer app.mu.Unlock()
+
+	rctx, cancel := context.WithTimeout(ctx, app.opts.registrarTimeout)
+	defer cancel()
+	for _, instance := range app.instances {
+		if err := app.opts.registrar.Register(rctx, instance); err != nil {
+			log.Errorf("[Malt] register service error: %s", err)
+			return err
+		}
+		app.registered = append(app.registered, instance)
+	}
+	log.Infof("[Malt] register %d service success", len(app.registered))
+
+	return nil
+}
+
+// deregister 注销所有已经注册成功的服务实例，可以重复调用
+func (app *App) deregister(ctx context.Context) error {
+	app.mu.Lock()
+	registered := app.registered
+	app.registered = nil
 	app.mu.Unlock()
 
-	if app.opts.registrar != nil && instances != nil {
-		ctx, cancel := context.WithTimeout(NewContext(app.ctx, app), app.opts.stopTimeout)
-		defer cancel()
-		for _, instance := range app.instances {
-			err := app.opts.registrar.Deregister(ctx, instance)
-			if err != nil {
-				log.Errorf("[Malt] deregister service error: %s", err)
-				return err
+	if app.opts.registrar == nil || len(registered) == 0 {
+		return nil
+	}
+
+	dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), app.opts.stopTimeout)
+	defer cancel()
+
+	var err error
+	for _, instance := range registered {
+		if derr := app.opts.registrar.Deregister(dctx, instance); derr != nil {
+			log.Errorf("[Malt] deregister service error: %s", derr)
+			if err == nil {
+				err = derr
 			}
 		}
+	}
+
+	return err
+}
 
+// abort 启动失败时回滚：注销已注册的实例，停止服务器并等待退出
+func (app *App) abort(eg *errgroup.Group, cause error) error {
+	if err := app.deregister(app.ctx); err != nil {
+		log.Errorf("[Malt] rollback register error: %s", err)
 	}
 	if app.cancel != nil {
 		app.cancel()
 	}
+	// 优先返回服务器启动失败的真实原因
+	if err := eg.Wait(); err != nil && errors.Is(cause, context.Canceled) {
+		return err
+	}
+	return cause
+}
 
-	return nil
+// runHooks 依次执行钩子函数，返回第一个错误，但不会中断后续钩子
+func (app *App) runHooks(ctx context.Context, hooks []func(context.Context) error, stage string) error {
+	var err error
+	for _, fn := range hooks {
+		if herr := fn(ctx); herr != nil {
+			log.Errorf("[Malt] %s hook error: %s", stage, herr)
+			if err == nil {
+				err = herr
+			}
+		}
+	}
+	return err
+}
+
+// endpointer 可以返回真实监听端点的服务器
+type endpointer interface {
+	Endpoint() (*url.URL, error)
+}
+
+// listenerCloser 可以在启动之前关闭已经打开的监听的服务器
+type listenerCloser interface {
+	CloseListener() error
+}
+
+// closeListeners 关闭服务器提前打开的监听
+func (app *App) closeListeners() {
+	for _, srv := range app.opts.server {
+		if c, ok := srv.(listenerCloser); ok {
+			if err := c.CloseListener(); err != nil {
+				log.Errorf("[Malt] close server %s listener error: %s", srv.Type(), err)
+			}
+		}
+	}
 }
 
 // 创建服务注册结构体
+// 端点来自 WithEndpoints 以及 WithServer 中实现了 Endpoint 的服务器，
+// 按 scheme 分组，每组创建一个服务实例
 func (app *App) buildInstance() ([]*registry.ServiceInstance, error) {
-	endpoints := make([]string, 0)
-	//tags := make([]string, 0)
-	instences := make([]*registry.ServiceInstance, 0)
+	endpoints := make([]*url.URL, 0, len(app.opts.endpoints)+len(app.opts.server))
+	endpoints = append(endpoints, app.opts.endpoints...)
+
+	for _, srv := range app.opts.server {
+		e, ok := srv.(endpointer)
+		if !ok {
+			continue
+		}
+		endpoint, err := e.Endpoint()
+		if err != nil {
+			log.Errorf("[Malt] get server endpoint error: %s", err)
+			return nil, err
+		}
+		if endpoint != nil {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	schemes := make([]string, 0)
+	grouped := make(map[string][]string)
+	seen := make(map[string]struct{})
+	for _, endpoint := range endpoints {
+		if endpoint == nil {
+			continue
+		}
+		raw := endpoint.String()
+		if _, ok := seen[raw]; ok {
+			continue
+		}
+		seen[raw] = struct{}{}
+		if _, ok := grouped[endpoint.Scheme]; !ok {
+			schemes = append(schemes, endpoint.Scheme)
+		}
+		grouped[endpoint.Scheme] = append(grouped[endpoint.Scheme], raw)
+	}
+
+	instences := make([]*registry.ServiceInstance, 0, len(schemes))
+	for _, scheme := range schemes {
+		tags := make([]string, 0, len(app.opts.tags)+1)
+		tags = append(tags, app.opts.tags...)
+		tags = append(tags, scheme)
 
-	for _, endendpoint := range app.opts.endpoints {
-		endpoints = append(endpoints, endendpoint.String())
 		instences = append(instences, &registry.ServiceInstance{
-			ID:        uuid.NewString(),
-			Name:      app.opts.name + "-" + endendpoint.Scheme,
+			ID:        app.opts.id + "-" + scheme,
+			Name:      app.opts.name + "-" + scheme,
 			Version:   app.opts.version,
-			Endpoints: []string{endendpoint.String()},
+			Endpoints: grouped[scheme],
 			Metadata:  app.opts.metadata,
-			Tags:      []string{endendpoint.Scheme},
+			Tags:      tags,
 		})
 	}
 	log.Infof("[Malt] Create %d service", len(instences))
@@ -0,0 +1,356 @@
+package Malt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/server"
+	restserver "github.com/taluos/Malt/server/rest"
+	ginServer "github.com/taluos/Malt/server/rest/rest-gin"
+	rpcserver "github.com/taluos/Malt/server/rpc"
+	grpcServer "github.com/taluos/Malt/server/rpc/rpc-grpc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInstanceFromServers(t *testing.T) {
+	restSrv, err := server.NewServer(server.RESTServerType, server.RESTConfig{
+		Method:  "gin",
+		Options: []restserver.ServerOptions{ginServer.WithAddress("127.0.0.1:0")},
+	})
+	require.NoError(t, err)
+
+	rpcSrv, err := server.NewServer(server.RPCServerType, server.RPCConfig{
+		Method:  "grpc",
+		Options: []rpcserver.ServerOptions{grpcServer.WithAddress("127.0.0.1:0")},
+	})
+	require.NoError(t, err)
+
+	extra, _ := url.Parse("http://10.0.0.1:8080")
+
+	app := New(
+		WithId("test-id"),
+		WithName("test"),
+		WithTags([]string{"blue"}),
+		WithEndpoints([]*url.URL{extra}),
+		WithServer(restSrv, rpcSrv),
+	)
+
+	instances, err := app.buildInstance()
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	httpIns, grpcIns := instances[0], instances[1]
+
+	assert.Equal(t, "test-http", httpIns.Name)
+	assert.Equal(t, "test-id-http", httpIns.ID)
+	assert.Equal(t, []string{"blue", "http"}, httpIns.Tags)
+	require.Len(t, httpIns.Endpoints, 2)
+	assert.Equal(t, extra.String(), httpIns.Endpoints[0])
+	assert.True(t, strings.HasPrefix(httpIns.Endpoints[1], "http://127.0.0.1:"))
+	assert.NotEqual(t, "http://127.0.0.1:0", httpIns.Endpoints[1])
+
+	assert.Equal(t, "test-grpc", grpcIns.Name)
+	assert.Equal(t, []string{"blue", "grpc"}, grpcIns.Tags)
+	require.Len(t, grpcIns.Endpoints, 1)
+	assert.True(t, strings.HasPrefix(grpcIns.Endpoints[0], "grpc://127.0.0.1:"))
+	assert.NotEqual(t, "grpc://127.0.0.1:0", grpcIns.Endpoints[0])
+}
+
+type fakeServer struct {
+	stop     chan struct{}
+	ready    chan struct{}
+	startErr error
+	stopErr  error
+	record   func(string)
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{stop: make(chan struct{}), ready: make(chan struct{})}
+}
+
+func (s *fakeServer) Type() string { return "fake" }
+
+func (s *fakeServer) Start(ctx context.Context) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	close(s.ready)
+	<-s.stop
+	return nil
+}
+
+func (s *fakeServer) Ready() <-chan struct{} { return s.ready }
+
+func (s *fakeServer) Drain() {
+	if s.record != nil {
+		s.record("drain")
+	}
+}
+
+func (s *fakeServer) Inflight() int64 { return 0 }
+
+func (s *fakeServer) Stop(ctx context.Context) error {
+	if s.record != nil {
+		s.record("stop")
+	}
+	close(s.stop)
+	return s.stopErr
+}
+
+type fakeRegistrar struct {
+	mu          sync.Mutex
+	registered  map[string]bool
+	events      []string
+	failOnNamed string
+}
+
+func newFakeRegistrar() *fakeRegistrar {
+	return &fakeRegistrar{registered: make(map[string]bool)}
+}
+
+func (r *fakeRegistrar) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if service.Name == r.failOnNamed {
+		return errors.New("register failed")
+	}
+	r.registered[service.ID] = true
+	r.events = append(r.events, "register")
+	return nil
+}
+
+func (r *fakeRegistrar) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.registered, service.ID)
+	r.events = append(r.events, "deregister")
+	return nil
+}
+
+func (r *fakeRegistrar) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestLifecycleHooksOrder(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+
+	hook := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			_, ok := FromContext(ctx)
+			assert.True(t, ok)
+			reg.record(name)
+			return nil
+		}
+	}
+
+	var app *App
+	app = New(
+		WithName("hooks"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(newFakeServer()),
+		WithRegistrar(reg),
+		WithBeforeStart(hook("beforeStart")),
+		WithAfterStart(hook("afterStart"), func(ctx context.Context) error {
+			go func() { _ = app.Stop() }()
+			return nil
+		}),
+		WithBeforeStop(hook("beforeStop")),
+		WithAfterStop(hook("afterStop")),
+	)
+
+	require.NoError(t, app.Run())
+	assert.Equal(t, []string{
+		"beforeStart", "register", "afterStart", "beforeStop", "deregister", "afterStop",
+	}, reg.events)
+	assert.Empty(t, reg.registered)
+}
+
+func TestAfterStopHooksRunWhenStopFails(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+	srv := newFakeServer()
+	srv.stopErr = errors.New("flush failed")
+
+	var app *App
+	app = New(
+		WithName("stop-error"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(srv),
+		WithRegistrar(reg),
+		WithAfterStart(func(ctx context.Context) error {
+			go func() { _ = app.Stop() }()
+			return nil
+		}),
+		WithAfterStop(func(ctx context.Context) error {
+			reg.record("afterStop")
+			return nil
+		}),
+	)
+
+	assert.ErrorIs(t, app.Run(), srv.stopErr)
+	assert.Contains(t, reg.events, "afterStop")
+}
+
+func TestAfterStartHookErrorRollsBack(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+	hookErr := errors.New("warmup failed")
+
+	app := New(
+		WithName("rollback"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(newFakeServer()),
+		WithRegistrar(reg),
+		WithAfterStart(func(ctx context.Context) error { return hookErr }),
+	)
+
+	err := app.Run()
+	assert.ErrorIs(t, err, hookErr)
+	assert.Equal(t, []string{"register", "deregister"}, reg.events)
+	assert.Empty(t, reg.registered)
+}
+
+func TestRegisterErrorRollsBack(t *testing.T) {
+	reg := newFakeRegistrar()
+	reg.failOnNamed = "partial-grpc"
+	httpEndpoint, _ := url.Parse("http://127.0.0.1:8000")
+	grpcEndpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+
+	app := New(
+		WithName("partial"),
+		WithEndpoints([]*url.URL{httpEndpoint, grpcEndpoint}),
+		WithServer(newFakeServer()),
+		WithRegistrar(reg),
+	)
+
+	assert.Error(t, app.Run())
+	assert.Equal(t, []string{"register", "deregister"}, reg.events)
+	assert.Empty(t, reg.registered)
+}
+
+func TestServerStartErrorSkipsRegister(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+	bindErr := errors.New("address already in use")
+
+	failing := newFakeServer()
+	failing.startErr = bindErr
+
+	app := New(
+		WithName("unready"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(newFakeServer(), failing),
+		WithRegistrar(reg),
+	)
+
+	err := app.Run()
+	assert.ErrorIs(t, err, bindErr)
+	assert.Empty(t, reg.events)
+}
+
+func TestServerReadyTimeout(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+
+	app := New(
+		WithName("slow"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(&slowServer{stop: make(chan struct{})}),
+		WithRegistrar(reg),
+		WithRegistrarTimeout(50*time.Millisecond),
+	)
+
+	assert.Error(t, app.Run())
+	assert.Empty(t, reg.events)
+}
+
+// slowServer never becomes ready.
+type slowServer struct {
+	stop chan struct{}
+}
+
+func (s *slowServer) Type() string { return "slow" }
+
+func (s *slowServer) Start(ctx context.Context) error {
+	<-s.stop
+	return nil
+}
+
+func (s *slowServer) Stop(ctx context.Context) error {
+	close(s.stop)
+	return nil
+}
+
+func (s *slowServer) Ready() <-chan struct{} { return make(chan struct{}) }
+
+func TestStopDrainsBeforeStoppingServers(t *testing.T) {
+	reg := newFakeRegistrar()
+	endpoint, _ := url.Parse("grpc://127.0.0.1:9000")
+	srv := newFakeServer()
+	srv.record = reg.record
+
+	var app *App
+	var stopCalled time.Time
+	app = New(
+		WithName("drain"),
+		WithEndpoints([]*url.URL{endpoint}),
+		WithServer(srv),
+		WithRegistrar(reg),
+		WithDrainDelay(100*time.Millisecond),
+		WithAfterStart(func(ctx context.Context) error {
+			go func() {
+				stopCalled = time.Now()
+				_ = app.Stop()
+			}()
+			return nil
+		}),
+	)
+
+	require.NoError(t, app.Run())
+	assert.GreaterOrEqual(t, time.Since(stopCalled), 100*time.Millisecond)
+	assert.Equal(t, []string{"register", "deregister", "drain", "stop"}, reg.events)
+}
+
+// brokenEndpointServer 的 Endpoint 总是失败
+type brokenEndpointServer struct {
+	*fakeServer
+}
+
+func (s brokenEndpointServer) Endpoint() (*url.URL, error) {
+	return nil, errors.New("no endpoint")
+}
+
+func TestBuildInstanceErrorClosesListeners(t *testing.T) {
+	restSrv, err := server.NewServer(server.RESTServerType, server.RESTConfig{
+		Method:  "gin",
+		Options: []restserver.ServerOptions{ginServer.WithAddress("127.0.0.1:0")},
+	})
+	require.NoError(t, err)
+	endpoint, err := restSrv.(interface{ Endpoint() (*url.URL, error) }).Endpoint()
+	require.NoError(t, err)
+
+	app := New(
+		WithName("broken"),
+		WithServer(restSrv, brokenEndpointServer{newFakeServer()}),
+		WithRegistrar(newFakeRegistrar()),
+	)
+
+	assert.Error(t, app.Run())
+
+	// Endpoint 已经打开过 gin 的监听，启动失败后端口应当被释放
+	ln, err := net.Listen("tcp", endpoint.Host)
+	require.NoError(t, err)
+	_ = ln.Close()
+}
@@ -44,6 +44,6 @@ type RESTConfig struct {
 
 // RPCConfig RPC客户端配置
 type RPCConfig struct {
-	Method  string // "grpc"
+	Method  string // "grpc" or "kitex"
 	Options []rpc.ClientOptions
 }
@@ -123,11 +123,6 @@ func convertRequestOptions(opts ...RequestOption) []resthttp.RequestOption {
 
 // 添加一个通用的响应包装函数
 func wrapHTTPResponse(resp *resthttp.Response) (Response, error) {
-	// 读取响应体
-	body, err := io.ReadAll(resp.Response.Body)
-	if err != nil {
-		return nil, err
-	}
-	resp.Response.Body.Close() // 关闭原始body
-	return &httpResponse{resp: resp.Response, body: body}, nil
+	// 响应体已经在 resthttp.NewResponse 中读取并关闭
+	return &httpResponse{resp: resp.Response, body: resp.Body()}, nil
 }
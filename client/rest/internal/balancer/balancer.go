@@ -0,0 +1,220 @@
+// Package balancer 为 REST 客户端提供基于服务发现的负载均衡，
+// 通过 registry.Watcher 维护节点列表，并使用 selector 为每个请求选择节点
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/resolver/discovery"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/log"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	// Scheme 使用服务发现的地址前缀，例如 discovery:///user-http
+	Scheme = "discovery"
+
+	watchSleep = 500 * time.Millisecond
+)
+
+// Balancer 监听服务实例变化，并为每个请求选择一个节点
+type Balancer struct {
+	discovery   registry.Discovery
+	serviceName string
+	selector    selector.Selector
+	filters     []selector.NodeFilter
+	timeout     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	watcher registry.Watcher
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// Target 解析 discovery:///service 形式的地址，返回服务名
+func Target(address string) (string, bool) {
+	u, err := url.Parse(address)
+	if err != nil || u.Scheme != Scheme {
+		return "", false
+	}
+	name := strings.Trim(u.Path, "/")
+	return name, name != ""
+}
+
+// New 创建 Balancer 并在后台开始监听服务实例，
+// timeout 为请求等待首次节点列表的最长时间，filters 在每次选择节点时执行
+func New(d registry.Discovery, serviceName string, builder selector.Builder, timeout time.Duration, filters ...selector.NodeFilter) *Balancer {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Balancer{
+		discovery:   d,
+		serviceName: serviceName,
+		selector:    builder.Build(),
+		filters:     filters,
+		timeout:     timeout,
+		ctx:         ctx,
+		cancel:      cancel,
+		ready:       make(chan struct{}),
+	}
+	go b.watch()
+	return b
+}
+
+// Select 选择一个节点，首次节点列表到达之前最多等待 timeout
+func (b *Balancer) Select(ctx context.Context) (selector.Node, selector.DoneFunc, error) {
+	select {
+	case <-b.ready:
+	default:
+		timer := time.NewTimer(b.timeout)
+		defer timer.Stop()
+		select {
+		case <-b.ready:
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	return b.selector.Select(selector.NewFilterContext(ctx, b.filters...))
+}
+
+// HashKeyContext 在请求没有显式设置哈希键时，使用 key 作为 chash/hash 负载均衡的哈希键
+func HashKeyContext(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	if _, ok := selector.FromHashKeyContext(ctx); ok {
+		return ctx
+	}
+	return selector.NewHashKeyContext(ctx, key)
+}
+
+// Close 停止监听服务实例
+func (b *Balancer) Close() error {
+	b.cancel()
+
+	b.mu.Lock()
+	w := b.watcher
+	b.watcher = nil
+	b.mu.Unlock()
+
+	if w != nil {
+		return w.Stop()
+	}
+	return nil
+}
+
+// watch 创建 watcher 并持续更新节点列表，失败时间隔 watchSleep 重试
+func (b *Balancer) watch() {
+	var (
+		w   registry.Watcher
+		err error
+	)
+	for {
+		w, err = b.discovery.Watch(b.ctx, b.serviceName)
+		if err == nil {
+			break
+		}
+		if b.ctx.Err() != nil {
+			return
+		}
+		log.Errorf("[Rest] failed to watch service %s: %v", b.serviceName, err)
+		time.Sleep(watchSleep)
+	}
+
+	b.mu.Lock()
+	if b.ctx.Err() != nil {
+		b.mu.Unlock()
+		_ = w.Stop()
+		return
+	}
+	b.watcher = w
+	b.mu.Unlock()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		default:
+		}
+		ins, err := w.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) || b.ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[Rest] failed to watch service %s: %v", b.serviceName, err)
+			time.Sleep(watchSleep)
+			continue
+		}
+		b.update(ins)
+	}
+}
+
+func (b *Balancer) update(ins []*registry.ServiceInstance) {
+	nodes := make([]selector.Node, 0, len(ins))
+	seen := make(map[string]struct{}, len(ins))
+	for _, in := range ins {
+		scheme, host, err := parseEndpoint(in.Endpoints)
+		if err != nil {
+			log.Errorf("[Rest] failed to parse discovery endpoint: %v", err)
+			continue
+		}
+		if host == "" {
+			continue
+		}
+		if _, ok := seen[host]; ok {
+			continue
+		}
+		seen[host] = struct{}{}
+		nodes = append(nodes, selector.NewNode(scheme, host, in))
+	}
+	if len(nodes) == 0 && len(ins) > 0 {
+		log.Warnf("[Rest] service %s has no http endpoint, keep current nodes", b.serviceName)
+		return
+	}
+	b.selector.Apply(nodes)
+	b.readyOnce.Do(func() { close(b.ready) })
+}
+
+// parseEndpoint 返回第一个 http 或 https 端点，isSecure=true 的端点使用 https
+func parseEndpoint(endpoints []string) (string, string, error) {
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return "", "", err
+		}
+		switch u.Scheme {
+		case "http", "https":
+			scheme := u.Scheme
+			if discovery.IsSecure(u) {
+				scheme = "https"
+			}
+			return scheme, u.Host, nil
+		}
+	}
+	return "", "", nil
+}
+
+// DoneInfo 根据请求结果生成回调信息，5xx 响应视为失败，便于 ewma 等节点统计
+func DoneInfo(statusCode int, md selector.ReplyMD, err error) selector.DoneInfo {
+	if err == nil && statusCode >= http.StatusInternalServerError {
+		err = kerrors.New(statusCode, http.StatusText(statusCode), "upstream responded with server error")
+	}
+	return selector.DoneInfo{
+		Err:           err,
+		ReplyMD:       md,
+		BytesSent:     true,
+		BytesReceived: statusCode != 0,
+	}
+}
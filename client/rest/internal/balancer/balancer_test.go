@@ -0,0 +1,66 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/taluos/Malt/core/selector"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarget(t *testing.T) {
+	name, ok := Target("discovery:///user-http")
+	assert.True(t, ok)
+	assert.Equal(t, "user-http", name)
+
+	_, ok = Target("http://127.0.0.1:8080")
+	assert.False(t, ok)
+	_, ok = Target("discovery:///")
+	assert.False(t, ok)
+}
+
+func TestParseEndpoint(t *testing.T) {
+	scheme, host, err := parseEndpoint([]string{"grpc://127.0.0.1:9000", "http://127.0.0.1:8080"})
+	require.NoError(t, err)
+	assert.Equal(t, "http", scheme)
+	assert.Equal(t, "127.0.0.1:8080", host)
+
+	scheme, host, err = parseEndpoint([]string{"http://127.0.0.1:8443?isSecure=true"})
+	require.NoError(t, err)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "127.0.0.1:8443", host)
+
+	_, host, err = parseEndpoint([]string{"grpc://127.0.0.1:9000"})
+	require.NoError(t, err)
+	assert.Empty(t, host)
+}
+
+func TestDoneInfo(t *testing.T) {
+	di := DoneInfo(http.StatusOK, http.Header{"X-Md": []string{"v"}}, nil)
+	assert.NoError(t, di.Err)
+	assert.True(t, di.BytesReceived)
+	assert.Equal(t, "v", di.ReplyMD.Get("X-Md"))
+
+	di = DoneInfo(http.StatusServiceUnavailable, nil, nil)
+	assert.True(t, kerrors.IsServiceUnavailable(di.Err))
+
+	di = DoneInfo(0, nil, http.ErrHandlerTimeout)
+	assert.ErrorIs(t, di.Err, http.ErrHandlerTimeout)
+	assert.False(t, di.BytesReceived)
+}
+
+func TestHashKeyContext(t *testing.T) {
+	_, ok := selector.FromHashKeyContext(HashKeyContext(context.Background(), ""))
+	assert.False(t, ok)
+
+	key, _ := selector.FromHashKeyContext(HashKeyContext(context.Background(), "u1"))
+	assert.Equal(t, "u1", key)
+
+	// 显式设置的哈希键优先
+	key, _ = selector.FromHashKeyContext(HashKeyContext(selector.NewHashKeyContext(context.Background(), "u2"), "u1"))
+	assert.Equal(t, "u2", key)
+}
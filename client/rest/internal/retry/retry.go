@@ -0,0 +1,197 @@
+// Package retry implements the retry policy shared by the REST clients.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/taluos/Malt/pkg/mathx"
+)
+
+const (
+	defaultBaseDelay = 100 * time.Millisecond
+	defaultMaxDelay  = 2 * time.Second
+	defaultJitter    = 0.2
+)
+
+// OnRetryFunc 每次重试前调用，attempt 从 1 开始，statusCode 为上一次请求的状态码（请求失败时为 0）
+type OnRetryFunc func(attempt int, statusCode int, err error, wait time.Duration)
+
+// Policy 重试策略：指数退避加抖动，按状态码和错误类型判断是否重试
+type Policy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	unstable   mathx.Unstable
+
+	statusCodes map[int]struct{}
+	methods     map[string]struct{}
+	retryable   func(error) bool
+	onRetry     []OnRetryFunc
+}
+
+// Option 自定义重试策略
+type Option func(*Policy)
+
+// WithBackoff 设置退避的初始间隔和最大间隔
+func WithBackoff(baseDelay, maxDelay time.Duration) Option {
+	return func(p *Policy) {
+		if baseDelay > 0 {
+			p.baseDelay = baseDelay
+		}
+		if maxDelay > 0 {
+			p.maxDelay = maxDelay
+		}
+	}
+}
+
+// WithJitter 设置退避间隔的抖动比例，取值 [0, 1]
+func WithJitter(deviation float64) Option {
+	return func(p *Policy) {
+		p.unstable = mathx.NewUnstable(deviation)
+	}
+}
+
+// WithStatusCodes 替换需要重试的状态码
+func WithStatusCodes(codes ...int) Option {
+	return func(p *Policy) {
+		p.statusCodes = make(map[int]struct{}, len(codes))
+		for _, code := range codes {
+			p.statusCodes[code] = struct{}{}
+		}
+	}
+}
+
+// WithMethods 追加允许重试的方法，例如显式允许 POST
+func WithMethods(methods ...string) Option {
+	return func(p *Policy) {
+		for _, method := range methods {
+			p.methods[strings.ToUpper(method)] = struct{}{}
+		}
+	}
+}
+
+// WithRetryable 设置判断错误是否可以重试的函数
+func WithRetryable(retryable func(error) bool) Option {
+	return func(p *Policy) {
+		p.retryable = retryable
+	}
+}
+
+// WithOnRetry 追加重试钩子
+func WithOnRetry(fn ...OnRetryFunc) Option {
+	return func(p *Policy) {
+		p.onRetry = append(p.onRetry, fn...)
+	}
+}
+
+// New 创建重试策略，maxRetries 为首次请求之外的最大重试次数
+func New(maxRetries int, opts ...Option) *Policy {
+	p := &Policy{
+		maxRetries: maxRetries,
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+		unstable:   mathx.NewUnstable(defaultJitter),
+		statusCodes: map[int]struct{}{
+			http.StatusTooManyRequests:    {},
+			http.StatusBadGateway:         {},
+			http.StatusServiceUnavailable: {},
+			http.StatusGatewayTimeout:     {},
+		},
+		// 默认只重试幂等方法
+		methods: map[string]struct{}{
+			http.MethodGet:     {},
+			http.MethodHead:    {},
+			http.MethodOptions: {},
+			http.MethodTrace:   {},
+			http.MethodPut:     {},
+			http.MethodDelete:  {},
+		},
+		retryable: Retryable,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Do 执行 fn 直到成功、不可重试、重试次数用尽或者超出 ctx 的截止时间
+// force 为 true 时忽略方法的幂等性限制
+// 返回最后一次执行的错误，状态码可重试但重试用尽时返回 nil，由调用方处理最后一次的响应
+func (p *Policy) Do(ctx context.Context, method string, force bool, fn func(attempt int) (int, error)) error {
+	_, allowed := p.methods[strings.ToUpper(method)]
+	allowed = allowed || force
+
+	for attempt := 0; ; attempt++ {
+		statusCode, err := fn(attempt)
+		if !allowed || attempt >= p.maxRetries || !p.shouldRetry(statusCode, err) {
+			return err
+		}
+
+		wait := p.backoff(attempt)
+		// 剩余时间不足以等待下一次重试时直接返回
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+
+		for _, hook := range p.onRetry {
+			hook(attempt+1, statusCode, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *Policy) shouldRetry(statusCode int, err error) bool {
+	if err != nil {
+		return p.retryable != nil && p.retryable(err)
+	}
+	_, ok := p.statusCodes[statusCode]
+	return ok
+}
+
+// backoff 返回第 attempt 次失败后的等待时间：base * 2^attempt，不超过 max，并加上抖动
+func (p *Policy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 0; i < attempt && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return p.unstable.AroundDuration(delay)
+}
+
+// Retryable 默认的错误分类：超时、连接被拒绝或重置、连接意外关闭可以重试，上下文取消不重试
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyRetriesStatusCodes(t *testing.T) {
+	var hooks []int
+	p := New(3,
+		WithBackoff(time.Millisecond, 5*time.Millisecond),
+		WithOnRetry(func(attempt int, statusCode int, err error, wait time.Duration) {
+			hooks = append(hooks, attempt)
+		}))
+
+	calls := 0
+	err := p.Do(context.Background(), http.MethodGet, false, func(attempt int) (int, error) {
+		calls++
+		if attempt < 2 {
+			return http.StatusServiceUnavailable, nil
+		}
+		return http.StatusOK, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, hooks)
+}
+
+func TestPolicyMaxRetries(t *testing.T) {
+	p := New(2, WithBackoff(time.Millisecond, time.Millisecond))
+
+	calls := 0
+	err := p.Do(context.Background(), http.MethodGet, false, func(int) (int, error) {
+		calls++
+		return 0, syscall.ECONNREFUSED
+	})
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	assert.Equal(t, 3, calls)
+}
+
+func TestPolicyNonIdempotent(t *testing.T) {
+	p := New(3, WithBackoff(time.Millisecond, time.Millisecond))
+
+	calls := 0
+	fn := func(int) (int, error) {
+		calls++
+		return http.StatusBadGateway, nil
+	}
+
+	// POST 默认不重试
+	assert.NoError(t, p.Do(context.Background(), http.MethodPost, false, fn))
+	assert.Equal(t, 1, calls)
+
+	// 请求显式声明可以重试
+	calls = 0
+	assert.NoError(t, p.Do(context.Background(), http.MethodPost, true, fn))
+	assert.Equal(t, 4, calls)
+
+	// 客户端允许重试 POST
+	calls = 0
+	p = New(1, WithBackoff(time.Millisecond, time.Millisecond), WithMethods("post"))
+	assert.NoError(t, p.Do(context.Background(), http.MethodPost, false, fn))
+	assert.Equal(t, 2, calls)
+}
+
+func TestPolicyNotRetryableError(t *testing.T) {
+	p := New(3, WithBackoff(time.Millisecond, time.Millisecond))
+
+	calls := 0
+	err := p.Do(context.Background(), http.MethodGet, false, func(int) (int, error) {
+		calls++
+		return 0, errors.New("bad request")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPolicyRespectsDeadline(t *testing.T) {
+	p := New(10, WithBackoff(50*time.Millisecond, time.Second), WithJitter(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	_ = p.Do(ctx, http.MethodGet, false, func(int) (int, error) {
+		calls++
+		return http.StatusServiceUnavailable, nil
+	})
+	// 第一次等待 50ms，第二次等待 100ms 超出截止时间
+	assert.Equal(t, 2, calls)
+	assert.Less(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestBackoff(t *testing.T) {
+	p := New(5, WithBackoff(10*time.Millisecond, 40*time.Millisecond), WithJitter(0))
+	assert.Equal(t, 10*time.Millisecond, p.backoff(0))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(5))
+}
@@ -3,18 +3,27 @@ package fasthttp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/taluos/Malt/client/rest/internal/balancer"
+	"github.com/taluos/Malt/client/rest/internal/retry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/p2c"
+	"github.com/taluos/Malt/pkg/log"
+
 	"github.com/valyala/fasthttp"
 )
 
 type Client struct {
-	client  *fasthttp.Client
-	baseURL string
-	opts    *clientOptions
+	client   *fasthttp.Client
+	baseURL  string
+	opts     *clientOptions
+	retry    *retry.Policy
+	balancer *balancer.Balancer
 }
 
 func NewClient(baseURL string, opts ...ClientOption) *Client {
@@ -40,11 +49,43 @@ func NewClient(baseURL string, opts ...ClientOption) *Client {
 		WriteTimeout:        o.writeTimeout,
 	}
 
-	return &Client{
+	retryOpts := append([]retry.Option{
+		retry.WithRetryable(retryable),
+		retry.WithOnRetry(logRetry),
+	}, o.retryOpts...)
+
+	cli := &Client{
 		client:  client,
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		opts:    o,
+		retry:   retry.New(o.retryCount, retryOpts...),
+	}
+
+	// discovery:///service 形式的地址通过服务发现选择节点
+	if name, ok := balancer.Target(baseURL); ok {
+		if o.discovery == nil {
+			log.Errorf("[Rest] discovery is required for address %s", baseURL)
+		} else {
+			cli.baseURL = ""
+			cli.balancer = balancer.New(o.discovery, name, selectorBuilder(o.selector, o.outlier), o.timeout, o.nodeFilters...)
+		}
 	}
+
+	return cli
+}
+
+// selectorBuilder 依次使用指定的选择器、全局选择器和 p2c，outlier 不为空时启用异常节点摘除
+func selectorBuilder(builder selector.Builder, outlier *selector.OutlierDetection) selector.Builder {
+	if builder == nil {
+		builder = selector.GlobalSelector()
+	}
+	if builder == nil {
+		builder = p2c.NewBuilder()
+	}
+	if outlier != nil {
+		builder = selector.Configure(builder, selector.WithOutlierDetection(outlier))
+	}
+	return builder
 }
 
 func (c *Client) Get(ctx context.Context, path string, opts ...RequestOption) (*Response, error) {
@@ -73,7 +114,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		opt(reqOpts)
 	}
 
-	// 构建URL
+	// 构建URL，使用服务发现时每次请求再填充节点地址
 	fullURL := c.baseURL + "/" + strings.TrimPrefix(path, "/")
 	if len(reqOpts.queryParams) > 0 {
 		u, err := url.Parse(fullURL)
@@ -120,8 +161,49 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		req.Header.SetContentType("application/json")
 	}
 
-	// 执行请求
-	err := c.client.DoTimeout(req, resp, c.opts.timeout)
+	if c.opts.hashKeyHeader != "" {
+		ctx = balancer.HashKeyContext(ctx, string(req.Header.Peek(c.opts.hashKeyHeader)))
+	}
+
+	// 执行请求，请求体保存在 req 中，重试时可以直接复用
+	err := c.retry.Do(ctx, method, reqOpts.retryable, func(attempt int) (int, error) {
+		resp.Reset()
+
+		// 单次请求的超时时间不超过 ctx 的剩余时间
+		timeout := c.opts.timeout
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return 0, context.DeadlineExceeded
+			}
+			if remaining < timeout {
+				timeout = remaining
+			}
+		}
+
+		// 每次请求重新选择节点，并将结果回传给选择器
+		if c.balancer != nil {
+			node, done, err := c.balancer.Select(ctx)
+			if err != nil {
+				return 0, err
+			}
+			req.URI().SetScheme(node.Scheme())
+			req.URI().SetHost(node.Address())
+
+			err = c.do(req, resp, timeout)
+			if err != nil {
+				done(ctx, balancer.DoneInfo(0, nil, err))
+				return 0, err
+			}
+			done(ctx, balancer.DoneInfo(resp.StatusCode(), replyMD{&resp.Header}, nil))
+			return resp.StatusCode(), nil
+		}
+
+		if err := c.do(req, resp, timeout); err != nil {
+			return 0, err
+		}
+		return resp.StatusCode(), nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("do request: %w", err)
 	}
@@ -135,7 +217,52 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	return response, nil
 }
 
+// do 执行单次请求，配置了熔断器时按 host 熔断，请求错误和 5xx 响应计入失败
+func (c *Client) do(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error {
+	if c.opts.breaker == nil {
+		return c.client.DoTimeout(req, resp, timeout)
+	}
+
+	b := c.opts.breaker.Get(string(req.URI().Host()))
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := c.client.DoTimeout(req, resp, timeout)
+	if err != nil || resp.StatusCode() >= fasthttp.StatusInternalServerError {
+		b.MarkFailed()
+	} else {
+		b.MarkSuccess()
+	}
+	return err
+}
+
+// retryable 在默认分类的基础上识别 fasthttp 的连接错误
+func retryable(err error) bool {
+	if errors.Is(err, fasthttp.ErrConnectionClosed) || errors.Is(err, fasthttp.ErrNoFreeConns) {
+		return true
+	}
+	return retry.Retryable(err)
+}
+
+// logRetry 记录每一次重试
+func logRetry(attempt int, statusCode int, err error, wait time.Duration) {
+	log.Warnf("[Rest] retry attempt %d after %s, status: %d, error: %v", attempt, wait, statusCode, err)
+}
+
+// replyMD 将 fasthttp 响应头适配为 selector.ReplyMD
+type replyMD struct {
+	header *fasthttp.ResponseHeader
+}
+
+func (r replyMD) Get(key string) string {
+	return string(r.header.Peek(key))
+}
+
 func (c *Client) Close() error {
-	// FastHTTP客户端通常不需要显式关闭
+	// FastHTTP客户端通常不需要显式关闭，只需停止服务发现
+	if c.balancer != nil {
+		return c.balancer.Close()
+	}
 	return nil
 }
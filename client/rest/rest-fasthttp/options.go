@@ -1,6 +1,13 @@
 package fasthttp
 
-import "time"
+import (
+	"time"
+
+	"github.com/taluos/Malt/client/rest/internal/retry"
+	"github.com/taluos/Malt/core/breaker"
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+)
 
 type clientOptions struct {
 	timeout             time.Duration
@@ -11,6 +18,13 @@ type clientOptions struct {
 	maxIdleConnDuration time.Duration
 	readTimeout         time.Duration
 	writeTimeout        time.Duration
+	retryOpts           []retry.Option
+	discovery           registry.Discovery
+	selector            selector.Builder
+	nodeFilters         []selector.NodeFilter
+	outlier             *selector.OutlierDetection // 异常节点摘除配置，nil 表示不启用
+	hashKeyHeader       string
+	breaker             *breaker.Group
 }
 
 type ClientOption func(*clientOptions)
@@ -62,3 +76,89 @@ func WithWriteTimeout(timeout time.Duration) ClientOption {
 		c.writeTimeout = timeout
 	}
 }
+
+// WithRetryBackoff 设置重试退避的初始间隔和最大间隔
+func WithRetryBackoff(baseDelay, maxDelay time.Duration) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithBackoff(baseDelay, maxDelay))
+	}
+}
+
+// WithRetryJitter 设置重试退避的抖动比例，取值 [0, 1]
+func WithRetryJitter(deviation float64) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithJitter(deviation))
+	}
+}
+
+// WithRetryStatusCodes 设置需要重试的状态码，默认 429、502、503、504
+func WithRetryStatusCodes(codes ...int) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithStatusCodes(codes...))
+	}
+}
+
+// WithRetryMethods 允许重试非幂等方法，例如 POST
+func WithRetryMethods(methods ...string) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithMethods(methods...))
+	}
+}
+
+// WithRetryOnError 设置判断请求错误是否可以重试的函数
+func WithRetryOnError(retryable func(error) bool) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithRetryable(retryable))
+	}
+}
+
+// WithRetryHook 追加重试钩子，每次重试前调用
+func WithRetryHook(hook func(attempt int, statusCode int, err error, wait time.Duration)) ClientOption {
+	return func(c *clientOptions) {
+		c.retryOpts = append(c.retryOpts, retry.WithOnRetry(hook))
+	}
+}
+
+// WithDiscovery 设置服务发现，地址为 discovery:///service 时按服务名发现节点
+func WithDiscovery(discovery registry.Discovery) ClientOption {
+	return func(c *clientOptions) {
+		c.discovery = discovery
+	}
+}
+
+// WithSelector 设置节点选择器，默认使用全局选择器，未设置时使用 p2c
+func WithSelector(builder selector.Builder) ClientOption {
+	return func(c *clientOptions) {
+		c.selector = builder
+	}
+}
+
+// WithOutlierDetection 为服务发现的节点启用异常节点摘除，返回 5xx 或连接失败的节点
+// 在摘除期间不再接收请求，只对 selector.DefaultBuilder 构建的选择器生效
+func WithOutlierDetection(cfg *selector.OutlierDetection) ClientOption {
+	return func(c *clientOptions) {
+		c.outlier = cfg
+	}
+}
+
+// WithNodeFilter 在负载均衡之前按版本、元数据等过滤节点，单次请求的过滤器可以通过 selector.NewFilterContext 设置
+func WithNodeFilter(filters ...selector.NodeFilter) ClientOption {
+	return func(c *clientOptions) {
+		c.nodeFilters = append(c.nodeFilters, filters...)
+	}
+}
+
+// WithHashKeyHeader 使用请求头 name 的值作为 chash/hash 负载均衡的哈希键，
+// 单次请求也可以通过 selector.NewHashKeyContext 显式设置
+func WithHashKeyHeader(name string) ClientOption {
+	return func(c *clientOptions) {
+		c.hashKeyHeader = name
+	}
+}
+
+// WithBreaker 设置熔断器组，每个 host 使用独立的熔断器
+func WithBreaker(group *breaker.Group) ClientOption {
+	return func(c *clientOptions) {
+		c.breaker = group
+	}
+}
@@ -9,14 +9,21 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/taluos/Malt/client/rest/internal/balancer"
+	"github.com/taluos/Malt/client/rest/internal/retry"
 	"github.com/taluos/Malt/client/rest/rest-http/internal/interceptors"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/p2c"
 	"github.com/taluos/Malt/pkg/log"
 )
 
 type Client struct {
 	*http.Client
-	opts *clientOptions
+	opts     *clientOptions
+	retry    *retry.Policy
+	balancer *balancer.Balancer
 }
 
 func NewClient(baseURL string, opts ...ClientOption) *Client {
@@ -36,11 +43,39 @@ func NewClient(baseURL string, opts ...ClientOption) *Client {
 	if transport == nil {
 		transport = http.DefaultTransport
 	}
-	cli := &Client{&http.Client{Timeout: o.timeout, Transport: transport}, o}
+	retryOpts := append([]retry.Option{retry.WithOnRetry(logRetry)}, o.retryOpts...)
+	cli := &Client{
+		Client: &http.Client{Timeout: o.timeout, Transport: transport},
+		opts:   o,
+		retry:  retry.New(o.retryCount, retryOpts...),
+	}
+
+	// discovery:///service 形式的地址通过服务发现选择节点
+	if name, ok := balancer.Target(o.address); ok {
+		if o.discovery == nil {
+			log.Errorf("[Rest] discovery is required for address %s", o.address)
+		} else {
+			cli.balancer = balancer.New(o.discovery, name, selectorBuilder(o.selector, o.outlier), o.timeout, o.nodeFilters...)
+		}
+	}
 
 	return cli
 }
 
+// selectorBuilder 依次使用指定的选择器、全局选择器和 p2c，outlier 不为空时启用异常节点摘除
+func selectorBuilder(builder selector.Builder, outlier *selector.OutlierDetection) selector.Builder {
+	if builder == nil {
+		builder = selector.GlobalSelector()
+	}
+	if builder == nil {
+		builder = p2c.NewBuilder()
+	}
+	if outlier != nil {
+		builder = selector.Configure(builder, selector.WithOutlierDetection(outlier))
+	}
+	return builder
+}
+
 func (c *Client) Get(ctx context.Context, path string, opts ...RequestOption) (*Response, error) {
 	return c.doRequest(ctx, http.MethodGet, path, nil, opts...)
 }
@@ -67,8 +102,12 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		opt(reqOpts)
 	}
 
-	// 构建URL
-	fullURL := c.opts.address + "/" + strings.TrimPrefix(path, "/")
+	// 构建URL，使用服务发现时每次请求再填充节点地址
+	baseURL := c.opts.address
+	if c.balancer != nil {
+		baseURL = ""
+	}
+	fullURL := baseURL + "/" + strings.TrimPrefix(path, "/")
 	if len(reqOpts.queryParams) > 0 {
 		u, err := url.Parse(fullURL)
 		if err != nil {
@@ -115,16 +154,10 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	}
 
 	// 执行拦截器链
-	return c.executeWithInterceptors(ctx, req)
+	return c.executeWithInterceptors(ctx, req, reqOpts.retryable)
 }
 
-func (c *Client) executeWithInterceptors(ctx context.Context, req *http.Request) (*Response, error) {
-	if len(c.opts.interceptors) == 0 {
-		res, err := c.Do(req)
-		response := NewResponse(res)
-		return response, err
-	}
-
+func (c *Client) executeWithInterceptors(ctx context.Context, req *http.Request, retryable bool) (*Response, error) {
 	// 构建拦截器链
 	var handler interceptors.RoundTripper
 	handler = func(ctx context.Context, req *http.Request) (*http.Response, error) {
@@ -139,9 +172,70 @@ func (c *Client) executeWithInterceptors(ctx context.Context, req *http.Request)
 			return interceptor.Intercept(ctx, req, next)
 		}
 	}
-	res, err := handler(ctx, req)
-	response := NewResponse(res)
-	return response, err
+
+	if c.opts.hashKeyHeader != "" {
+		ctx = balancer.HashKeyContext(ctx, req.Header.Get(c.opts.hashKeyHeader))
+	}
+
+	// 每次重试都会重新经过拦截器链
+	var res *http.Response
+	err := c.retry.Do(ctx, req.Method, retryable, func(attempt int) (int, error) {
+		// 丢弃上一次的响应，复用连接
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+			res = nil
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			// 重新生成请求体，保证每次重试发送完整的请求体
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return 0, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		// 每次请求重新选择节点，并将结果回传给选择器
+		var done selector.DoneFunc
+		if c.balancer != nil {
+			node, d, err := c.balancer.Select(ctx)
+			if err != nil {
+				return 0, err
+			}
+			attemptReq.URL.Scheme = node.Scheme()
+			attemptReq.URL.Host = node.Address()
+			attemptReq.Host = node.Address()
+			done = d
+		}
+
+		var err error
+		res, err = handler(ctx, attemptReq)
+		if done != nil {
+			if res != nil {
+				done(ctx, balancer.DoneInfo(res.StatusCode, res.Header, err))
+			} else {
+				done(ctx, balancer.DoneInfo(0, nil, err))
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		return res.StatusCode, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewResponse(res), nil
+}
+
+// logRetry 记录每一次重试
+func logRetry(attempt int, statusCode int, err error, wait time.Duration) {
+	log.Warnf("[Rest] retry attempt %d after %s, status: %d, error: %v", attempt, wait, statusCode, err)
 }
 
 func (c *Client) Close(ctx context.Context) error {
@@ -152,6 +246,12 @@ func (c *Client) Close(ctx context.Context) error {
 		defer cancel()
 	}
 
+	if c.balancer != nil {
+		if err := c.balancer.Close(); err != nil {
+			log.Errorf("[Rest] stop discovery watcher error: %s", err)
+		}
+	}
+
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
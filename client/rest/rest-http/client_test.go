@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/breaker"
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/wrr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRetryReplaysBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	var attempts []int
+	cli := NewClient(srv.URL,
+		WithRetryBackoff(time.Millisecond, 5*time.Millisecond),
+		WithRetryHook(func(attempt int, statusCode int, err error, wait time.Duration) {
+			attempts = append(attempts, statusCode)
+		}))
+
+	resp, err := cli.Put(context.Background(), "/echo", map[string]string{"k": "v"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.JSONEq(t, `{"k":"v"}`, resp.String())
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, attempts)
+}
+
+func TestClientPostNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cli := NewClient(srv.URL, WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	resp, err := cli.Post(context.Background(), "/orders", map[string]string{"k": "v"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode())
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 请求声明幂等后可以重试
+	calls.Store(0)
+	_, err = cli.Post(context.Background(), "/orders", map[string]string{"k": "v"}, WithRetryable(true))
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+type fakeWatcher struct {
+	updates chan []*registry.ServiceInstance
+	stop    chan struct{}
+}
+
+func (w *fakeWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case ins := <-w.updates:
+		return ins, nil
+	case <-w.stop:
+		return nil, context.Canceled
+	}
+}
+
+func (w *fakeWatcher) Stop() error {
+	close(w.stop)
+	return nil
+}
+
+type fakeDiscovery struct {
+	watcher *fakeWatcher
+}
+
+func (d *fakeDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *fakeDiscovery) Watch(context.Context, string) (registry.Watcher, error) {
+	return d.watcher, nil
+}
+
+func TestClientDiscovery(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	srvA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitsA.Add(1)
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srvA.Close()
+	srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitsB.Add(1)
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srvB.Close()
+
+	watcher := &fakeWatcher{
+		updates: make(chan []*registry.ServiceInstance, 1),
+		stop:    make(chan struct{}),
+	}
+	watcher.updates <- []*registry.ServiceInstance{
+		{ID: "a", Name: "user-http", Endpoints: []string{"grpc://127.0.0.1:9000", srvA.URL}},
+		{ID: "b", Name: "user-http", Endpoints: []string{srvB.URL}},
+	}
+
+	cli := NewClient("discovery:///user-http",
+		WithDiscovery(&fakeDiscovery{watcher: watcher}),
+		WithSelector(wrr.NewBuilder()),
+		WithTimeout(time.Second))
+
+	for i := 0; i < 4; i++ {
+		resp, err := cli.Get(context.Background(), "/users/1", WithQueryParam("q", "1"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.Equal(t, "/users/1", resp.String())
+	}
+	assert.Equal(t, int32(2), hitsA.Load())
+	assert.Equal(t, int32(2), hitsB.Load())
+
+	require.NoError(t, cli.Close(context.Background()))
+	select {
+	case <-watcher.stop:
+	default:
+		t.Fatal("watcher not stopped")
+	}
+}
+
+func TestClientOutlierDetection(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	srvA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitsA.Add(1)
+	}))
+	defer srvA.Close()
+	srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitsB.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srvB.Close()
+
+	watcher := &fakeWatcher{
+		updates: make(chan []*registry.ServiceInstance, 1),
+		stop:    make(chan struct{}),
+	}
+	watcher.updates <- []*registry.ServiceInstance{
+		{ID: "a", Name: "user-http", Endpoints: []string{srvA.URL}},
+		{ID: "b", Name: "user-http", Endpoints: []string{srvB.URL}},
+	}
+
+	cfg := selector.DefaultOutlierDetection()
+	cfg.ConsecutiveErrors = 2
+	cli := NewClient("discovery:///user-http",
+		WithDiscovery(&fakeDiscovery{watcher: watcher}),
+		WithSelector(wrr.NewBuilder()),
+		WithOutlierDetection(cfg),
+		WithRetryCount(0),
+		WithTimeout(time.Second))
+	defer cli.Close(context.Background())
+
+	// 异常节点连续返回 ConsecutiveErrors 次 5xx 之后被摘除，之后的请求都落在正常节点上
+	for i := 0; i < 10; i++ {
+		_, err := cli.Get(context.Background(), "/users/1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(cfg.ConsecutiveErrors), hitsB.Load())
+	assert.Equal(t, int32(10-cfg.ConsecutiveErrors), hitsA.Load())
+}
+
+func TestClientDiscoveryNoNode(t *testing.T) {
+	watcher := &fakeWatcher{
+		updates: make(chan []*registry.ServiceInstance, 1),
+		stop:    make(chan struct{}),
+	}
+	cli := NewClient("discovery:///user-http",
+		WithDiscovery(&fakeDiscovery{watcher: watcher}),
+		WithTimeout(50*time.Millisecond))
+	defer cli.Close(context.Background())
+
+	_, err := cli.Get(context.Background(), "/users/1")
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "no_available_node"))
+}
+
+func TestClientBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cli := NewClient(srv.URL,
+		WithRetryCount(0),
+		WithBreaker(breaker.NewGroup(func() breaker.Breaker {
+			return breaker.NewClassicBreaker(breaker.WithRequest(2), breaker.WithOpenTimeout(time.Minute))
+		})))
+
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Get(context.Background(), "/users")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode())
+	}
+
+	_, err := cli.Get(context.Background(), "/users")
+	assert.ErrorIs(t, err, breaker.ErrNotAllowed)
+	assert.Equal(t, int32(2), calls.Load())
+}
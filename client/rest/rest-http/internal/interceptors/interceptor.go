@@ -3,6 +3,8 @@ package interceptors
 import (
 	"context"
 	"net/http"
+
+	"github.com/taluos/Malt/core/breaker"
 )
 
 type Interceptor interface {
@@ -24,3 +26,27 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, req *http.Request, next
 	req.Header.Set("Authorization", "Bearer "+a.token)
 	return next(ctx, req)
 }
+
+// 熔断拦截器，按 host 熔断，请求错误和 5xx 响应计入失败
+type BreakerInterceptor struct {
+	group *breaker.Group
+}
+
+func NewBreakerInterceptor(group *breaker.Group) *BreakerInterceptor {
+	return &BreakerInterceptor{group: group}
+}
+
+func (b *BreakerInterceptor) Intercept(ctx context.Context, req *http.Request, next RoundTripper) (*http.Response, error) {
+	brk := b.group.Get(req.URL.Host)
+	if err := brk.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := next(ctx, req)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		brk.MarkFailed()
+	} else {
+		brk.MarkSuccess()
+	}
+	return resp, err
+}
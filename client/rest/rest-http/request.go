@@ -5,6 +5,7 @@ type RequestOption func(*requestOptions)
 type requestOptions struct {
 	headers     map[string]string
 	queryParams map[string]string
+	retryable   bool
 }
 
 func WithRequestHeader(key, value string) RequestOption {
@@ -24,3 +25,10 @@ func WithQueryParam(key, value string) RequestOption {
 		r.queryParams[key] = value
 	}
 }
+
+// WithRetryable 声明请求是幂等的，允许重试非幂等方法，例如带幂等键的 POST
+func WithRetryable(retryable bool) RequestOption {
+	return func(r *requestOptions) {
+		r.retryable = retryable
+	}
+}
@@ -13,6 +13,7 @@ type Response struct {
 }
 
 func NewResponse(resp *http.Response) *Response {
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil
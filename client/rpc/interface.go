@@ -27,6 +27,8 @@ func NewClient(method string, opts ...ClientOptions) (Client, error) {
 	switch method {
 	case GRPCClientType:
 		return newGrpcClient(opts...)
+	case KitexClientType:
+		return newKitexClient(opts...)
 	default:
 		return nil, errors.New(fmt.Sprintf("[RPC] unsupported client type: %s", method))
 	}
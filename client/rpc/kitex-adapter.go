@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+
+	kitex "github.com/taluos/Malt/client/rpc/rpc-kitex"
+)
+
+// kitexClient 是基于Kitex的Client实现
+type kitexClient struct {
+	client *kitex.Client
+}
+
+// 确保kitexClient实现了Client接口
+var _ Client = (*kitexClient)(nil)
+
+// newKitexClient 创建一个新的基于Kitex的客户端
+func newKitexClient(opts ...ClientOptions) (Client, error) {
+	clientOpts := make([]kitex.ClientOptions, 0, len(opts))
+	for _, opt := range opts {
+		if co, ok := opt.(kitex.ClientOptions); ok {
+			clientOpts = append(clientOpts, co)
+		}
+	}
+	client, err := kitex.NewClient(clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &kitexClient{
+		client: client,
+	}, nil
+}
+
+// Endpoint 实现Client.Endpoint
+func (c *kitexClient) Endpoint() string {
+	return c.client.Endpoint()
+}
+
+// Close 实现Client.Close
+func (c *kitexClient) Close(ctx context.Context) error {
+	return c.client.Close(ctx)
+}
+
+// Conn 实现Client.Conn
+func (c *kitexClient) Conn() any {
+	return c.client.Client
+}
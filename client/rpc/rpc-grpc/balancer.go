@@ -1,12 +1,26 @@
 package grpc
 
 import (
+	"encoding/json"
+
 	"github.com/taluos/Malt/core/registry"
 	"github.com/taluos/Malt/core/selector"
+	_ "github.com/taluos/Malt/core/selector/picker/chash"
+	_ "github.com/taluos/Malt/core/selector/picker/hash"
+	_ "github.com/taluos/Malt/core/selector/picker/leastreq"
+	_ "github.com/taluos/Malt/core/selector/picker/maglev"
+	_ "github.com/taluos/Malt/core/selector/picker/p2c"
+	_ "github.com/taluos/Malt/core/selector/picker/random"
+	_ "github.com/taluos/Malt/core/selector/picker/rr"
+	_ "github.com/taluos/Malt/core/selector/picker/wrandom"
+	_ "github.com/taluos/Malt/core/selector/picker/wrr"
+	"github.com/taluos/Malt/pkg/errors"
 
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/serviceconfig"
 )
 
 const (
@@ -14,12 +28,21 @@ const (
 )
 
 var (
-	_ base.PickerBuilder = &balancerBuilder{}
-	_ balancer.Picker    = &balancerPicker{}
+	_ balancer.Builder      = &builder{}
+	_ balancer.ConfigParser = &builder{}
+	_ base.PickerBuilder    = &balancerBuilder{}
+	_ balancer.Picker       = &balancerPicker{}
 )
 
+// builder creates a balancer with its own selector for each ClientConn,
+// so the node statistics survive picker rebuilds.
+type builder struct {
+	name    string
+	builder selector.Builder // nil looks up the selector builder registered with name
+}
+
 type balancerBuilder struct {
-	builder selector.Builder
+	selector selector.Selector
 }
 
 // balancerPicker is a grpc picker.
@@ -27,17 +50,128 @@ type balancerPicker struct {
 	selector selector.Selector
 }
 
+// 每个 picker 按名称注册为 gRPC 负载均衡器，WithBalancerName 可以直接选择，如 p2c、leastreq、maglev
+func init() {
+	for _, name := range selector.Names() {
+		_ = RegisterBalancer(name)
+	}
+}
+
+// RegisterBalancer 将 selector.Register 注册的 picker 注册为同名的 gRPC 负载均衡器。
+// 本包导入时已经注册的 picker 会自动注册，之后调用 selector.Register 的自定义 picker
+// 需要在创建客户端之前调用 RegisterBalancer，才能通过 WithBalancerName 选择。
+// name 没有通过 selector.Register 注册时返回错误
+func RegisterBalancer(name string) error {
+	if _, ok := selector.GetBuilder(name); !ok {
+		return errors.Errorf("selector %q is not registered", name)
+	}
+	balancer.Register(&builder{name: name})
+	return nil
+}
+
+// InitBuilder 把全局选择器注册为名为 selector 的负载均衡器
 func InitBuilder() {
-	b := base.NewBalancerBuilder(
-		balancerName,
-		&balancerBuilder{
-			builder: selector.GlobalSelector(),
-		},
-		base.Config{HealthCheck: true},
-	)
-	balancer.Register(b)
+	balancer.Register(&builder{name: balancerName, builder: selector.GlobalSelector()})
+}
+
+// Build creates a balancer for cc.
+func (b *builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	sb := b.builder
+	if sb == nil {
+		// looked up on every connection so a later selector.Register takes effect
+		sb, _ = selector.GetBuilder(b.name)
+	}
+	if sb == nil {
+		// InitBuilder without a global selector, every RPC fails instead of panicking here
+		return &errBalancer{cc: cc, err: errors.Errorf("selector %q is not registered", b.name)}
+	}
+	s := sb.Build()
+	return &selectorBalancer{
+		Balancer: base.NewBalancerBuilder(
+			b.name,
+			&balancerBuilder{selector: s},
+			base.Config{HealthCheck: true},
+		).Build(cc, opts),
+		selector: s,
+	}
+}
+
+// Name returns the balancer name.
+func (b *builder) Name() string {
+	return b.name
+}
+
+// ParseConfig parses the loadBalancingConfig of the balancer in the service config.
+func (b *builder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	cfg := &lbConfig{}
+	if err := json.Unmarshal(js, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// lbConfig 每个 ClientConn 的负载均衡配置，由 WithOutlierDetection 通过 service config 传入
+type lbConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	OutlierDetection *selector.OutlierDetection `json:"outlierDetection,omitempty"`
+}
+
+// serviceConfig 返回使用负载均衡器 name 的 service config，outlier 不为空时启用异常节点摘除
+func serviceConfig(name string, outlier *selector.OutlierDetection) string {
+	if outlier == nil {
+		return `{"loadBalancingPolicy": "` + name + `"}`
+	}
+	js, _ := json.Marshal(map[string]any{
+		"loadBalancingConfig": []map[string]any{{name: &lbConfig{OutlierDetection: outlier}}},
+	})
+	return string(js)
+}
+
+// selectorBalancer 在节点应用到选择器之前读取 ClientConn 的负载均衡配置
+type selectorBalancer struct {
+	balancer.Balancer
+	selector selector.Selector
+}
+
+// UpdateClientConnState enables the outlier detection of the ClientConn before the nodes are applied,
+// gRPC serializes the calls so it does not race with Apply.
+func (b *selectorBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*lbConfig); ok && cfg.OutlierDetection != nil {
+		if d, ok := b.selector.(*selector.Default); ok && d.Outlier == nil {
+			d.Outlier = cfg.OutlierDetection
+		}
+	}
+	return b.Balancer.UpdateClientConnState(s)
 }
 
+// ExitIdle forwards to the base balancer.
+func (b *selectorBalancer) ExitIdle() {
+	if ei, ok := b.Balancer.(balancer.ExitIdler); ok {
+		ei.ExitIdle()
+	}
+}
+
+// errBalancer fails every RPC with err, it is used when the selector builder is missing.
+type errBalancer struct {
+	cc  balancer.ClientConn
+	err error
+}
+
+func (b *errBalancer) UpdateClientConnState(balancer.ClientConnState) error {
+	b.cc.UpdateState(balancer.State{
+		ConnectivityState: connectivity.TransientFailure,
+		Picker:            base.NewErrPicker(b.err),
+	})
+	return nil
+}
+
+func (b *errBalancer) ResolverError(error) {}
+
+func (b *errBalancer) UpdateSubConnState(balancer.SubConn, balancer.SubConnState) {}
+
+func (b *errBalancer) Close() {}
+
 // Build creates a grpc Picker.
 func (b *balancerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 	if len(info.ReadySCs) == 0 {
@@ -53,11 +187,8 @@ func (b *balancerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 			subConn: conn,
 		})
 	}
-	p := &balancerPicker{
-		selector: b.builder.Build(),
-	}
-	p.selector.Apply(nodes)
-	return p
+	b.selector.Apply(nodes)
+	return &balancerPicker{selector: b.selector}
 }
 
 // Pick pick instances.
@@ -96,3 +227,9 @@ type grpcNode struct {
 	selector.Node
 	subConn balancer.SubConn
 }
+
+// Equal reports whether n uses the same SubConn, the selector rebuilds the node otherwise.
+func (g *grpcNode) Equal(n selector.Node) bool {
+	o, ok := n.(*grpcNode)
+	return ok && o.subConn == g.subConn
+}
@@ -107,6 +107,15 @@ func dial(insecure bool, opts clientOptions) (*grpc.ClientConn, error) {
 	uraryInts := []grpc.UnaryClientInterceptor{
 		interceptors.UnaryTimeoutInterceptor(opts.timeout), // 添加超时拦截器
 	}
+	if len(opts.nodeFilters) > 0 {
+		uraryInts = append(uraryInts, interceptors.UnaryNodeFilterInterceptor(opts.nodeFilters...)) // 添加节点过滤拦截器
+	}
+	if opts.hashKeyMetadata != "" {
+		uraryInts = append(uraryInts, interceptors.UnaryHashKeyInterceptor(opts.hashKeyMetadata)) // 添加哈希键拦截器
+	}
+	if opts.breaker != nil {
+		uraryInts = append(uraryInts, interceptors.UnaryBreakerInterceptor(opts.breaker)) // 添加熔断拦截器
+	}
 	if len(opts.unaryInterceptors) > 0 {
 		uraryInts = append(uraryInts, opts.unaryInterceptors...) // 追加用户传入的拦截器
 	}
@@ -122,13 +131,32 @@ func dial(insecure bool, opts clientOptions) (*grpc.ClientConn, error) {
 			interceptors.UnaryTracingInterceptor(opts.agent))
 	}
 
-	steamInts := []grpc.StreamClientInterceptor{}
+	steamInts := []grpc.StreamClientInterceptor{
+		interceptors.StreamTimeoutInterceptor(opts.streamTimeout, opts.streamIdleTimeout), // 添加流超时拦截器
+	}
+	if len(opts.nodeFilters) > 0 {
+		steamInts = append(steamInts, interceptors.StreamNodeFilterInterceptor(opts.nodeFilters...))
+	}
+	if opts.hashKeyMetadata != "" {
+		steamInts = append(steamInts, interceptors.StreamHashKeyInterceptor(opts.hashKeyMetadata))
+	}
+	if opts.breaker != nil {
+		steamInts = append(steamInts, interceptors.StreamBreakerInterceptor(opts.breaker))
+	}
 	if len(opts.streamInterceptors) > 0 {
 		steamInts = append(steamInts, opts.streamInterceptors...) // 追加用户传入的拦截器
 	}
 
+	if opts.enableMetrics {
+		steamInts = append(steamInts, interceptors.StreamPrometheusInterceptor())
+	}
+
+	if opts.enableTracing {
+		steamInts = append(steamInts, interceptors.StreamTracingInterceptor(opts.agent))
+	}
+
 	grpcOpts := []grpc.DialOption{
-		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "` + opts.balancerName + `"}`),
+		grpc.WithDefaultServiceConfig(serviceConfig(opts.balancerName, opts.outlier)),
 		grpc.WithChainUnaryInterceptor(uraryInts...),
 		grpc.WithChainStreamInterceptor(steamInts...),
 	}
@@ -4,11 +4,19 @@ import (
 	"context"
 	"crypto/tls"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/taluos/Malt/core/selector"
+
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -268,3 +276,124 @@ func TestDial(t *testing.T) {
 		conn.Close()
 	})
 }
+
+// 测试各个 picker 按名称注册为 gRPC 负载均衡器
+func TestPickerBalancers(t *testing.T) {
+	for _, name := range []string{"p2c", "wrr", "leastreq", "maglev", "chash"} {
+		if b := balancer.Get(name); b == nil || b.Name() != name {
+			t.Errorf("负载均衡器 %s 未注册", name)
+		}
+	}
+}
+
+func TestRegisterBalancer(t *testing.T) {
+	const name = "custom_picker"
+	if balancer.Get(name) != nil {
+		t.Fatalf("负载均衡器 %s 不应提前注册", name)
+	}
+
+	builder, _ := selector.GetBuilder("p2c")
+	selector.Register(name, builder)
+	if err := RegisterBalancer(name); err != nil {
+		t.Fatalf("注册负载均衡器失败: %v", err)
+	}
+
+	if b := balancer.Get(name); b == nil || b.Name() != name {
+		t.Errorf("负载均衡器 %s 未注册", name)
+	}
+
+	if err := RegisterBalancer("missing_picker"); err == nil {
+		t.Error("未注册的 picker 应该返回错误")
+	}
+	if balancer.Get("missing_picker") != nil {
+		t.Error("未注册的 picker 不应注册为负载均衡器")
+	}
+}
+
+// 测试找不到选择器时请求返回错误，而不是在创建负载均衡器时 panic
+func TestBalancerWithoutSelector(t *testing.T) {
+	const name = "nil_selector"
+	balancer.Register(&builder{name: name})
+
+	c, err := NewClient(
+		WithEndpoint("direct:///"+startHealthServer(t, health.NewServer())),
+		WithBalancerName(name),
+	)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = grpc_health_v1.NewHealthClient(c).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("应该返回选择器未注册的错误，实际: %v", err)
+	}
+}
+
+// failingHealthServer 总是返回 codes.Unavailable
+type failingHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (failingHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+// startHealthServer 在本地随机端口启动健康检查服务，返回监听地址
+func startHealthServer(t *testing.T, srv grpc_health_v1.HealthServer) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// 测试通过客户端选项启用异常节点摘除，持续失败的节点不再接收请求
+func TestOutlierDetection(t *testing.T) {
+	good := startHealthServer(t, health.NewServer())
+	bad := startHealthServer(t, failingHealthServer{})
+
+	cfg := selector.DefaultOutlierDetection()
+	cfg.ConsecutiveErrors = 2
+	c, err := NewClient(
+		WithEndpoint("direct:///"+good+","+bad),
+		WithBalancerName("wrr"),
+		WithOutlierDetection(cfg),
+	)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	defer c.Close(context.Background())
+
+	hc := grpc_health_v1.NewHealthClient(c)
+	check := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		return err
+	}
+
+	// 两个节点都连接之后，异常节点连续失败 ConsecutiveErrors 次就会被摘除
+	var failures int
+	for i := 0; i < 50; i++ {
+		if check() != nil {
+			failures++
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if failures != cfg.ConsecutiveErrors {
+		t.Errorf("摘除之前应失败 %d 次，实际 %d 次", cfg.ConsecutiveErrors, failures)
+	}
+	for i := 0; i < 10; i++ {
+		if err := check(); err != nil {
+			t.Fatalf("异常节点摘除之后请求失败: %v", err)
+		}
+	}
+}
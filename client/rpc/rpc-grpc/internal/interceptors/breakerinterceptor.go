@@ -0,0 +1,118 @@
+package clientinterceptors
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/taluos/Malt/core/breaker"
+	"github.com/taluos/Malt/pkg/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryBreakerInterceptor 按方法熔断，下游不可用时直接返回 codes.Unavailable
+func UnaryBreakerInterceptor(group *breaker.Group) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := group.Get(method)
+		if err := b.Allow(); err != nil {
+			return rejected(err)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		mark(b, err)
+		return err
+	}
+}
+
+// StreamBreakerInterceptor 按方法熔断，流结束时根据最终的错误记录结果
+func StreamBreakerInterceptor(group *breaker.Group) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := group.Get(method)
+		if err := b.Allow(); err != nil {
+			return nil, rejected(err)
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			mark(b, err)
+			return nil, err
+		}
+		return &breakerClientStream{ClientStream: clientStream, breaker: b, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// errorDomain 错误详情中的 domain，与服务端拦截器保持一致
+const errorDomain = "Malt"
+
+// rejected 将熔断拒绝转换为 codes.Unavailable，调用方可以重试或降级，
+// 错误码放在 errdetails.ErrorInfo 中，用于区分熔断拒绝和下游返回的 Unavailable
+func rejected(err error) error {
+	coder := errors.ParseCoder(err)
+	st := status.New(codes.Unavailable, err.Error())
+	if ds, derr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: strconv.Itoa(coder.Code()),
+		Domain: errorDomain,
+	}); derr == nil {
+		st = ds
+	}
+	return st.Err()
+}
+
+// acceptable 只有表示下游异常的状态码才计入失败
+func acceptable(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss,
+		codes.ResourceExhausted, codes.Unknown:
+		return false
+	default:
+		return true
+	}
+}
+
+func mark(b breaker.Breaker, err error) {
+	if err == nil || acceptable(err) {
+		b.MarkSuccess()
+	} else {
+		b.MarkFailed()
+	}
+}
+
+type breakerClientStream struct {
+	grpc.ClientStream
+	breaker       breaker.Breaker
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *breakerClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && err != io.EOF {
+		s.done(err)
+	}
+	return err
+}
+
+func (s *breakerClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.done(nil)
+	} else if err != nil {
+		s.done(err)
+	} else if !s.serverStreams {
+		// 非服务端流只有一个响应，收到后流即结束
+		s.done(nil)
+	}
+	return err
+}
+
+// done 每个流只记录一次结果
+func (s *breakerClientStream) done(err error) {
+	s.once.Do(func() {
+		mark(s.breaker, err)
+	})
+}
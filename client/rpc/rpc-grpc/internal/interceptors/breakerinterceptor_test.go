@@ -0,0 +1,130 @@
+package clientinterceptors
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/breaker"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryBreakerInterceptor(t *testing.T) {
+	group := breaker.NewGroup(func() breaker.Breaker {
+		return breaker.NewClassicBreaker(breaker.WithRequest(2), breaker.WithOpenTimeout(time.Minute))
+	})
+	interceptor := UnaryBreakerInterceptor(group)
+
+	var calls int
+	invoke := func(err error) error {
+		return interceptor(context.Background(), "/pkg.Svc/Get", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				calls++
+				return err
+			})
+	}
+
+	// 业务错误不计入失败
+	notFound := status.Error(codes.NotFound, "not found")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, notFound, invoke(notFound))
+	}
+	assert.Equal(t, breaker.StateClosed, group.Get("/pkg.Svc/Get").State())
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, unavailable, invoke(unavailable))
+	}
+	assert.Equal(t, breaker.StateOpen, group.Get("/pkg.Svc/Get").State())
+
+	calls = 0
+	err := invoke(nil)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assertBreakerOpen(t, err)
+	assert.Equal(t, 0, calls)
+
+	// 其他方法不受影响
+	err = interceptor(context.Background(), "/pkg.Svc/List", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return nil
+		})
+	assert.NoError(t, err)
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	recvErr error
+}
+
+func (s *fakeClientStream) RecvMsg(m any) error { return s.recvErr }
+
+// countingBreaker 记录成功次数并转发给真实的熔断器
+type countingBreaker struct {
+	breaker.Breaker
+	successes *int
+}
+
+func (b *countingBreaker) MarkSuccess() {
+	*b.successes++
+	b.Breaker.MarkSuccess()
+}
+
+func TestStreamBreakerInterceptor(t *testing.T) {
+	successes := 0
+	group := breaker.NewGroup(func() breaker.Breaker {
+		return &countingBreaker{
+			Breaker:   breaker.NewClassicBreaker(breaker.WithRequest(2), breaker.WithOpenTimeout(time.Minute)),
+			successes: &successes,
+		}
+	})
+	interceptor := StreamBreakerInterceptor(group)
+
+	open := func(desc *grpc.StreamDesc, method string, recvErr error) (grpc.ClientStream, error) {
+		return interceptor(context.Background(), desc, nil, method,
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{recvErr: recvErr}, nil
+			})
+	}
+
+	// 客户端流收到唯一的响应即记录成功
+	clientStreams := &grpc.StreamDesc{ClientStreams: true}
+	for i := 0; i < 3; i++ {
+		stream, err := open(clientStreams, "/pkg.Svc/Upload", nil)
+		require.NoError(t, err)
+		assert.NoError(t, stream.RecvMsg(nil))
+	}
+	assert.Equal(t, 3, successes)
+
+	// 服务端流在结束时才记录结果
+	serverStreams := &grpc.StreamDesc{ServerStreams: true}
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	for i := 0; i < 2; i++ {
+		stream, err := open(serverStreams, "/pkg.Svc/Watch", unavailable)
+		require.NoError(t, err)
+		assert.Equal(t, unavailable, stream.RecvMsg(nil))
+	}
+	assert.Equal(t, breaker.StateOpen, group.Get("/pkg.Svc/Watch").State())
+
+	_, err := open(serverStreams, "/pkg.Svc/Watch", nil)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assertBreakerOpen(t, err)
+}
+
+// assertBreakerOpen 熔断拒绝在 ErrorInfo 中带有 code.ErrBreakerOpen
+func assertBreakerOpen(t *testing.T, err error) {
+	t.Helper()
+	st, _ := status.FromError(err)
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, strconv.Itoa(code.ErrBreakerOpen), info.Reason)
+	assert.Equal(t, errorDomain, info.Domain)
+}
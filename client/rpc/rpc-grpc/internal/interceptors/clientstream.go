@@ -0,0 +1,99 @@
+package clientinterceptors
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// clientStream 包装 grpc.ClientStream，统计收发成功的消息数，并在流结束时调用一次 onFinish。
+// 流在以下情况结束：RecvMsg 返回错误（io.EOF 视为正常结束）、非服务端流收到唯一的响应、
+// SendMsg 或 Header 返回错误、上下文被取消
+type clientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+
+	sent atomic.Int64
+	recv atomic.Int64
+
+	// onSend/onRecv 每次收发消息成功之后调用，可以为空
+	onSend func(msg any)
+	onRecv func(msg any)
+	// onFinish 流结束时调用，err 为流的最终错误，正常结束时为空
+	onFinish func(err error)
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newClientStream(ctx context.Context, stream grpc.ClientStream, desc *grpc.StreamDesc) *clientStream {
+	s := &clientStream{
+		ClientStream: stream,
+		desc:         desc,
+		done:         make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.finish(ctx.Err())
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+		if s.onSend != nil {
+			s.onSend(m)
+		}
+	} else if err != io.EOF {
+		// io.EOF 表示流已经结束，最终状态由 RecvMsg 返回
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+		return err
+	}
+
+	s.recv.Add(1)
+	if s.onRecv != nil {
+		s.onRecv(m)
+	}
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+	return nil
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	md, err := s.ClientStream.Header()
+	if err != nil {
+		s.finish(err)
+	}
+	return md, err
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		if s.onFinish != nil {
+			s.onFinish(err)
+		}
+	})
+}
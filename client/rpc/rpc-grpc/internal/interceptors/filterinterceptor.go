@@ -0,0 +1,23 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/selector"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryNodeFilterInterceptor 把客户端配置的节点过滤器放入调用的上下文，由 selector 在负载均衡之前执行
+func UnaryNodeFilterInterceptor(filters ...selector.NodeFilter) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(selector.NewFilterContext(ctx, filters...), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamNodeFilterInterceptor 把客户端配置的节点过滤器放入流的上下文
+func StreamNodeFilterInterceptor(filters ...selector.NodeFilter) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(selector.NewFilterContext(ctx, filters...), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,31 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taluos/Malt/core/selector"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestNodeFilterInterceptor(t *testing.T) {
+	keepAll := func(_ context.Context, nodes []selector.Node) []selector.Node { return nodes }
+	ctx := selector.NewFilterContext(context.Background(), keepAll)
+
+	// 客户端的过滤器追加在调用上下文已有的过滤器之后
+	err := UnaryNodeFilterInterceptor(keepAll, keepAll)(ctx, "/test.Service/Method", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			assert.Len(t, selector.FromFilterContext(ctx), 3)
+			return nil
+		})
+	assert.NoError(t, err)
+
+	_, err = StreamNodeFilterInterceptor(keepAll)(context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Stream",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			assert.Len(t, selector.FromFilterContext(ctx), 1)
+			return nil, nil
+		})
+	assert.NoError(t, err)
+}
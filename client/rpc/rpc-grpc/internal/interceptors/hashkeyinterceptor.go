@@ -0,0 +1,42 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/selector"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryHashKeyInterceptor 在调用没有显式设置哈希键时，从 metadata 的 key 中取值作为 chash/hash 负载均衡的哈希键
+func UnaryHashKeyInterceptor(key string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(hashKeyContext(ctx, key), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamHashKeyInterceptor 在流没有显式设置哈希键时，从 metadata 的 key 中取值作为哈希键
+func StreamHashKeyInterceptor(key string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(hashKeyContext(ctx, key), desc, cc, method, opts...)
+	}
+}
+
+// hashKeyContext 依次查找出站和入站 metadata，入站 metadata 使服务端转发请求时沿用上游的哈希键
+func hashKeyContext(ctx context.Context, key string) context.Context {
+	if _, ok := selector.FromHashKeyContext(ctx); ok {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if v := md.Get(key); len(v) > 0 && v[0] != "" {
+			return selector.NewHashKeyContext(ctx, v[0])
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(key); len(v) > 0 && v[0] != "" {
+			return selector.NewHashKeyContext(ctx, v[0])
+		}
+	}
+	return ctx
+}
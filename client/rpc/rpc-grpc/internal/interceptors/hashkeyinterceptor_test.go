@@ -0,0 +1,46 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taluos/Malt/core/selector"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHashKeyInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"none", context.Background(), ""},
+		{"outgoing", metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "u1"), "u1"},
+		{"incoming", metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", "u2")), "u2"},
+		{"explicit", selector.NewHashKeyContext(
+			metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "u1"), "u3"), "u3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnaryHashKeyInterceptor("x-user-id")(tt.ctx, "/test.Service/Method", nil, nil, nil,
+				func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					key, _ := selector.FromHashKeyContext(ctx)
+					assert.Equal(t, tt.want, key)
+					return nil
+				})
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err := StreamHashKeyInterceptor("x-user-id")(metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "u1"),
+		&grpc.StreamDesc{}, nil, "/test.Service/Stream",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			key, _ := selector.FromHashKeyContext(ctx)
+			assert.Equal(t, "u1", key)
+			return nil, nil
+		})
+	assert.NoError(t, err)
+}
@@ -32,6 +32,31 @@ var (
 		Help:      "rpc client requests code count.",
 		Labels:    []string{"method", "code"},
 	})
+
+	metricClientStreamDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "duration_ms",
+		Help:      "rpc client streams duration(ms).",
+		Labels:    []string{"method"},
+		Buckets:   []float64{10, 50, 100, 500, 1000, 5000, 10000, 60000},
+	})
+
+	metricClientStreamCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "code_total",
+		Help:      "rpc client streams code count.",
+		Labels:    []string{"method", "code"},
+	})
+
+	metricClientStreamMsgTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "msg_total",
+		Help:      "rpc client streams messages count.",
+		Labels:    []string{"method", "direction"},
+	})
 )
 
 func UnaryPrometheusInterceptor(histogramVecOpts *metric.HistogramVecOpts, counterVecOpts *metric.CounterVecOpts) grpc.UnaryClientInterceptor {
@@ -52,20 +77,27 @@ func UnaryPrometheusInterceptor(histogramVecOpts *metric.HistogramVecOpts, count
 	}
 }
 
-func StreamPrometheusInterceptor(histogramVecOpts *metric.HistogramVecOpts, counterVecOpts *metric.CounterVecOpts) grpc.StreamClientInterceptor {
-	if histogramVecOpts != nil {
-		metricServerReqDur = metric.NewHistogramVec(histogramVecOpts)
-	}
-	if counterVecOpts != nil {
-		metricServerReqCodeTotal = metric.NewCounterVec(counterVecOpts)
-	}
+// StreamPrometheusInterceptor 统计流的收发消息数、从建立到结束的持续时间和最终状态码
+func StreamPrometheusInterceptor() grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		now := time.Now()
-		clientStream, err := streamer(ctx, desc, cc, method, opts...)
-		// 记录耗时
-		metricServerReqDur.Observe(int64(time.Since(now)/time.Millisecond), method)
-		// 记录状态码
-		metricServerReqCodeTotal.Inc(method, status.Code(err).String())
-		return clientStream, err
+		finish := func(err error) {
+			// 记录耗时
+			metricClientStreamDur.Observe(int64(time.Since(now)/time.Millisecond), method)
+			// 记录状态码
+			metricClientStreamCodeTotal.Inc(method, status.Code(err).String())
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			finish(err)
+			return nil, err
+		}
+
+		s := newClientStream(ctx, stream, desc)
+		s.onSend = func(any) { metricClientStreamMsgTotal.Inc(method, "sent") }
+		s.onRecv = func(any) { metricClientStreamMsgTotal.Inc(method, "received") }
+		s.onFinish = finish
+		return s, nil
 	}
 }
@@ -0,0 +1,63 @@
+package clientinterceptors
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatherCounter 从默认注册表中读取匹配 labels 的计数器的值
+func gatherCounter(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if v, ok := labels[label.GetName()]; ok && v != label.GetValue() {
+					continue next
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestStreamPrometheusInterceptor(t *testing.T) {
+	const method = "/test.Service/PrometheusStream"
+	desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+	interceptor := StreamPrometheusInterceptor()
+
+	var streamCtx context.Context
+	s, err := interceptor(context.Background(), desc, nil, method, newStreamer(2, io.EOF, &streamCtx))
+	require.NoError(t, err)
+
+	require.NoError(t, s.SendMsg(nil))
+	require.NoError(t, s.RecvMsg(nil))
+	require.NoError(t, s.RecvMsg(nil))
+	// 流结束之前不记录状态码
+	assert.Zero(t, gatherCounter(t, "rpc_client_streams_code_total", map[string]string{"method": method}))
+	assert.Equal(t, io.EOF, s.RecvMsg(nil))
+
+	assert.Equal(t, 1.0, gatherCounter(t, "rpc_client_streams_msg_total", map[string]string{"method": method, "direction": "sent"}))
+	assert.Equal(t, 2.0, gatherCounter(t, "rpc_client_streams_msg_total", map[string]string{"method": method, "direction": "received"}))
+	assert.Equal(t, 1.0, gatherCounter(t, "rpc_client_streams_code_total", map[string]string{"method": method, "code": codes.OK.String()}))
+
+	// 建立流失败时立即记录状态码
+	_, err = interceptor(context.Background(), desc, nil, method, func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1.0, gatherCounter(t, "rpc_client_streams_code_total", map[string]string{"method": method, "code": codes.Unavailable.String()}))
+}
@@ -2,9 +2,13 @@ package clientinterceptors
 
 import (
 	"context"
+	"io"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -32,20 +36,70 @@ func UnaryTimeoutInterceptor(timeout time.Duration, methodTimeouts ...MethodTime
 	}
 }
 
-func StreamTimeoutInterceptor(timeout time.Duration, methodTimeouts ...MethodTimeoutConf) grpc.StreamClientInterceptor {
+// StreamTimeoutInterceptor 为流设置整体超时和空闲超时，超时之后取消流。
+// timeout 从建立流开始计算，idleTimeout 从最近一次收发消息开始计算，为 0 时不限制
+func StreamTimeoutInterceptor(timeout, idleTimeout time.Duration, methodTimeouts ...MethodTimeoutConf) grpc.StreamClientInterceptor {
 	timeouts := buildMethodTimeouts(methodTimeouts)
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		// 获取当前方法的超时时间
 		t := getTimeoutByUnaryServerInfo(method, timeouts, timeout)
-		if t <= 0 {
+		if t <= 0 && idleTimeout <= 0 {
 			return streamer(ctx, desc, cc, method, opts...)
 		}
-		// 创建一个带有超时的上下文
-		ctx, cancel := context.WithTimeout(ctx, t)
-		defer cancel() // 确保在函数返回时取消上下文
-		// 调用 streamer 函数，传递带有超时的上下文
-		return streamer(ctx, desc, cc, method, opts...)
+
+		// 流结束之前不能取消上下文，取消函数在流结束时调用
+		var cancel context.CancelFunc
+		if t > 0 {
+			ctx, cancel = context.WithTimeout(ctx, t)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		s := &timeoutClientStream{clientStream: newClientStream(ctx, stream, desc)}
+		if idleTimeout <= 0 {
+			s.onFinish = func(error) { cancel() }
+			return s, nil
+		}
+
+		idle := time.AfterFunc(idleTimeout, func() {
+			s.idleExpired.Store(true)
+			cancel()
+		})
+		resetIdle := func(any) { idle.Reset(idleTimeout) }
+		s.onSend = resetIdle
+		s.onRecv = resetIdle
+		s.onFinish = func(error) {
+			idle.Stop()
+			cancel()
+		}
+		return s, nil
+	}
+}
+
+// timeoutClientStream 空闲超时取消流之后返回 DEADLINE_EXCEEDED，而不是 CANCELED
+type timeoutClientStream struct {
+	*clientStream
+	idleExpired atomic.Bool
+}
+
+func (s *timeoutClientStream) SendMsg(m any) error {
+	return s.idleError(s.clientStream.SendMsg(m))
+}
+
+func (s *timeoutClientStream) RecvMsg(m any) error {
+	return s.idleError(s.clientStream.RecvMsg(m))
+}
+
+func (s *timeoutClientStream) idleError(err error) error {
+	if err != nil && err != io.EOF && s.idleExpired.Load() {
+		return status.Error(codes.DeadlineExceeded, "stream idle timeout")
 	}
+	return err
 }
 
 func buildMethodTimeouts(timeouts []MethodTimeoutConf) methodTimeouts {
@@ -0,0 +1,102 @@
+package clientinterceptors
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mockClientStream 先返回 msgs 条消息，之后返回 recvErr；recvErr 为空时阻塞到上下文结束
+type mockClientStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	msgs    int
+	recvErr error
+}
+
+func (s *mockClientStream) SendMsg(any) error {
+	if s.ctx.Err() != nil {
+		return io.EOF
+	}
+	return nil
+}
+
+func (s *mockClientStream) RecvMsg(any) error {
+	if s.msgs > 0 {
+		s.msgs--
+		return nil
+	}
+	if s.recvErr != nil {
+		return s.recvErr
+	}
+	<-s.ctx.Done()
+	return status.FromContextError(s.ctx.Err()).Err()
+}
+
+// newStreamer 返回一个 grpc.Streamer，并通过 ctx 暴露建立流时使用的上下文
+func newStreamer(msgs int, recvErr error, ctx *context.Context) grpc.Streamer {
+	return func(c context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		*ctx = c
+		return &mockClientStream{ctx: c, msgs: msgs, recvErr: recvErr}, nil
+	}
+}
+
+func TestStreamTimeoutInterceptor(t *testing.T) {
+	desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+	interceptor := StreamTimeoutInterceptor(0, 0, MethodTimeoutConf{FullMethod: "/test.Service/Stream", Timeout: 20 * time.Millisecond})
+
+	// 没有配置超时的方法不设置截止时间
+	var streamCtx context.Context
+	_, err := interceptor(context.Background(), desc, nil, "/test.Service/Other", newStreamer(0, io.EOF, &streamCtx))
+	require.NoError(t, err)
+	_, ok := streamCtx.Deadline()
+	assert.False(t, ok)
+
+	// 超时在建立流之后仍然有效，直到流结束
+	s, err := interceptor(context.Background(), desc, nil, "/test.Service/Stream", newStreamer(0, nil, &streamCtx))
+	require.NoError(t, err)
+	_, ok = streamCtx.Deadline()
+	assert.True(t, ok)
+	assert.NoError(t, s.SendMsg(nil))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(s.RecvMsg(nil)))
+}
+
+func TestStreamIdleTimeoutInterceptor(t *testing.T) {
+	desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+	interceptor := StreamTimeoutInterceptor(0, 50*time.Millisecond)
+
+	var streamCtx context.Context
+	s, err := interceptor(context.Background(), desc, nil, "/test.Service/Stream", newStreamer(0, nil, &streamCtx))
+	require.NoError(t, err)
+
+	// 每次收发消息重新计时
+	for i := 0; i < 3; i++ {
+		time.Sleep(25 * time.Millisecond)
+		require.NoError(t, s.SendMsg(nil))
+	}
+	assert.NoError(t, streamCtx.Err())
+
+	err = s.RecvMsg(nil)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, "stream idle timeout", status.Convert(err).Message())
+}
+
+func TestStreamTimeoutInterceptorFinish(t *testing.T) {
+	// 非服务端流收到唯一的响应之后结束，上下文随之取消
+	interceptor := StreamTimeoutInterceptor(time.Minute, time.Minute)
+	var streamCtx context.Context
+	s, err := interceptor(context.Background(), &grpc.StreamDesc{ClientStreams: true}, nil, "/test.Service/Stream", newStreamer(1, nil, &streamCtx))
+	require.NoError(t, err)
+
+	require.NoError(t, s.SendMsg(nil))
+	assert.NoError(t, streamCtx.Err())
+	require.NoError(t, s.RecvMsg(nil))
+	assert.ErrorIs(t, streamCtx.Err(), context.Canceled)
+}
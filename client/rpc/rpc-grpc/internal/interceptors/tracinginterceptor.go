@@ -4,11 +4,12 @@ import (
 	"context"
 
 	maltAgent "github.com/taluos/Malt/core/trace"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func UnaryTracingInterceptor(agent *maltAgent.Agent) grpc.UnaryClientInterceptor {
@@ -16,31 +17,58 @@ func UnaryTracingInterceptor(agent *maltAgent.Agent) grpc.UnaryClientInterceptor
 		tr := maltAgent.NewTracer(trace.SpanKindClient,
 			maltAgent.WithTracerProvider(agent.TracerProvider()),
 			maltAgent.WithTracerName(method))
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			md = metadata.New(nil)
-		}
-		carrier := propagation.HeaderCarrier(md)
-		spanCtx, span := tr.Start(ctx, method, agent.Propagator(), carrier)
+		spanCtx, span := startClientSpan(ctx, tr, agent, method)
 		err := invoker(spanCtx, method, req, reply, cc, opts...)
 		tr.End(spanCtx, span, err)
 		return err
 	}
 }
 
+// StreamTracingInterceptor 为每个流创建一个 span，收发的每条消息记录为 span 事件，流结束时结束 span
 func StreamTracingInterceptor(agent *maltAgent.Agent) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		tr := maltAgent.NewTracer(trace.SpanKindClient,
 			maltAgent.WithTracerProvider(agent.TracerProvider()),
 			maltAgent.WithTracerName(method))
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			md = metadata.New(nil)
+		spanCtx, span := startClientSpan(ctx, tr, agent, method)
+
+		stream, err := streamer(spanCtx, desc, cc, method, opts...)
+		if err != nil {
+			tr.End(spanCtx, span, err)
+			return nil, err
 		}
-		carrier := propagation.HeaderCarrier(md)
-		spanCtx, span := tr.Start(ctx, method, agent.Propagator(), carrier)
-		clientStream, err := streamer(spanCtx, desc, cc, method, opts...)
-		tr.End(spanCtx, span, err)
-		return clientStream, err
+
+		s := newClientStream(spanCtx, stream, desc)
+		s.onSend = func(any) { messageEvent(span, "SENT", s.sent.Load()) }
+		s.onRecv = func(any) { messageEvent(span, "RECEIVED", s.recv.Load()) }
+		s.onFinish = func(err error) {
+			span.SetAttributes(
+				attribute.Int64("rpc.grpc.sent_messages", s.sent.Load()),
+				attribute.Int64("rpc.grpc.received_messages", s.recv.Load()),
+				attribute.Int("rpc.grpc.status_code", int(status.Code(err))),
+			)
+			tr.End(spanCtx, span, err)
+		}
+		return s, nil
+	}
+}
+
+// startClientSpan 创建客户端 span，并通过 agent 的 propagator 把链路上下文注入到请求的 metadata
+func startClientSpan(ctx context.Context, tr *maltAgent.Tracer, agent *maltAgent.Agent, method string) (context.Context, trace.Span) {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.New(nil)
 	}
+	spanCtx, span := tr.Start(ctx, method, agent.Propagator(), maltAgent.MetadataCarrier(md))
+	return metadata.NewOutgoingContext(spanCtx, md), span
+}
+
+// messageEvent 按 OpenTelemetry rpc 语义约定记录一条消息事件
+func messageEvent(span trace.Span, typ string, id int64) {
+	span.AddEvent("message", trace.WithAttributes(
+		attribute.String("message.type", typ),
+		attribute.Int64("message.id", id),
+	))
 }
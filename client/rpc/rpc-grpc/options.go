@@ -3,8 +3,10 @@ package grpc
 import (
 	"time"
 
+	"github.com/taluos/Malt/core/breaker"
 	metric "github.com/taluos/Malt/core/metrics"
 	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
 	maltAgent "github.com/taluos/Malt/core/trace"
 
 	"github.com/go-playground/validator/v10"
@@ -17,6 +19,9 @@ type clientOptions struct {
 	address string        `validate:"required"` // 服务器地址
 	timeout time.Duration `validate:"required,gte=0"`
 
+	streamTimeout     time.Duration // 流的整体超时，0 表示不限制
+	streamIdleTimeout time.Duration // 流的空闲超时，超过该时间没有收发消息则取消流，0 表示不限制
+
 	insecure      bool `validate:"required"`
 	enableTracing bool `validate:"required"`
 	enableMetrics bool `validate:"required"`
@@ -26,12 +31,17 @@ type clientOptions struct {
 
 	discovery registry.Discovery
 	agent     *maltAgent.Agent
+	breaker   *breaker.Group // 按方法熔断
+
+	nodeFilters     []selector.NodeFilter // 负载均衡之前执行的节点过滤器
+	hashKeyMetadata string                // 作为 chash/hash 负载均衡哈希键的 metadata 键
 
 	unaryInterceptors  []grpc.UnaryClientInterceptor  // 一元拦截器列表
 	streamInterceptors []grpc.StreamClientInterceptor // 流式拦截器列表
 	grpcOpts           []grpc.DialOption
 
-	balancerName string // 负载均衡器名称
+	balancerName string                     // 负载均衡器名称
+	outlier      *selector.OutlierDetection // 异常节点摘除配置，nil 表示不启用
 }
 
 func (o *clientOptions) Validate() error {
@@ -64,6 +74,20 @@ func WithTimeout(timeout time.Duration) ClientOptions {
 	}
 }
 
+// WithStreamTimeout 设置流式调用的整体超时，超时后流被取消
+func WithStreamTimeout(timeout time.Duration) ClientOptions {
+	return func(c *clientOptions) {
+		c.streamTimeout = timeout
+	}
+}
+
+// WithStreamIdleTimeout 设置流式调用的空闲超时，每次收发消息都会重新计时
+func WithStreamIdleTimeout(timeout time.Duration) ClientOptions {
+	return func(c *clientOptions) {
+		c.streamIdleTimeout = timeout
+	}
+}
+
 func WithInsecure(insecure bool) ClientOptions {
 	return func(c *clientOptions) {
 		c.insecure = insecure
@@ -100,6 +124,28 @@ func WithDiscovery(discovery registry.Discovery) ClientOptions {
 	}
 }
 
+// WithBreaker 设置熔断器组，每个方法使用独立的熔断器
+func WithBreaker(group *breaker.Group) ClientOptions {
+	return func(c *clientOptions) {
+		c.breaker = group
+	}
+}
+
+// WithNodeFilter 在负载均衡之前按版本、元数据等过滤节点，单次调用的过滤器可以通过 selector.NewFilterContext 设置
+func WithNodeFilter(filters ...selector.NodeFilter) ClientOptions {
+	return func(c *clientOptions) {
+		c.nodeFilters = append(c.nodeFilters, filters...)
+	}
+}
+
+// WithHashKeyMetadata 使用出站（或入站）metadata 中 key 的值作为 chash/hash 负载均衡的哈希键，
+// 单次调用也可以通过 selector.NewHashKeyContext 显式设置
+func WithHashKeyMetadata(key string) ClientOptions {
+	return func(c *clientOptions) {
+		c.hashKeyMetadata = key
+	}
+}
+
 func WithAgent(agent *maltAgent.Agent) ClientOptions {
 	return func(c *clientOptions) {
 		c.agent = agent
@@ -124,6 +170,18 @@ func WithOptions(opts ...grpc.DialOption) ClientOptions {
 	}
 }
 
+// WithOutlierDetection 为 selector.Register 注册的负载均衡器启用异常节点摘除，
+// 连续失败或成功率、延迟明显差于其他节点的节点在摘除期间不再接收请求，
+// gRPC 内置的 round_robin、pick_first 不支持该选项
+func WithOutlierDetection(cfg *selector.OutlierDetection) ClientOptions {
+	return func(c *clientOptions) {
+		c.outlier = cfg
+	}
+}
+
+// WithBalancerName 设置负载均衡器名称，可以是 gRPC 内置的 round_robin、pick_first，
+// selector.Register 注册的 picker 名称，或者 InitBuilder 注册的 selector。
+// 本包导入之后才注册的 picker 需要先调用 RegisterBalancer
 func WithBalancerName(name string) ClientOptions {
 	return func(c *clientOptions) {
 		c.balancerName = name
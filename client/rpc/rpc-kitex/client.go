@@ -0,0 +1,137 @@
+package kitex
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/taluos/Malt/client/rpc/rpc-kitex/internal/middlewares"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/log"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/transport"
+)
+
+// discoveryPrefix 与 gRPC 客户端保持一致的服务发现地址前缀
+const discoveryPrefix = "discovery:///"
+
+type Client struct {
+	// kitex 泛化调用客户端，通过 Call 发起请求
+	client.Client
+
+	opts clientOptions
+}
+
+func NewClient(opts ...ClientOptions) (*Client, error) {
+	o := clientOptions{
+		name:    defaultClientName,
+		address: defaultAddress,
+		timeout: defaultTimeout,
+
+		enableTracing: false,
+		enableMetrics: false,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.serviceInfo == nil {
+		return nil, errors.New("[Kitex] client service info cannot be empty")
+	}
+	if o.address == "" {
+		return nil, errors.New("[Kitex] client address cannot be empty")
+	}
+
+	mws := []endpoint.Middleware{}
+	if o.enableMetrics {
+		mws = append(mws, middlewares.PrometheusMiddleware(o.histogramVecOpts, o.counterVecOpts))
+	}
+	if o.enableTracing && o.agent != nil {
+		mws = append(mws, middlewares.TracingMiddleware(o.agent))
+	}
+	if len(o.middlewares) > 0 {
+		mws = append(mws, o.middlewares...) // 追加用户传入的中间件
+	}
+
+	kitexOpts := []client.Option{
+		client.WithRPCTimeout(o.timeout),
+		// 使用 TTHeader 透传 metainfo，服务端的认证和链路追踪依赖它
+		client.WithTransportProtocol(transport.TTHeader),
+		client.WithMetaHandler(transmeta.ClientTTHeaderHandler),
+	}
+	for _, mw := range mws {
+		kitexOpts = append(kitexOpts, client.WithMiddleware(mw))
+	}
+
+	// 服务发现
+	if o.discovery != nil {
+		service := strings.TrimPrefix(o.address, discoveryPrefix)
+		kitexOpts = append(kitexOpts,
+			client.WithDestService(service),
+			client.WithResolver(newResolver(o.discovery)))
+	} else {
+		kitexOpts = append(kitexOpts,
+			client.WithDestService(o.serviceInfo.ServiceName),
+			client.WithHostPorts(o.address))
+	}
+
+	if len(o.kitexOpts) > 0 {
+		kitexOpts = append(kitexOpts, o.kitexOpts...) // 追加用户传入的选项
+	}
+
+	cli, err := client.NewClient(o.serviceInfo, kitexOpts...)
+	if err != nil {
+		log.Errorf("[Kitex] new client error: %v", err)
+		return nil, err
+	}
+
+	return &Client{Client: cli, opts: o}, nil
+}
+
+func (c *Client) Close(ctx context.Context) error {
+	if c.Client == nil {
+		return errors.New("client not initialized")
+	}
+
+	closer, ok := c.Client.(io.Closer)
+	if !ok {
+		return nil
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		log.Infof("[Kitex] client closing")
+		done <- closer.Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		log.Errorf("[Kitex] client couldn't close gracefully in time")
+		return ctx.Err()
+	}
+
+	log.Infof("[Kitex] client closed")
+	return nil
+}
+
+func (c *Client) Name() string {
+	return c.opts.name
+}
+
+func (c *Client) Endpoint() string {
+	return c.opts.address
+}
@@ -0,0 +1,100 @@
+package kitex
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/generic"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"github.com/cloudwego/kitex/server"
+	"github.com/cloudwego/kitex/server/genericserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const echoIDL = `
+namespace go echo
+
+struct EchoRequest {
+	1: string message
+}
+
+struct EchoResponse {
+	1: string message
+}
+
+service Echo {
+	EchoResponse Echo(1: EchoRequest req)
+}
+`
+
+// echoService 原样返回 JSON 泛化调用的请求
+type echoService struct{}
+
+func (*echoService) GenericCall(ctx context.Context, method string, request any) (any, error) {
+	return request, nil
+}
+
+func newEchoGeneric(t *testing.T) generic.Generic {
+	t.Helper()
+	p, err := generic.NewThriftContentProvider(echoIDL, nil)
+	require.NoError(t, err)
+	g, err := generic.JSONThriftGeneric(p)
+	require.NoError(t, err)
+	return g
+}
+
+// startEchoServer 在本地随机端口启动 kitex echo 服务，返回监听地址
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// Run 只在收到退出信号后返回并停止服务
+	exit := make(chan error)
+	svr := genericserver.NewServer(&echoService{}, newEchoGeneric(t),
+		server.WithListener(ln),
+		server.WithExitSignal(func() <-chan error { return exit }))
+	errCh := make(chan error, 1)
+	go func() { errCh <- svr.Run() }()
+	t.Cleanup(func() {
+		close(exit)
+		assert.NoError(t, <-errCh)
+	})
+	return ln.Addr().String()
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	address := startEchoServer(t)
+	g := newEchoGeneric(t)
+	svcInfo := generic.ServiceInfoWithGeneric(g)
+
+	c, err := NewClient(
+		WithEndpoint(address),
+		WithServiceInfo(svcInfo),
+		WithOptions(client.WithGeneric(g)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, address, c.Endpoint())
+
+	// 参数和结果需要由泛化服务描述创建，才能带上泛化编解码器
+	mtInfo := svcInfo.MethodInfo(serviceinfo.GenericMethod)
+	args := mtInfo.NewArgs().(*generic.Args)
+	args.Method = "Echo"
+	args.Request = `{"message":"hello"}`
+	result := mtInfo.NewResult().(*generic.Result)
+	require.NoError(t, c.Call(context.Background(), "Echo", args, result))
+	assert.JSONEq(t, `{"message":"hello"}`, result.GetSuccess().(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, c.Close(ctx))
+}
+
+func TestNewClientRequiresServiceInfo(t *testing.T) {
+	_, err := NewClient(WithEndpoint("127.0.0.1:8888"))
+	assert.Error(t, err)
+}
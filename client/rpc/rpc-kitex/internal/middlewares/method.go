@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// fullMethod 返回 /service/method 形式的方法名，与 gRPC 的 method 保持一致
+func fullMethod(ctx context.Context) string {
+	ri := rpcinfo.GetRPCInfo(ctx)
+	if ri == nil || ri.Invocation() == nil {
+		return ""
+	}
+	return "/" + ri.Invocation().ServiceName() + "/" + ri.Invocation().MethodName()
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	metric "github.com/taluos/Malt/core/metrics"
+	"github.com/taluos/Malt/pkg/errors"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+const (
+	// ClientNamespace defines a logical grouping of clients.
+	ClientNamespace = "kitex_client"
+)
+
+var (
+	metricClientReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: ClientNamespace,
+		Subsystem: "requests",
+		Name:      "requests_duration_seconds",
+		Help:      "kitex client requests duration(ms).",
+		Labels:    []string{"method"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	metricClientReqCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ClientNamespace,
+		Subsystem: "requests",
+		Name:      "code_total",
+		Help:      "kitex client requests code count.",
+		Labels:    []string{"method", "code"},
+	})
+)
+
+func PrometheusMiddleware(histogramVecOpts *metric.HistogramVecOpts, counterVecOpts *metric.CounterVecOpts) endpoint.Middleware {
+	if histogramVecOpts != nil {
+		metricClientReqDur = metric.NewHistogramVec(histogramVecOpts)
+	}
+	if counterVecOpts != nil {
+		metricClientReqCodeTotal = metric.NewCounterVec(counterVecOpts)
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			now := time.Now()
+			err := next(ctx, req, resp)
+			method := fullMethod(ctx)
+			// 记录耗时
+			metricClientReqDur.Observe(int64(time.Since(now)/time.Millisecond), method)
+			// 记录状态码
+			code := "OK"
+			if err != nil {
+				code = strconv.Itoa(errors.ParseCoder(err).Code())
+			}
+			metricClientReqCodeTotal.Inc(method, code)
+			return err
+		}
+	}
+}
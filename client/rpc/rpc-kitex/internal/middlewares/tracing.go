@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"context"
+
+	maltAgent "github.com/taluos/Malt/core/trace"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TracingMiddleware(agent *maltAgent.Agent) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			method := fullMethod(ctx)
+			tr := maltAgent.NewTracer(trace.SpanKindClient,
+				maltAgent.WithTracerProvider(agent.TracerProvider()),
+				maltAgent.WithTracerName(method))
+			carrier := propagation.MapCarrier{}
+			spanCtx, span := tr.Start(ctx, method, agent.Propagator(), carrier)
+			// 通过 metainfo 将链路信息透传给服务端
+			for k, v := range carrier {
+				spanCtx = metainfo.WithValue(spanCtx, k, v)
+			}
+			err := next(spanCtx, req, resp)
+			tr.End(spanCtx, span, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package kitex
+
+import (
+	"time"
+
+	metric "github.com/taluos/Malt/core/metrics"
+	"github.com/taluos/Malt/core/registry"
+	maltAgent "github.com/taluos/Malt/core/trace"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+)
+
+// A clientOptions is a client option.
+type clientOptions struct {
+	name    string        // 客户端名称
+	address string        // 服务器地址: ip:port 或 discovery:///service
+	timeout time.Duration // 超时时间
+
+	enableTracing bool
+	enableMetrics bool
+
+	histogramVecOpts *metric.HistogramVecOpts
+	counterVecOpts   *metric.CounterVecOpts
+
+	discovery registry.Discovery
+	agent     *maltAgent.Agent
+
+	serviceInfo *serviceinfo.ServiceInfo // kitex 生成代码中的服务描述
+	middlewares []endpoint.Middleware    // 中间件列表
+	kitexOpts   []client.Option
+}
+
+// ClientOptions 定义了自定义客户端选项的方法
+type ClientOptions func(c *clientOptions)
+
+func WithName(name string) ClientOptions {
+	return func(c *clientOptions) {
+		c.name = name
+	}
+}
+
+func WithEndpoint(endpoint string) ClientOptions {
+	return func(c *clientOptions) {
+		c.address = endpoint
+	}
+}
+
+func WithTimeout(timeout time.Duration) ClientOptions {
+	return func(c *clientOptions) {
+		c.timeout = timeout
+	}
+}
+
+func WithEnableTracing(enableTracing bool) ClientOptions {
+	return func(c *clientOptions) {
+		c.enableTracing = enableTracing
+	}
+}
+
+func WithEnableMetrics(enableMetrics bool) ClientOptions {
+	return func(c *clientOptions) {
+		c.enableMetrics = enableMetrics
+	}
+}
+
+func WithHistogramVecOpts(opts *metric.HistogramVecOpts) ClientOptions {
+	return func(c *clientOptions) {
+		c.histogramVecOpts = opts
+	}
+}
+
+func WithCounterVecOpts(opts *metric.CounterVecOpts) ClientOptions {
+	return func(c *clientOptions) {
+		c.counterVecOpts = opts
+	}
+}
+
+func WithDiscovery(discovery registry.Discovery) ClientOptions {
+	return func(c *clientOptions) {
+		c.discovery = discovery
+	}
+}
+
+func WithAgent(agent *maltAgent.Agent) ClientOptions {
+	return func(c *clientOptions) {
+		c.agent = agent
+	}
+}
+
+func WithServiceInfo(serviceInfo *serviceinfo.ServiceInfo) ClientOptions {
+	return func(c *clientOptions) {
+		c.serviceInfo = serviceInfo
+	}
+}
+
+func WithMiddlewares(middlewares ...endpoint.Middleware) ClientOptions {
+	return func(c *clientOptions) {
+		c.middlewares = middlewares
+	}
+}
+
+func WithOptions(opts ...client.Option) ClientOptions {
+	return func(c *clientOptions) {
+		c.kitexOpts = opts
+	}
+}
@@ -0,0 +1,68 @@
+package kitex
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/taluos/Malt/core/registry"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// resolver 将 core/registry 的服务发现适配为 kitex 的 Resolver
+type resolver struct {
+	discovery registry.Discovery
+}
+
+var _ discovery.Resolver = (*resolver)(nil)
+
+func newResolver(d registry.Discovery) *resolver {
+	return &resolver{discovery: d}
+}
+
+func (r *resolver) Target(_ context.Context, target rpcinfo.EndpointInfo) string {
+	return target.ServiceName()
+}
+
+func (r *resolver) Resolve(ctx context.Context, desc string) (discovery.Result, error) {
+	services, err := r.discovery.GetService(ctx, desc)
+	if err != nil {
+		return discovery.Result{}, err
+	}
+
+	instances := make([]discovery.Instance, 0, len(services))
+	for _, service := range services {
+		for _, raw := range service.Endpoints {
+			u, err := url.Parse(raw)
+			if err != nil || u.Scheme != kitexScheme {
+				continue
+			}
+			instances = append(instances,
+				discovery.NewInstance("tcp", u.Host, weight(service.Metadata), service.Metadata))
+		}
+	}
+
+	return discovery.Result{
+		Cacheable: true,
+		CacheKey:  desc,
+		Instances: instances,
+	}, nil
+}
+
+func (r *resolver) Diff(cacheKey string, prev, next discovery.Result) (discovery.Change, bool) {
+	return discovery.DefaultDiff(cacheKey, prev, next)
+}
+
+func (r *resolver) Name() string {
+	return "malt"
+}
+
+// weight 从元数据中读取实例权重
+func weight(metadata map[string]string) int {
+	if w, err := strconv.Atoi(metadata[registry.MetadataWeight]); err == nil && w > 0 {
+		return w
+	}
+	return defaultWeight
+}
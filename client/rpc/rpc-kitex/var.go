@@ -0,0 +1,15 @@
+package kitex
+
+import "time"
+
+const (
+	defaultClientName = "my kitex client"
+	defaultAddress    = "127.0.0.1:8888"
+	defaultTimeout    = 5 * time.Second
+
+	// defaultWeight 注册中心没有设置权重时使用的权重，与 registry.MetadataWeight 的默认值一致
+	defaultWeight = 100
+
+	// kitexScheme 注册中心中 kitex 端点的 scheme
+	kitexScheme = "kitex"
+)
@@ -1,5 +1,6 @@
 package rpc
 
 const (
-	GRPCClientType = "grpc"
+	GRPCClientType  = "grpc"
+	KitexClientType = "kitex"
 )
@@ -73,6 +73,24 @@ func (auth *Authenticator) Authenticate(token, path, method string) error {
 	return nil
 }
 
+// Authorize 校验 token 中的角色是否有权限以 act 访问 obj
+// token 无效时返回 ErrInvalidAuthHeader，没有权限时返回 ErrPermissionDenied
+func (auth *Authenticator) Authorize(token, obj, act string) error {
+	role, err := cosjwt.ParseRoleFromHTTPContext(token, auth.publicKey)
+	if err != nil {
+		return errors.WithCode(code.ErrInvalidAuthHeader, "failed to parse role from token: %v", err)
+	}
+	ok, err := auth.RBACEnforcer.VerifyAuth(role, obj, act)
+	if err != nil {
+		return errors.WithCode(code.ErrPermissionDenied, "failed to verify auth: %v", err)
+	}
+	if !ok {
+		return errors.WithCode(code.ErrPermissionDenied, "role %s is not permitted to %s %s", role, act, obj)
+	}
+
+	return nil
+}
+
 func (auth *Authenticator) validateAuth(role, path, method string) bool {
 	ok, err := auth.RBACEnforcer.VerifyAuth(role, path, method)
 	if err != nil {
@@ -0,0 +1,91 @@
+// Package breaker 提供客户端熔断器：
+// Google SRE 自适应限流熔断器和经典的 closed/open/half-open 熔断器
+package breaker
+
+import (
+	"sync"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+)
+
+// ErrNotAllowed 熔断器拒绝请求时返回的错误
+var ErrNotAllowed = errors.WithCode(code.ErrBreakerOpen, "circuit breaker is open")
+
+// State 熔断器状态
+type State int32
+
+const (
+	// StateClosed 正常放行请求
+	StateClosed State = iota
+	// StateOpen 拒绝请求，SRE 熔断器在按比例丢弃请求时也处于该状态
+	StateOpen
+	// StateHalfOpen 放行少量探测请求
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker 熔断器
+type Breaker interface {
+	// Allow 判断是否放行请求，拒绝时返回 ErrNotAllowed
+	Allow() error
+	// MarkSuccess 记录一次成功的请求
+	MarkSuccess()
+	// MarkFailed 记录一次失败的请求
+	MarkFailed()
+	// State 返回当前状态
+	State() State
+}
+
+// Group 按 key 管理熔断器，例如 gRPC 的方法名或 HTTP 的 host，
+// 每个 key 拥有独立的统计窗口，并导出状态指标
+type Group struct {
+	new      func() Breaker
+	breakers sync.Map
+}
+
+// NewGroup 创建熔断器组，new 为每个 key 创建熔断器，为空时使用 SRE 熔断器
+func NewGroup(new func() Breaker) *Group {
+	if new == nil {
+		new = func() Breaker { return NewSREBreaker() }
+	}
+	return &Group{new: new}
+}
+
+// Get 返回 key 对应的熔断器，不存在时创建
+func (g *Group) Get(key string) Breaker {
+	if b, ok := g.breakers.Load(key); ok {
+		return b.(Breaker)
+	}
+	b, _ := g.breakers.LoadOrStore(key, &metricBreaker{Breaker: g.new(), name: key})
+	return b.(Breaker)
+}
+
+// Do 使用 key 对应的熔断器执行 fn，acceptable 判断 fn 返回的错误是否可以接受，
+// 为空时所有错误都视为失败
+func (g *Group) Do(key string, fn func() error, acceptable func(error) bool) error {
+	b := g.Get(key)
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err == nil || (acceptable != nil && acceptable(err)) {
+		b.MarkSuccess()
+	} else {
+		b.MarkFailed()
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSREBreaker(t *testing.T) {
+	b := NewSREBreaker(WithRequest(10), WithWindow(time.Second))
+
+	// 请求数不足时始终放行
+	for i := 0; i < 9; i++ {
+		require.NoError(t, b.Allow())
+		b.MarkFailed()
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	for i := 0; i < 100; i++ {
+		b.MarkFailed()
+	}
+
+	var rejected int
+	for i := 0; i < 100; i++ {
+		if err := b.Allow(); err != nil {
+			assert.True(t, errors.IsCode(err, code.ErrBreakerOpen))
+			rejected++
+		}
+	}
+	assert.Greater(t, rejected, 80)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestSREBreakerHealthy(t *testing.T) {
+	b := NewSREBreaker(WithRequest(10), WithWindow(time.Second))
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, b.Allow())
+		if i%3 == 0 {
+			b.MarkFailed()
+		} else {
+			b.MarkSuccess()
+		}
+	}
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestClassicBreaker(t *testing.T) {
+	b := NewClassicBreaker(
+		WithRequest(4),
+		WithFailureRatio(0.5),
+		WithOpenTimeout(50*time.Millisecond),
+		WithHalfOpenRequests(2),
+	)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.MarkSuccess()
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.MarkFailed()
+	}
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrNotAllowed)
+
+	// 熔断超时后进入半开状态，只放行 2 个探测请求
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, StateHalfOpen, b.State())
+	require.NoError(t, b.Allow())
+	require.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrNotAllowed)
+
+	// 探测失败重新打开
+	b.MarkFailed()
+	assert.Equal(t, StateOpen, b.State())
+
+	// 探测全部成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, b.Allow())
+	require.NoError(t, b.Allow())
+	b.MarkSuccess()
+	b.MarkSuccess()
+	assert.Equal(t, StateClosed, b.State())
+	require.NoError(t, b.Allow())
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(func() Breaker {
+		return NewClassicBreaker(WithRequest(1), WithOpenTimeout(time.Minute))
+	})
+
+	assert.Same(t, g.Get("/pkg.Svc/A"), g.Get("/pkg.Svc/A"))
+	assert.NotSame(t, g.Get("/pkg.Svc/A"), g.Get("/pkg.Svc/B"))
+
+	failed := errors.New("failed")
+	assert.ErrorIs(t, g.Do("/pkg.Svc/A", func() error { return failed }, nil), failed)
+	assert.ErrorIs(t, g.Do("/pkg.Svc/A", func() error { return nil }, nil), ErrNotAllowed)
+	assert.Equal(t, StateOpen, g.Get("/pkg.Svc/A").State())
+
+	// 可接受的错误不计入失败
+	assert.ErrorIs(t, g.Do("/pkg.Svc/B", func() error { return failed }, func(error) bool { return true }), failed)
+	assert.Equal(t, StateClosed, g.Get("/pkg.Svc/B").State())
+}
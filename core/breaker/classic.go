@@ -0,0 +1,129 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/taluos/Malt/pkg/window"
+)
+
+// classicBreaker 经典熔断器：
+// closed 状态下失败率超过阈值时打开；open 状态持续 openTimeout 后进入 half-open；
+// half-open 状态放行 halfOpenRequests 个探测请求，全部成功则关闭，任意失败则重新打开
+type classicBreaker struct {
+	mu    sync.Mutex
+	state State
+	stat  *window.RollingWindow
+
+	request          int64
+	failureRatio     float64
+	openTimeout      time.Duration
+	halfOpenRequests int64
+
+	openedAt         time.Time
+	halfOpenAt       time.Time
+	halfOpenInflight int64
+	halfOpenSuccess  int64
+}
+
+// NewClassicBreaker 创建经典熔断器
+func NewClassicBreaker(opts ...Option) Breaker {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &classicBreaker{
+		state:            StateClosed,
+		stat:             window.NewRollingWindow(o.bucket, o.window/time.Duration(o.bucket)),
+		request:          o.request,
+		failureRatio:     o.failureRatio,
+		openTimeout:      o.openTimeout,
+		halfOpenRequests: max(o.halfOpenRequests, 1),
+	}
+}
+
+func (b *classicBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrNotAllowed
+		}
+		b.halfOpen()
+	case StateHalfOpen:
+		// 探测请求长时间没有结果时重新开始探测，避免一直停留在半开状态
+		if b.halfOpenInflight >= b.halfOpenRequests {
+			if time.Since(b.halfOpenAt) < b.openTimeout {
+				return ErrNotAllowed
+			}
+			b.halfOpen()
+		}
+	}
+	if b.state == StateHalfOpen {
+		b.halfOpenInflight++
+	}
+	return nil
+}
+
+func (b *classicBreaker) MarkSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		b.stat.Add(1)
+	case StateHalfOpen:
+		b.halfOpenSuccess++
+		if b.halfOpenSuccess >= b.halfOpenRequests {
+			b.state = StateClosed
+			b.stat.Reset()
+		}
+	}
+}
+
+func (b *classicBreaker) MarkFailed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		b.stat.Add(0)
+		var success float64
+		var total int64
+		b.stat.Reduce(func(bucket *window.Bucket) {
+			success += bucket.Sum
+			total += bucket.Count
+		})
+		if total >= b.request && (float64(total)-success)/float64(total) >= b.failureRatio {
+			b.open()
+		}
+	case StateHalfOpen:
+		b.open()
+	}
+}
+
+func (b *classicBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// open 状态超时后对外表现为 half-open，实际切换在下一次 Allow 时发生
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *classicBreaker) halfOpen() {
+	b.state = StateHalfOpen
+	b.halfOpenAt = time.Now()
+	b.halfOpenInflight = 0
+	b.halfOpenSuccess = 0
+}
+
+func (b *classicBreaker) open() {
+	b.state = StateOpen
+	b.openedAt = time.Now()
+}
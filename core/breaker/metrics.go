@@ -0,0 +1,55 @@
+package breaker
+
+import (
+	metric "github.com/taluos/Malt/core/metrics"
+)
+
+const (
+	// Namespace defines a logical grouping of breakers.
+	Namespace = "breaker"
+)
+
+var (
+	metricBreakerState = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: Namespace,
+		Subsystem: "requests",
+		Name:      "state",
+		Help:      "circuit breaker state, 0: closed, 1: open, 2: half-open.",
+		Labels:    []string{"name"},
+	})
+
+	metricBreakerReqTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: Namespace,
+		Subsystem: "requests",
+		Name:      "total",
+		Help:      "circuit breaker requests count.",
+		Labels:    []string{"name", "result"},
+	})
+)
+
+// metricBreaker 在请求经过熔断器时导出状态和放行结果
+type metricBreaker struct {
+	Breaker
+	name string
+}
+
+func (b *metricBreaker) Allow() error {
+	err := b.Breaker.Allow()
+	if err != nil {
+		metricBreakerReqTotal.Inc(b.name, "rejected")
+	} else {
+		metricBreakerReqTotal.Inc(b.name, "accepted")
+	}
+	metricBreakerState.Set(float64(b.Breaker.State()), b.name)
+	return err
+}
+
+func (b *metricBreaker) MarkSuccess() {
+	b.Breaker.MarkSuccess()
+	metricBreakerState.Set(float64(b.Breaker.State()), b.name)
+}
+
+func (b *metricBreaker) MarkFailed() {
+	b.Breaker.MarkFailed()
+	metricBreakerState.Set(float64(b.Breaker.State()), b.name)
+}
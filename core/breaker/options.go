@@ -0,0 +1,85 @@
+package breaker
+
+import "time"
+
+type options struct {
+	// 统计窗口
+	window time.Duration
+	bucket int
+
+	// 窗口内请求数达到 request 之后才会触发熔断
+	request int64
+
+	// SRE 熔断器：期望的成功率，K = 1/success
+	success float64
+
+	// 经典熔断器：失败率阈值、熔断时长和半开状态的探测请求数
+	failureRatio     float64
+	openTimeout      time.Duration
+	halfOpenRequests int64
+}
+
+func defaultOptions() options {
+	return options{
+		window:           3 * time.Second,
+		bucket:           10,
+		request:          100,
+		success:          0.6,
+		failureRatio:     0.5,
+		openTimeout:      5 * time.Second,
+		halfOpenRequests: 5,
+	}
+}
+
+// Option 熔断器选项
+type Option func(*options)
+
+// WithWindow 设置统计窗口时长，默认 3s
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket 设置统计窗口的桶数，默认 10
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+// WithRequest 设置触发熔断的最小请求数，默认 100
+func WithRequest(r int64) Option {
+	return func(o *options) {
+		o.request = r
+	}
+}
+
+// WithSuccess 设置 SRE 熔断器期望的成功率，默认 0.6
+func WithSuccess(s float64) Option {
+	return func(o *options) {
+		o.success = s
+	}
+}
+
+// WithFailureRatio 设置经典熔断器打开的失败率阈值，默认 0.5
+func WithFailureRatio(r float64) Option {
+	return func(o *options) {
+		o.failureRatio = r
+	}
+}
+
+// WithOpenTimeout 设置经典熔断器打开之后进入半开状态的时间，默认 5s
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests 设置经典熔断器半开状态放行的探测请求数，
+// 探测请求全部成功后关闭熔断器，默认 5
+func WithHalfOpenRequests(n int64) Option {
+	return func(o *options) {
+		o.halfOpenRequests = n
+	}
+}
@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/taluos/Malt/pkg/mathx"
+	"github.com/taluos/Malt/pkg/window"
+)
+
+// sreBreaker 实现 Google SRE 自适应限流：
+// 当请求数超过 K 倍成功数时，按 max(0, (requests - K*accepts) / (requests + 1)) 的概率丢弃请求
+// https://sre.google/sre-book/handling-overload/
+type sreBreaker struct {
+	stat    *window.RollingWindow
+	proba   *mathx.Proba
+	k       float64
+	request int64
+	state   atomic.Int32
+}
+
+// NewSREBreaker 创建 SRE 自适应熔断器
+func NewSREBreaker(opts ...Option) Breaker {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &sreBreaker{
+		stat:    window.NewRollingWindow(o.bucket, o.window/time.Duration(o.bucket)),
+		proba:   mathx.NewProba(),
+		k:       1 / o.success,
+		request: o.request,
+	}
+}
+
+func (b *sreBreaker) summary() (accepts float64, total int64) {
+	b.stat.Reduce(func(bucket *window.Bucket) {
+		accepts += bucket.Sum
+		total += bucket.Count
+	})
+	return
+}
+
+// Allow 被拒绝的请求同样计入窗口，保证下游恢复前持续限流
+func (b *sreBreaker) Allow() error {
+	accepts, total := b.summary()
+	requests := b.k * accepts
+	if total < b.request || float64(total) < requests {
+		b.state.Store(int32(StateClosed))
+		return nil
+	}
+
+	b.state.Store(int32(StateOpen))
+	dr := math.Max(0, (float64(total)-requests)/float64(total+1))
+	if b.proba.TrueOnProba(dr) {
+		b.stat.Add(0)
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+func (b *sreBreaker) MarkSuccess() {
+	b.stat.Add(1)
+}
+
+func (b *sreBreaker) MarkFailed() {
+	b.stat.Add(0)
+}
+
+func (b *sreBreaker) State() State {
+	return State(b.state.Load())
+}
@@ -0,0 +1,132 @@
+// Package bootstrap builds Malt servers, registries and the trace agent
+// from a config tree, so that a service can run from a config file alone.
+package bootstrap
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	malt "github.com/taluos/Malt"
+	"github.com/taluos/Malt/core/config"
+	maltAgent "github.com/taluos/Malt/core/trace"
+)
+
+// Bootstrap 服务的完整配置树
+type Bootstrap struct {
+	App      AppConfig       `json:"app"`
+	Gin      *GinConfig      `json:"gin"`
+	Fiber    *FiberConfig    `json:"fiber"`
+	GRPC     *GRPCConfig     `json:"grpc"`
+	Registry *RegistryConfig `json:"registry"`
+	Trace    *TraceConfig    `json:"trace"`
+}
+
+// AppConfig 对应 Malt.App 的选项
+type AppConfig struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Version          string            `json:"version"`
+	Tags             []string          `json:"tags"`
+	Metadata         map[string]string `json:"metadata"`
+	RegistrarTimeout time.Duration     `json:"registrarTimeout"`
+	StopTimeout      time.Duration     `json:"stopTimeout"`
+	DrainDelay       time.Duration     `json:"drainDelay"`
+	AdminAddress     string            `json:"adminAddress"`
+}
+
+// TraceConfig 对应 trace.Agent 的配置，Sampler 为指针以区分未配置与 0
+type TraceConfig struct {
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	SamplerMode string   `json:"samplerMode"`
+	Sampler     *float64 `json:"sampler"`
+	Batcher     string   `json:"batcher"`
+}
+
+// Load 从配置中解析 Bootstrap
+func Load(c config.Config) (*Bootstrap, error) {
+	b := &Bootstrap{}
+	if err := c.Scan(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// AppOptions 将 AppConfig 转换为 Malt.App 的选项，未配置的字段保持默认值
+func AppOptions(c *AppConfig) []malt.Option {
+	opts := make([]malt.Option, 0)
+	if c == nil {
+		return opts
+	}
+	if c.ID != "" {
+		opts = append(opts, malt.WithId(c.ID))
+	}
+	if c.Name != "" {
+		opts = append(opts, malt.WithName(c.Name))
+	}
+	if c.Version != "" {
+		opts = append(opts, malt.WithVersion(c.Version))
+	}
+	if len(c.Tags) > 0 {
+		opts = append(opts, malt.WithTags(c.Tags))
+	}
+	if len(c.Metadata) > 0 {
+		opts = append(opts, malt.WithMetadata(c.Metadata))
+	}
+	if c.RegistrarTimeout > 0 {
+		opts = append(opts, malt.WithRegistrarTimeout(c.RegistrarTimeout))
+	}
+	if c.StopTimeout > 0 {
+		opts = append(opts, malt.WithStopTimeout(c.StopTimeout))
+	}
+	if c.DrainDelay > 0 {
+		opts = append(opts, malt.WithDrainDelay(c.DrainDelay))
+	}
+	if c.AdminAddress != "" {
+		opts = append(opts, malt.WithAdminAddress(c.AdminAddress))
+	}
+	return opts
+}
+
+// NewAgent 根据配置创建链路追踪 Agent，未配置的字段使用 Agent 标签中的默认值
+func NewAgent(c *TraceConfig, opts ...maltAgent.TelemetryOptions) *maltAgent.Agent {
+	if c == nil {
+		return nil
+	}
+	samplerMode, sampler, batcher := agentSettings(c)
+	return maltAgent.NewAgent(c.Name, c.Endpoint, samplerMode, sampler, batcher, opts...)
+}
+
+// agentSettings 返回补齐默认值后的采样模式、采样率和导出方式
+func agentSettings(c *TraceConfig) (samplerMode string, sampler float64, batcher string) {
+	samplerMode = c.SamplerMode
+	if samplerMode == "" {
+		samplerMode = agentDefault("SamplerMode")
+	}
+	if c.Sampler != nil {
+		sampler = *c.Sampler
+	} else {
+		sampler, _ = strconv.ParseFloat(agentDefault("Sampler"), 64)
+	}
+	batcher = c.Batcher
+	if batcher == "" {
+		batcher = agentDefault("Batcher")
+	}
+	return samplerMode, sampler, batcher
+}
+
+// agentDefault 读取 trace.Agent 字段 json 标签中的 default 值
+func agentDefault(field string) string {
+	f, ok := reflect.TypeOf(maltAgent.Agent{}).FieldByName(field)
+	if !ok {
+		return ""
+	}
+	for _, opt := range strings.Split(f.Tag.Get("json"), ",") {
+		if v, ok := strings.CutPrefix(opt, "default="); ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+	"github.com/taluos/Malt/core/config/file"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBootstrap = `
+app:
+  name: demo
+  version: v1.0.0
+  tags: [blue]
+  stopTimeout: 5s
+  drainDelay: 2s
+gin:
+  address: 127.0.0.1:0
+  mode: release
+  enableProfiling: false
+grpc:
+  address: 127.0.0.1:0
+  timeout: 3s
+  enableMetrics: false
+registry:
+  type: etcd
+  endpoints: [127.0.0.1:2379]
+  ttl: 10s
+trace:
+  name: demo
+  endpoint: http://127.0.0.1:14268/api/traces
+  samplerMode: ratio
+  sampler: 0.5
+`
+
+func TestLoadBootstrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testBootstrap), 0o644))
+
+	c := config.New(config.WithSource(file.NewSource(path)))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	b, err := Load(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, "demo", b.App.Name)
+	assert.Equal(t, 5*time.Second, b.App.StopTimeout)
+	assert.Len(t, AppOptions(&b.App), 5)
+
+	require.NotNil(t, b.Gin)
+	require.NotNil(t, b.Gin.EnableProfiling)
+	assert.False(t, *b.Gin.EnableProfiling)
+	assert.Nil(t, b.Gin.EnableHealth)
+	assert.Len(t, GinOptions(b.Gin), 3)
+
+	require.NotNil(t, b.GRPC)
+	assert.Equal(t, 3*time.Second, b.GRPC.Timeout)
+	assert.Nil(t, b.Fiber)
+
+	require.NotNil(t, b.Registry)
+	assert.Equal(t, "etcd", b.Registry.Type)
+	assert.Equal(t, 10*time.Second, b.Registry.TTL)
+
+	require.NotNil(t, b.Trace)
+	assert.Equal(t, "ratio", b.Trace.SamplerMode)
+	require.NotNil(t, b.Trace.Sampler)
+	assert.Equal(t, 0.5, *b.Trace.Sampler)
+
+	servers, err := NewServers(b, nil)
+	require.NoError(t, err)
+	require.Len(t, servers, 2)
+	assert.Equal(t, "gin", servers[0].Type())
+	assert.Equal(t, "grpc", servers[1].Type())
+}
+
+func TestNewRegistryInvalid(t *testing.T) {
+	_, err := NewRegistry(nil)
+	assert.Error(t, err)
+
+	_, err = NewRegistry(&RegistryConfig{Type: "zookeeper", Endpoints: []string{"127.0.0.1:2181"}})
+	assert.Error(t, err)
+
+	_, err = NewRegistry(&RegistryConfig{Type: "etcd"})
+	assert.Error(t, err)
+}
+
+func TestAgentSettings(t *testing.T) {
+	samplerMode, sampler, batcher := agentSettings(&TraceConfig{})
+	assert.Equal(t, "never", samplerMode)
+	assert.Equal(t, 1.0, sampler)
+	assert.Equal(t, "jaeger", batcher)
+
+	// 显式配置的 0 不会被默认值覆盖
+	zero := 0.0
+	samplerMode, sampler, batcher = agentSettings(&TraceConfig{SamplerMode: "ratio", Sampler: &zero, Batcher: "zipkin"})
+	assert.Equal(t, "ratio", samplerMode)
+	assert.Equal(t, 0.0, sampler)
+	assert.Equal(t, "zipkin", batcher)
+}
@@ -0,0 +1,163 @@
+package bootstrap
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/taluos/Malt/core/registry"
+	consulRegistry "github.com/taluos/Malt/core/registry/consul"
+	etcdRegistry "github.com/taluos/Malt/core/registry/etcd"
+	nacosRegistry "github.com/taluos/Malt/core/registry/nacos"
+	"github.com/taluos/Malt/pkg/errors"
+
+	consulApi "github.com/hashicorp/consul/api"
+	"github.com/nacos-group/nacos-sdk-go/clients"
+	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Registry 同时支持服务注册和服务发现
+type Registry interface {
+	registry.Registrar
+	registry.Discovery
+}
+
+// RegistryConfig 注册中心配置
+type RegistryConfig struct {
+	// Type 注册中心类型: etcd, consul, nacos
+	Type      string        `json:"type"`
+	Endpoints []string      `json:"endpoints"`
+	Timeout   time.Duration `json:"timeout"`
+	Username  string        `json:"username"`
+	Password  string        `json:"password"`
+
+	// etcd
+	Namespace string        `json:"namespace"`
+	TTL       time.Duration `json:"ttl"`
+
+	// consul
+	Datacenter          string `json:"datacenter"`
+	Token               string `json:"token"`
+	HealthCheck         *bool  `json:"healthCheck"`
+	Heartbeat           *bool  `json:"heartbeat"`
+	HealthCheckInterval int    `json:"healthCheckInterval"`
+
+	// nacos
+	NamespaceID string `json:"namespaceId"`
+	Group       string `json:"group"`
+	Cluster     string `json:"cluster"`
+	LogDir      string `json:"logDir"`
+	CacheDir    string `json:"cacheDir"`
+}
+
+// NewRegistry 按配置创建注册中心客户端
+func NewRegistry(c *RegistryConfig) (Registry, error) {
+	if c == nil {
+		return nil, errors.New("[Bootstrap] registry config is nil")
+	}
+	if len(c.Endpoints) == 0 {
+		return nil, errors.Errorf("[Bootstrap] %s registry endpoints is empty", c.Type)
+	}
+
+	switch c.Type {
+	case "etcd":
+		return newEtcdRegistry(c)
+	case "consul":
+		return newConsulRegistry(c)
+	case "nacos":
+		return newNacosRegistry(c)
+	default:
+		return nil, errors.Errorf("[Bootstrap] unsupported registry type: %s", c.Type)
+	}
+}
+
+func newEtcdRegistry(c *RegistryConfig) (Registry, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   c.Endpoints,
+		DialTimeout: c.Timeout,
+		Username:    c.Username,
+		Password:    c.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]etcdRegistry.Option, 0)
+	if c.Namespace != "" {
+		opts = append(opts, etcdRegistry.WithNamespace(c.Namespace))
+	}
+	if c.TTL > 0 {
+		opts = append(opts, etcdRegistry.WithRegisterTTL(c.TTL))
+	}
+	return etcdRegistry.New(client, opts...), nil
+}
+
+func newConsulRegistry(c *RegistryConfig) (Registry, error) {
+	client, err := consulApi.NewClient(&consulApi.Config{
+		Address:    c.Endpoints[0],
+		Datacenter: c.Datacenter,
+		Token:      c.Token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]consulRegistry.Option, 0)
+	if c.HealthCheck != nil {
+		opts = append(opts, consulRegistry.WithHealthCheck(*c.HealthCheck))
+	}
+	if c.Heartbeat != nil {
+		opts = append(opts, consulRegistry.WithHeartbeat(*c.Heartbeat))
+	}
+	if c.HealthCheckInterval > 0 {
+		opts = append(opts, consulRegistry.WithHealthCheckInterval(c.HealthCheckInterval))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, consulRegistry.WithTimeout(c.Timeout))
+	}
+	return consulRegistry.New(client, opts...), nil
+}
+
+func newNacosRegistry(c *RegistryConfig) (Registry, error) {
+	serverConfigs := make([]constant.ServerConfig, 0, len(c.Endpoints))
+	for _, endpoint := range c.Endpoints {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		p, err := strconv.ParseUint(port, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		serverConfigs = append(serverConfigs, *constant.NewServerConfig(host, p))
+	}
+
+	cc := constant.ClientConfig{
+		NamespaceId:         c.NamespaceID,
+		TimeoutMs:           uint64(c.Timeout / time.Millisecond),
+		NotLoadCacheAtStart: true,
+		Username:            c.Username,
+		Password:            c.Password,
+		LogDir:              c.LogDir,
+		CacheDir:            c.CacheDir,
+	}
+
+	client, err := clients.NewNamingClient(vo.NacosClientParam{
+		ClientConfig:  &cc,
+		ServerConfigs: serverConfigs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]nacosRegistry.Option, 0)
+	if c.Group != "" {
+		opts = append(opts, nacosRegistry.WithGroup(c.Group))
+	}
+	if c.Cluster != "" {
+		opts = append(opts, nacosRegistry.WithCluster(c.Cluster))
+	}
+	return nacosRegistry.New(client, opts...), nil
+}
@@ -0,0 +1,216 @@
+package bootstrap
+
+import (
+	"time"
+
+	maltAgent "github.com/taluos/Malt/core/trace"
+	"github.com/taluos/Malt/server"
+	restserver "github.com/taluos/Malt/server/rest"
+	fiberServer "github.com/taluos/Malt/server/rest/rest-fiber"
+	ginServer "github.com/taluos/Malt/server/rest/rest-gin"
+	rpcserver "github.com/taluos/Malt/server/rpc"
+	grpcServer "github.com/taluos/Malt/server/rpc/rpc-grpc"
+)
+
+// GinConfig gin 服务器配置，布尔字段为空时保持服务器默认值
+type GinConfig struct {
+	Name            string   `json:"name"`
+	Address         string   `json:"address"`
+	Mode            string   `json:"mode"`
+	Trans           string   `json:"trans"`
+	EnableHealth    *bool    `json:"enableHealth"`
+	EnableProfiling *bool    `json:"enableProfiling"`
+	EnableMetrics   *bool    `json:"enableMetrics"`
+	EnableTracing   *bool    `json:"enableTracing"`
+	EnableCert      *bool    `json:"enableCert"`
+	CertFile        string   `json:"certFile"`
+	KeyFile         string   `json:"keyFile"`
+	TrustedProxies  []string `json:"trustedProxies"`
+}
+
+// FiberConfig fiber 服务器配置，布尔字段为空时保持服务器默认值
+type FiberConfig struct {
+	Name            string   `json:"name"`
+	Address         string   `json:"address"`
+	Trans           string   `json:"trans"`
+	EnableHealth    *bool    `json:"enableHealth"`
+	EnableProfiling *bool    `json:"enableProfiling"`
+	EnableMetrics   *bool    `json:"enableMetrics"`
+	EnableTracing   *bool    `json:"enableTracing"`
+	TrustedProxies  []string `json:"trustedProxies"`
+}
+
+// GRPCConfig gRPC 服务器配置，布尔字段为空时保持服务器默认值
+type GRPCConfig struct {
+	Name              string        `json:"name"`
+	Address           string        `json:"address"`
+	Timeout           time.Duration `json:"timeout"`
+	EnableTracing     *bool         `json:"enableTracing"`
+	EnableMetrics     *bool         `json:"enableMetrics"`
+	EnableHealthCheck *bool         `json:"enableHealthCheck"`
+	EnableReflection  *bool         `json:"enableReflection"`
+	EnableInsecure    *bool         `json:"enableInsecure"`
+}
+
+// GinOptions 将 GinConfig 转换为 gin 服务器选项
+func GinOptions(c *GinConfig) []ginServer.ServerOptions {
+	opts := make([]ginServer.ServerOptions, 0)
+	if c == nil {
+		return opts
+	}
+	if c.Name != "" {
+		opts = append(opts, ginServer.WithName(c.Name))
+	}
+	if c.Address != "" {
+		opts = append(opts, ginServer.WithAddress(c.Address))
+	}
+	if c.Mode != "" {
+		opts = append(opts, ginServer.WithMode(c.Mode))
+	}
+	if c.Trans != "" {
+		opts = append(opts, ginServer.WithTrans(c.Trans))
+	}
+	if c.EnableHealth != nil {
+		opts = append(opts, ginServer.WithHealthz(*c.EnableHealth))
+	}
+	if c.EnableProfiling != nil {
+		opts = append(opts, ginServer.WithEnableProfiling(*c.EnableProfiling))
+	}
+	if c.EnableMetrics != nil {
+		opts = append(opts, ginServer.WithEnableMetrics(*c.EnableMetrics))
+	}
+	if c.EnableTracing != nil {
+		opts = append(opts, ginServer.WithEnableTracing(*c.EnableTracing))
+	}
+	if c.EnableCert != nil {
+		opts = append(opts, ginServer.WithEnableCert(*c.EnableCert))
+	}
+	if c.CertFile != "" {
+		opts = append(opts, ginServer.WithCertFile(c.CertFile))
+	}
+	if c.KeyFile != "" {
+		opts = append(opts, ginServer.WithKeyFile(c.KeyFile))
+	}
+	if len(c.TrustedProxies) > 0 {
+		opts = append(opts, ginServer.WithTrustedProxies(c.TrustedProxies))
+	}
+	return opts
+}
+
+// FiberOptions 将 FiberConfig 转换为 fiber 服务器选项
+func FiberOptions(c *FiberConfig) []fiberServer.ServerOptions {
+	opts := make([]fiberServer.ServerOptions, 0)
+	if c == nil {
+		return opts
+	}
+	if c.Name != "" {
+		opts = append(opts, fiberServer.WithName(c.Name))
+	}
+	if c.Address != "" {
+		opts = append(opts, fiberServer.WithAddress(c.Address))
+	}
+	if c.Trans != "" {
+		opts = append(opts, fiberServer.WithTrans(c.Trans))
+	}
+	if c.EnableHealth != nil {
+		opts = append(opts, fiberServer.WithHealthz(*c.EnableHealth))
+	}
+	if c.EnableProfiling != nil {
+		opts = append(opts, fiberServer.WithEnableProfiling(*c.EnableProfiling))
+	}
+	if c.EnableMetrics != nil {
+		opts = append(opts, fiberServer.WithEnableMetrics(*c.EnableMetrics))
+	}
+	if c.EnableTracing != nil {
+		opts = append(opts, fiberServer.WithEnableTracing(*c.EnableTracing))
+	}
+	if len(c.TrustedProxies) > 0 {
+		opts = append(opts, fiberServer.WithTrustedProxies(c.TrustedProxies))
+	}
+	return opts
+}
+
+// GRPCOptions 将 GRPCConfig 转换为 gRPC 服务器选项
+func GRPCOptions(c *GRPCConfig) []grpcServer.ServerOptions {
+	opts := make([]grpcServer.ServerOptions, 0)
+	if c == nil {
+		return opts
+	}
+	if c.Name != "" {
+		opts = append(opts, grpcServer.WithName(c.Name))
+	}
+	if c.Address != "" {
+		opts = append(opts, grpcServer.WithAddress(c.Address))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, grpcServer.WithTimeout(c.Timeout))
+	}
+	if c.EnableTracing != nil {
+		opts = append(opts, grpcServer.WithEnableTracing(*c.EnableTracing))
+	}
+	if c.EnableMetrics != nil {
+		opts = append(opts, grpcServer.WithEnableMetrics(*c.EnableMetrics))
+	}
+	if c.EnableHealthCheck != nil {
+		opts = append(opts, grpcServer.WithEnableHealthCheck(*c.EnableHealthCheck))
+	}
+	if c.EnableReflection != nil {
+		opts = append(opts, grpcServer.WithEnableReflection(*c.EnableReflection))
+	}
+	if c.EnableInsecure != nil {
+		opts = append(opts, grpcServer.WithEnableInsecure(*c.EnableInsecure))
+	}
+	return opts
+}
+
+// NewServers 按配置创建 gin、fiber、gRPC 服务器，agent 不为空时注入到各服务器
+func NewServers(c *Bootstrap, agent *maltAgent.Agent) ([]server.Server, error) {
+	servers := make([]server.Server, 0, 3)
+
+	if c.Gin != nil {
+		opts := make([]restserver.ServerOptions, 0)
+		for _, o := range GinOptions(c.Gin) {
+			opts = append(opts, o)
+		}
+		if agent != nil {
+			opts = append(opts, ginServer.WithAgent(agent))
+		}
+		srv, err := server.NewServer(server.RESTServerType, server.RESTConfig{Method: "gin", Options: opts})
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+
+	if c.Fiber != nil {
+		opts := make([]restserver.ServerOptions, 0)
+		for _, o := range FiberOptions(c.Fiber) {
+			opts = append(opts, o)
+		}
+		if agent != nil {
+			opts = append(opts, fiberServer.WithAgent(agent))
+		}
+		srv, err := server.NewServer(server.RESTServerType, server.RESTConfig{Method: "fiber", Options: opts})
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+
+	if c.GRPC != nil {
+		opts := make([]rpcserver.ServerOptions, 0)
+		for _, o := range GRPCOptions(c.GRPC) {
+			opts = append(opts, o)
+		}
+		if agent != nil {
+			opts = append(opts, grpcServer.WithAgent(agent))
+		}
+		srv, err := server.NewServer(server.RPCServerType, server.RPCConfig{Method: "grpc", Options: opts})
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+
+	return servers, nil
+}
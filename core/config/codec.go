@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec decodes a config document into a map.
+type Codec func(data []byte, v *map[string]any) error
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{
+		"json": func(data []byte, v *map[string]any) error { return json.Unmarshal(data, v) },
+		"yaml": func(data []byte, v *map[string]any) error { return yaml.Unmarshal(data, v) },
+		"yml":  func(data []byte, v *map[string]any) error { return yaml.Unmarshal(data, v) },
+		"toml": func(data []byte, v *map[string]any) error { return toml.Unmarshal(data, v) },
+	}
+)
+
+// RegisterCodec 注册配置格式的解码器，format 不区分大小写
+func RegisterCodec(format string, codec Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[strings.ToLower(format)] = codec
+}
+
+// HasCodec 判断 format 是否有已注册的解码器
+func HasCodec(format string) bool {
+	_, ok := getCodec(format)
+	return ok
+}
+
+func getCodec(format string) (Codec, bool) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	codec, ok := codecs[strings.ToLower(format)]
+	return codec, ok
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/log"
+)
+
+var _ Config = (*config)(nil)
+
+// ErrNotFound is returned when a key does not exist in the config tree.
+var ErrNotFound = errors.New("key not found")
+
+// KeyValue is a config item loaded from a source.
+//
+// When Format is empty, Key is a dotted path such as "server.grpc.address"
+// and Value is the raw string value of that path. Otherwise Value is a
+// document encoded as Format (json, yaml, toml) and merged at the root.
+type KeyValue struct {
+	Key    string
+	Value  []byte
+	Format string
+}
+
+// Source 配置源
+type Source interface {
+	// Load 加载配置源中的所有配置项
+	Load() ([]*KeyValue, error)
+
+	// Watch 创建配置源的监听器
+	Watch() (Watcher, error)
+}
+
+// Watcher 配置源监听
+type Watcher interface {
+	// Next 阻塞直到配置源发生变化，返回变化后的全部配置项
+	// 监听器停止后返回 context.Canceled
+	Next() ([]*KeyValue, error)
+
+	// Stop 停止监听
+	Stop() error
+}
+
+// Observer is called when the value of a watched key changes.
+type Observer func(key string, value Value)
+
+// Config 配置
+type Config interface {
+	// Load 加载所有配置源并开始监听变化
+	Load() error
+
+	// Scan 将整个配置树解析到结构体中
+	Scan(v any) error
+
+	// Value 返回指定路径的配置值，路径使用 "." 分隔
+	Value(key string) Value
+
+	// Watch 监听指定路径的配置变化
+	Watch(key string, o Observer) error
+
+	// Close 停止所有监听
+	Close() error
+}
+
+type options struct {
+	sources []Source
+}
+
+// Option is config option.
+type Option func(*options)
+
+// WithSource 添加配置源，后添加的配置源优先级更高
+func WithSource(s ...Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, s...)
+	}
+}
+
+type config struct {
+	opts   options
+	reader *reader
+
+	mu        sync.Mutex
+	observers map[string][]Observer
+	cached    map[string]any
+	watchers  []Watcher
+}
+
+// New 创建配置
+func New(opts ...Option) Config {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &config{
+		opts:      o,
+		reader:    newReader(len(o.sources)),
+		observers: make(map[string][]Observer),
+		cached:    make(map[string]any),
+	}
+}
+
+func (c *config) Load() error {
+	for i, src := range c.opts.sources {
+		kvs, err := src.Load()
+		if err != nil {
+			return err
+		}
+		if err = c.reader.apply(i, kvs); err != nil {
+			log.Errorf("[Config] merge source failed: %s", err)
+			return err
+		}
+
+		w, err := src.Watch()
+		if err != nil {
+			log.Errorf("[Config] watch source failed: %s", err)
+			return err
+		}
+		c.mu.Lock()
+		c.watchers = append(c.watchers, w)
+		c.mu.Unlock()
+		go c.watch(i, w)
+	}
+	return nil
+}
+
+func (c *config) watch(i int, w Watcher) {
+	for {
+		kvs, err := w.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				log.Infof("[Config] watcher stopped")
+				return
+			}
+			log.Errorf("[Config] watch next failed: %s", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if err = c.reader.apply(i, kvs); err != nil {
+			log.Errorf("[Config] merge source failed: %s", err)
+			continue
+		}
+		c.notify()
+	}
+}
+
+// notify 对比被监听路径的新旧值，变化时回调
+func (c *config) notify() {
+	c.mu.Lock()
+	changed := make(map[string]Value)
+	for key := range c.observers {
+		v, ok := c.reader.value(key)
+		if !ok && c.cached[key] == nil {
+			continue
+		}
+		if reflect.DeepEqual(v, c.cached[key]) {
+			continue
+		}
+		c.cached[key] = v
+		changed[key] = newValue(key, v, ok)
+	}
+	observers := make(map[string][]Observer, len(changed))
+	for key := range changed {
+		observers[key] = append([]Observer(nil), c.observers[key]...)
+	}
+	c.mu.Unlock()
+
+	for key, v := range changed {
+		for _, o := range observers[key] {
+			o(key, v)
+		}
+	}
+}
+
+func (c *config) Scan(v any) error {
+	return decode(c.reader.snapshot(), v)
+}
+
+func (c *config) Value(key string) Value {
+	v, ok := c.reader.value(key)
+	return newValue(key, v, ok)
+}
+
+func (c *config) Watch(key string, o Observer) error {
+	v, ok := c.reader.value(key)
+	if !ok {
+		return errors.Wrapf(ErrNotFound, "watch %s", key)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observers[key] = append(c.observers[key], o)
+	c.cached[key] = v
+
+	return nil
+}
+
+func (c *config) Close() error {
+	c.mu.Lock()
+	watchers := c.watchers
+	c.watchers = nil
+	c.mu.Unlock()
+
+	var err error
+	for _, w := range watchers {
+		if werr := w.Stop(); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
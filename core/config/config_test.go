@@ -0,0 +1,118 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+	"github.com/taluos/Malt/core/config/env"
+	"github.com/taluos/Malt/core/config/file"
+	"github.com/taluos/Malt/core/config/flag"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testYAML = `
+server:
+  grpc:
+    address: 127.0.0.1:9000
+    timeout: 3s
+  http:
+    address: 127.0.0.1:8000
+tags: [a, b]
+`
+
+const testTOML = `
+[server.http]
+address = "0.0.0.0:8080"
+`
+
+type testConf struct {
+	Server struct {
+		GRPC struct {
+			Address string        `json:"address"`
+			Timeout time.Duration `json:"timeout"`
+			Weight  int           `json:"weight"`
+		} `json:"grpc"`
+		HTTP struct {
+			Address string `json:"address"`
+		} `json:"http"`
+	} `json:"server"`
+	Tags []string `json:"tags"`
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestConfigMergeAndScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), testYAML)
+	writeFile(t, filepath.Join(dir, "b.toml"), testTOML)
+	writeFile(t, filepath.Join(dir, "README.md"), "# config")
+
+	t.Setenv("MALTTEST_SERVER_GRPC_WEIGHT", "10")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("server.grpc.address", "", "grpc address")
+	require.NoError(t, fs.Parse([]string{"--server.grpc.address=127.0.0.1:9999"}))
+
+	c := config.New(config.WithSource(
+		file.NewSource(dir),
+		env.NewSource("MALTTEST_"),
+		flag.NewSource(fs),
+	))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	var conf testConf
+	require.NoError(t, c.Scan(&conf))
+
+	assert.Equal(t, "127.0.0.1:9999", conf.Server.GRPC.Address)
+	assert.Equal(t, 3*time.Second, conf.Server.GRPC.Timeout)
+	assert.Equal(t, 10, conf.Server.GRPC.Weight)
+	assert.Equal(t, "0.0.0.0:8080", conf.Server.HTTP.Address)
+	assert.Equal(t, []string{"a", "b"}, conf.Tags)
+
+	timeout, err := c.Value("server.grpc.timeout").Duration()
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, timeout)
+
+	weight, err := c.Value("server.grpc.weight").Int()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), weight)
+
+	assert.False(t, c.Value("server.missing").Exists())
+	_, err = c.Value("server.missing").String()
+	assert.ErrorIs(t, err, config.ErrNotFound)
+}
+
+func TestConfigWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	writeFile(t, path, `{"limit": {"rate": 10}}`)
+
+	c := config.New(config.WithSource(file.NewSource(path, file.WithInterval(10*time.Millisecond))))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	changed := make(chan int64, 1)
+	require.NoError(t, c.Watch("limit.rate", func(key string, v config.Value) {
+		n, _ := v.Int()
+		changed <- n
+	}))
+
+	writeFile(t, path, `{"limit": {"rate": 20, "burst": 5}}`)
+
+	select {
+	case n := <-changed:
+		assert.Equal(t, int64(20), n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("observer was not called")
+	}
+
+	assert.Error(t, c.Watch("limit.missing", func(string, config.Value) {}))
+}
@@ -0,0 +1,102 @@
+package consul
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/core/config"
+	"github.com/taluos/Malt/pkg/errors"
+
+	"github.com/hashicorp/consul/api"
+)
+
+var _ config.Source = (*source)(nil)
+
+type options struct {
+	ctx  context.Context
+	path string
+}
+
+// Option is consul config source option.
+type Option func(o *options)
+
+// WithContext 设置请求 consul 使用的上下文
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPath 设置配置所在的键前缀，前缀按 path + "/" 匹配
+func WithPath(p string) Option {
+	return func(o *options) {
+		o.path = p
+	}
+}
+
+type source struct {
+	client  *api.Client
+	options *options
+
+	lastIndex atomic.Uint64 // 最近一次加载的索引，监听从该索引开始阻塞查询
+}
+
+// NewSource 创建 consul KV 配置源
+func NewSource(client *api.Client, opts ...Option) (config.Source, error) {
+	options := &options{
+		ctx: context.Background(),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.path == "" {
+		return nil, errors.New("[Config] consul path invalid")
+	}
+
+	return &source{
+		client:  client,
+		options: options,
+	}, nil
+}
+
+// key 返回列举使用的前缀，以 "/" 结尾，避免匹配到 app/config-old 这样的兄弟键
+func (s *source) key() string {
+	if !strings.HasSuffix(s.options.path, "/") {
+		return s.options.path + "/"
+	}
+	return s.options.path
+}
+
+func (s *source) Load() ([]*config.KeyValue, error) {
+	kvs, index, err := s.list(s.options.ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.lastIndex.Store(index)
+	return kvs, nil
+}
+
+// list 加载前缀下所有的键，waitIndex 不为 0 时阻塞直到数据变化
+func (s *source) list(ctx context.Context, waitIndex uint64) ([]*config.KeyValue, uint64, error) {
+	q := (&api.QueryOptions{WaitIndex: waitIndex}).WithContext(ctx)
+	pairs, meta, err := s.client.KV().List(s.key(), q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make([]*config.KeyValue, 0, len(pairs))
+	for _, item := range pairs {
+		// 目录节点没有值
+		if strings.HasSuffix(item.Key, "/") {
+			continue
+		}
+		kvs = append(kvs, config.NewKeyValue(s.options.path, item.Key, item.Value))
+	}
+	return kvs, meta.LastIndex, nil
+}
+
+func (s *source) Watch() (config.Watcher, error) {
+	return newWatcher(s)
+}
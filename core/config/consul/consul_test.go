@@ -0,0 +1,167 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsul serves the subset of the consul KV HTTP API used by the source,
+// including blocking queries on the index.
+type fakeConsul struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	index   uint64
+	data    map[string]string
+	handler http.Handler
+}
+
+func newFakeConsul() *fakeConsul {
+	f := &fakeConsul{index: 1, data: make(map[string]string)}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *fakeConsul) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = value
+	f.index++
+	f.cond.Broadcast()
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	wait, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+
+	f.mu.Lock()
+	for wait > 0 && f.index <= wait {
+		f.cond.Wait()
+	}
+	pairs := make([]*api.KVPair, 0)
+	for k, v := range f.data {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, &api.KVPair{Key: k, Value: []byte(v), ModifyIndex: f.index})
+		}
+	}
+	index := f.index
+	f.mu.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func TestConsulSource(t *testing.T) {
+	fake := newFakeConsul()
+	fake.put("app/config.json", `{"limit": {"rate": 10}}`)
+	fake.put("app/limit/burst", "5")
+
+	srv := httptest.NewServer(fake)
+	defer func() {
+		// 唤醒阻塞查询，避免关闭服务器时等待
+		fake.put("app/done", "1")
+		srv.Close()
+	}()
+
+	client, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(srv.URL, "http://")})
+	require.NoError(t, err)
+
+	source, err := NewSource(client, WithPath("app/"))
+	require.NoError(t, err)
+
+	c := config.New(config.WithSource(source))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	rate, err := c.Value("limit.rate").Int()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), rate)
+
+	burst, err := c.Value("limit.burst").Int()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), burst)
+
+	changed := make(chan int64, 1)
+	require.NoError(t, c.Watch("limit.burst", func(_ string, v config.Value) {
+		n, _ := v.Int()
+		changed <- n
+	}))
+
+	fake.put("app/limit/burst", "8")
+
+	select {
+	case n := <-changed:
+		assert.Equal(t, int64(8), n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("observer was not called")
+	}
+}
+
+func newTestSource(t *testing.T, fake *fakeConsul, path string) *source {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(func() {
+		// 唤醒阻塞查询，避免关闭服务器时等待
+		fake.put("done", "1")
+		srv.Close()
+	})
+
+	client, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(srv.URL, "http://")})
+	require.NoError(t, err)
+	s, err := NewSource(client, WithPath(path))
+	require.NoError(t, err)
+	return s.(*source)
+}
+
+func TestConsulSourcePrefixIsDirectory(t *testing.T) {
+	fake := newFakeConsul()
+	fake.put("app/config/limit/rate", "10")
+	fake.put("app/config-old/limit/rate", "1")
+
+	kvs, err := newTestSource(t, fake, "app/config").Load()
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+	assert.Equal(t, "limit.rate", kvs[0].Key)
+	assert.Equal(t, "10", string(kvs[0].Value))
+}
+
+func TestConsulWatchFromLoadedIndex(t *testing.T) {
+	fake := newFakeConsul()
+	fake.put("app/limit/burst", "5")
+
+	s := newTestSource(t, fake, "app")
+	_, err := s.Load()
+	require.NoError(t, err)
+
+	// 加载和监听之间的修改
+	fake.put("app/limit/burst", "8")
+
+	w, err := s.Watch()
+	require.NoError(t, err)
+	defer w.Stop()
+
+	next := make(chan []*config.KeyValue, 1)
+	go func() {
+		kvs, _ := w.Next()
+		next <- kvs
+	}()
+
+	select {
+	case kvs := <-next:
+		require.Len(t, kvs, 1)
+		assert.Equal(t, "8", string(kvs[0].Value))
+	case <-time.After(2 * time.Second):
+		t.Fatal("change between Load and Watch was lost")
+	}
+}
@@ -0,0 +1,64 @@
+package consul
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/config"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+// watcher 使用 consul 的阻塞查询监听前缀下的变化
+type watcher struct {
+	source    *source
+	lastIndex uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(s *source) (*watcher, error) {
+	ctx, cancel := context.WithCancel(s.options.ctx)
+	// 从加载时的索引开始监听，加载和监听之间的修改不会丢失
+	index := s.lastIndex.Load()
+	if index == 0 {
+		var err error
+		if _, index, err = s.list(ctx, 0); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	return &watcher{
+		source:    s,
+		lastIndex: index,
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	for {
+		kvs, index, err := w.source.list(w.ctx, w.lastIndex)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			return nil, err
+		}
+		// 阻塞查询超时返回时索引不变
+		if index == w.lastIndex {
+			continue
+		}
+		// 索引回退时重新开始
+		if index < w.lastIndex {
+			index = 0
+		}
+		w.lastIndex = index
+		return kvs, nil
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/taluos/Malt/core/config"
+)
+
+var _ config.Source = (*env)(nil)
+
+type env struct {
+	prefixes []string
+}
+
+// NewSource 创建环境变量配置源
+// 只加载带有指定前缀的环境变量，去掉前缀后按 "_" 拆分为小写路径，
+// 例如前缀为 "MALT_" 时，MALT_GRPC_ADDRESS 对应 grpc.address
+// 不指定前缀时加载全部环境变量
+func NewSource(prefixes ...string) config.Source {
+	return &env{prefixes: prefixes}
+}
+
+func (e *env) Load() ([]*config.KeyValue, error) {
+	return e.load(os.Environ()), nil
+}
+
+func (e *env) load(envs []string) []*config.KeyValue {
+	kvs := make([]*config.KeyValue, 0)
+	for _, item := range envs {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok || k == "" {
+			continue
+		}
+
+		if len(e.prefixes) > 0 {
+			prefix, matched := matchPrefix(e.prefixes, k)
+			if !matched {
+				continue
+			}
+			k = strings.TrimPrefix(strings.TrimPrefix(k, prefix), "_")
+		}
+		if k == "" {
+			continue
+		}
+
+		kvs = append(kvs, &config.KeyValue{
+			Key:   strings.ReplaceAll(strings.ToLower(k), "_", "."),
+			Value: []byte(v),
+		})
+	}
+	return kvs
+}
+
+func (e *env) Watch() (config.Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{ctx: ctx, cancel: cancel}, nil
+}
+
+func matchPrefix(prefixes []string, s string) (string, bool) {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// watcher 进程内的环境变量不会被外部修改，Next 一直阻塞到 Stop
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,105 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/core/config"
+	"github.com/taluos/Malt/pkg/errors"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ config.Source = (*source)(nil)
+
+type options struct {
+	ctx    context.Context
+	path   string
+	prefix bool
+}
+
+// Option is etcd config source option.
+type Option func(o *options)
+
+// WithContext 设置请求 etcd 使用的上下文
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithPath 设置配置所在的键
+func WithPath(p string) Option {
+	return func(o *options) {
+		o.path = p
+	}
+}
+
+// WithPrefix 是否加载 path 目录下的所有键，前缀按 path + "/" 匹配
+func WithPrefix(prefix bool) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+type source struct {
+	client  *clientv3.Client
+	options *options
+
+	revision atomic.Int64 // 最近一次加载的版本，监听从下一个版本开始
+}
+
+// NewSource 创建 etcd 配置源
+func NewSource(client *clientv3.Client, opts ...Option) (config.Source, error) {
+	options := &options{
+		ctx:    context.Background(),
+		prefix: true,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.path == "" {
+		return nil, errors.New("[Config] etcd path invalid")
+	}
+
+	return &source{
+		client:  client,
+		options: options,
+	}, nil
+}
+
+func (s *source) opOptions() []clientv3.OpOption {
+	if s.options.prefix {
+		return []clientv3.OpOption{clientv3.WithPrefix()}
+	}
+	return nil
+}
+
+// key 返回读取和监听使用的键，前缀模式下以 "/" 结尾，避免匹配到 /app/config-old 这样的兄弟键
+func (s *source) key() string {
+	if s.options.prefix && !strings.HasSuffix(s.options.path, "/") {
+		return s.options.path + "/"
+	}
+	return s.options.path
+}
+
+func (s *source) Load() ([]*config.KeyValue, error) {
+	rsp, err := s.client.Get(s.options.ctx, s.key(), s.opOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.Header != nil {
+		s.revision.Store(rsp.Header.Revision)
+	}
+	kvs := make([]*config.KeyValue, 0, len(rsp.Kvs))
+	for _, item := range rsp.Kvs {
+		kvs = append(kvs, config.NewKeyValue(s.options.path, string(item.Key), item.Value))
+	}
+	return kvs, nil
+}
+
+func (s *source) Watch() (config.Watcher, error) {
+	return newWatcher(s), nil
+}
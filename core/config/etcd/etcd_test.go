@@ -0,0 +1,151 @@
+package etcd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcd is an in-process stand-in for the etcd KV and Watcher APIs.
+type fakeEtcd struct {
+	clientv3.KV
+	clientv3.Watcher
+
+	mu       sync.Mutex
+	data     map[string]string
+	revision int64
+	watches  []clientv3.Op
+	watchers []chan clientv3.WatchResponse
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{data: make(map[string]string)}
+}
+
+func (f *fakeEtcd) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefix := len(clientv3.OpGet(key, opts...).RangeBytes()) > 0
+	rsp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.revision}}
+	for k, v := range f.data {
+		if k == key || (prefix && strings.HasPrefix(k, key)) {
+			rsp.Kvs = append(rsp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return rsp, nil
+}
+
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.watches = append(f.watches, clientv3.OpGet(key, opts...))
+	ch := make(chan clientv3.WatchResponse, 1)
+	f.watchers = append(f.watchers, ch)
+	return ch
+}
+
+func (f *fakeEtcd) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = value
+	f.revision++
+	for _, ch := range f.watchers {
+		ch <- clientv3.WatchResponse{}
+	}
+}
+
+// closeWatches 模拟版本被压缩或者连接断开时关闭所有监听通道
+func (f *fakeEtcd) closeWatches() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range f.watchers {
+		close(ch)
+	}
+	f.watchers = nil
+}
+
+func TestEtcdSource(t *testing.T) {
+	fake := newFakeEtcd()
+	fake.put("/app/config.yaml", "limit:\n  rate: 10\n")
+	fake.put("/app/rbac/policy", "v1")
+	fake.put("/app-old/rbac/policy", "v0")
+
+	source, err := NewSource(&clientv3.Client{KV: fake, Watcher: fake}, WithPath("/app"))
+	require.NoError(t, err)
+
+	c := config.New(config.WithSource(source))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	rate, err := c.Value("limit.rate").Int()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), rate)
+
+	policy, err := c.Value("rbac.policy").String()
+	require.NoError(t, err)
+	assert.Equal(t, "v1", policy)
+
+	// 监听 /app/ 目录，并从加载之后的版本开始
+	require.Len(t, fake.watches, 1)
+	assert.Equal(t, "/app/", string(fake.watches[0].KeyBytes()))
+	assert.Equal(t, int64(4), fake.watches[0].Rev())
+
+	changed := make(chan string, 1)
+	require.NoError(t, c.Watch("rbac.policy", func(_ string, v config.Value) {
+		s, _ := v.String()
+		changed <- s
+	}))
+
+	fake.put("/app/rbac/policy", "v2")
+
+	select {
+	case s := <-changed:
+		assert.Equal(t, "v2", s)
+	case <-time.After(2 * time.Second):
+		t.Fatal("observer was not called")
+	}
+}
+
+func TestEtcdSourceInvalidPath(t *testing.T) {
+	_, err := NewSource(&clientv3.Client{})
+	assert.Error(t, err)
+}
+
+func TestEtcdWatchReopensClosedChannel(t *testing.T) {
+	fake := newFakeEtcd()
+	fake.put("/app/rbac/policy", "v1")
+
+	s, err := NewSource(&clientv3.Client{KV: fake, Watcher: fake}, WithPath("/app"))
+	require.NoError(t, err)
+	_, err = s.Load()
+	require.NoError(t, err)
+
+	w, err := s.Watch()
+	require.NoError(t, err)
+
+	// 通道关闭期间的修改在重新加载时返回，并从最新版本重新监听
+	fake.closeWatches()
+	fake.put("/app/rbac/policy", "v2")
+
+	kvs, err := w.Next()
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+	assert.Equal(t, "v2", string(kvs[0].Value))
+	require.Len(t, fake.watches, 2)
+	assert.Equal(t, int64(3), fake.watches[1].Rev())
+
+	// Stop 之后才返回 context.Canceled
+	require.NoError(t, w.Stop())
+	_, err = w.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+}
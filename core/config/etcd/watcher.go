@@ -0,0 +1,69 @@
+package etcd
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/config"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	source *source
+	ch     clientv3.WatchChan
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(s *source) *watcher {
+	ctx, cancel := context.WithCancel(s.options.ctx)
+	w := &watcher{
+		source: s,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	w.watch()
+	return w
+}
+
+// watch 从加载之后的版本开始监听，加载和监听之间的修改不会丢失
+func (w *watcher) watch() {
+	opts := w.source.opOptions()
+	if rev := w.source.revision.Load(); rev > 0 {
+		opts = append(opts, clientv3.WithRev(rev+1))
+	}
+	w.ch = w.source.client.Watch(w.ctx, w.source.key(), opts...)
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case resp, ok := <-w.ch:
+		if !ok {
+			// 只有 Stop 之后才算监听结束
+			if err := w.ctx.Err(); err != nil {
+				return nil, err
+			}
+			// 版本被压缩或者连接断开时通道会关闭，重新加载并从最新版本继续监听
+			kvs, err := w.source.Load()
+			if err != nil {
+				return nil, err
+			}
+			w.watch()
+			return kvs, nil
+		}
+		if err := resp.Err(); err != nil {
+			return nil, err
+		}
+		return w.source.Load()
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
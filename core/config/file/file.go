@@ -0,0 +1,101 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+)
+
+var _ config.Source = (*file)(nil)
+
+const defaultInterval = time.Second
+
+type options struct {
+	interval time.Duration
+}
+
+// Option is file source option.
+type Option func(*options)
+
+// WithInterval 设置检查文件变化的周期
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+type file struct {
+	path string
+	opts options
+}
+
+// NewSource 创建文件配置源，path 可以是单个文件或目录
+// 文件格式由扩展名决定：json、yaml、yml、toml，目录中其他扩展名的文件会被忽略
+func NewSource(path string, opts ...Option) config.Source {
+	o := options{interval: defaultInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &file{path: path, opts: o}
+}
+
+func (f *file) Load() ([]*config.KeyValue, error) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		kv, err := f.loadFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		return []*config.KeyValue{kv}, nil
+	}
+	return f.loadDir(f.path)
+}
+
+func (f *file) loadDir(path string) ([]*config.KeyValue, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	kvs := make([]*config.KeyValue, 0, len(entries))
+	for _, entry := range entries {
+		// 忽略子目录、隐藏文件以及没有解码器的文件，例如 README.md
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !config.HasCodec(format(entry.Name())) {
+			continue
+		}
+		kv, err := f.loadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, nil
+}
+
+func (f *file) loadFile(path string) (*config.KeyValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &config.KeyValue{
+		Key:    filepath.Base(path),
+		Value:  data,
+		Format: format(path),
+	}, nil
+}
+
+func (f *file) Watch() (config.Watcher, error) {
+	return newWatcher(f)
+}
+
+func format(path string) string {
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+)
+
+var _ config.Watcher = (*watcher)(nil)
+
+// watcher 周期性检查文件的修改时间和大小
+type watcher struct {
+	f    *file
+	last string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(f *file) (config.Watcher, error) {
+	last, err := fingerprint(f.path)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{f: f, last: last, ctx: ctx, cancel: cancel}, nil
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	ticker := time.NewTicker(w.f.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-ticker.C:
+			fp, err := fingerprint(w.f.path)
+			if err != nil {
+				return nil, err
+			}
+			if fp == w.last {
+				continue
+			}
+			w.last = fp
+			return w.f.Load()
+		}
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+// fingerprint 由文件名、修改时间和大小组成，用于判断文件是否变化
+func fingerprint(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !fi.IsDir() {
+		return fmt.Sprintf("%d-%d", fi.ModTime().UnixNano(), fi.Size()), nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d-%d;", entry.Name(), info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,62 @@
+package flag
+
+import (
+	"context"
+	"strings"
+
+	"github.com/taluos/Malt/core/config"
+
+	"github.com/spf13/pflag"
+)
+
+var _ config.Source = (*flagSource)(nil)
+
+type flagSource struct {
+	fs *pflag.FlagSet
+}
+
+// NewSource 创建命令行参数配置源
+// 只加载命令行中显式设置过的参数，参数名即配置路径，例如 --grpc.address
+// fs 为 nil 时使用 pflag.CommandLine
+func NewSource(fs *pflag.FlagSet) config.Source {
+	if fs == nil {
+		fs = pflag.CommandLine
+	}
+	return &flagSource{fs: fs}
+}
+
+func (f *flagSource) Load() ([]*config.KeyValue, error) {
+	kvs := make([]*config.KeyValue, 0)
+	f.fs.Visit(func(fl *pflag.Flag) {
+		v := fl.Value.String()
+		if sv, ok := fl.Value.(pflag.SliceValue); ok {
+			v = strings.Join(sv.GetSlice(), ",")
+		}
+		kvs = append(kvs, &config.KeyValue{
+			Key:   fl.Name,
+			Value: []byte(v),
+		})
+	})
+	return kvs, nil
+}
+
+func (f *flagSource) Watch() (config.Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{ctx: ctx, cancel: cancel}, nil
+}
+
+// watcher 命令行参数在进程启动后不会变化，Next 一直阻塞到 Stop
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// NewKeyValue 将远程 KV 存储中 prefix 下的一项转换为配置项
+// 扩展名为已注册格式（json、yaml、toml）的键按文档解析，
+// 其他键去掉 prefix 后转换为 "." 分隔的路径，例如 /app/limit/rate 对应 limit.rate
+func NewKeyValue(prefix, key string, value []byte) *KeyValue {
+	if format := strings.TrimPrefix(path.Ext(key), "."); format != "" {
+		if _, ok := getCodec(format); ok {
+			return &KeyValue{Key: key, Value: value, Format: format}
+		}
+	}
+
+	rel := strings.TrimPrefix(key, prefix)
+	rel = strings.Trim(rel, "/")
+	return &KeyValue{
+		Key:   strings.ReplaceAll(rel, "/", "."),
+		Value: value,
+	}
+}
@@ -0,0 +1,148 @@
+package nacos
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/taluos/Malt/core/config"
+	"github.com/taluos/Malt/pkg/errors"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+)
+
+var _ config.Source = (*source)(nil)
+
+type options struct {
+	group  string
+	dataID string
+	format string
+}
+
+// Option is nacos config source option.
+type Option func(*options)
+
+// WithGroup 设置配置分组
+func WithGroup(group string) Option {
+	return func(o *options) {
+		o.group = group
+	}
+}
+
+// WithDataID 设置配置的 dataId
+func WithDataID(dataID string) Option {
+	return func(o *options) {
+		o.dataID = dataID
+	}
+}
+
+// WithFormat 设置配置格式，默认使用 dataId 的扩展名
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+type source struct {
+	client  config_client.IConfigClient
+	options options
+}
+
+// NewSource 创建 nacos 配置源
+func NewSource(client config_client.IConfigClient, opts ...Option) (config.Source, error) {
+	o := options{
+		group: "DEFAULT_GROUP",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.dataID == "" {
+		return nil, errors.New("[Config] nacos dataId invalid")
+	}
+	if o.format == "" {
+		o.format = strings.TrimPrefix(path.Ext(o.dataID), ".")
+	}
+
+	return &source{client: client, options: o}, nil
+}
+
+func (s *source) param() vo.ConfigParam {
+	return vo.ConfigParam{
+		DataId: s.options.dataID,
+		Group:  s.options.group,
+	}
+}
+
+func (s *source) Load() ([]*config.KeyValue, error) {
+	content, err := s.client.GetConfig(s.param())
+	if err != nil {
+		return nil, err
+	}
+	return s.keyValues(content), nil
+}
+
+func (s *source) keyValues(content string) []*config.KeyValue {
+	return []*config.KeyValue{{
+		Key:    s.options.dataID,
+		Value:  []byte(content),
+		Format: s.options.format,
+	}}
+}
+
+func (s *source) Watch() (config.Watcher, error) {
+	return newWatcher(s)
+}
+
+var _ config.Watcher = (*watcher)(nil)
+
+type watcher struct {
+	source  *source
+	content chan string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWatcher(s *source) (*watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		source:  s,
+		content: make(chan string, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	param := s.param()
+	param.OnChange = func(_, group, dataID, data string) {
+		if group != s.options.group || dataID != s.options.dataID {
+			return
+		}
+		// 只保留最新的一次变化
+		select {
+		case <-w.content:
+		default:
+		}
+		w.content <- data
+	}
+	if err := s.client.ListenConfig(param); err != nil {
+		cancel()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case content := <-w.content:
+		return w.source.keyValues(content), nil
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return w.source.client.CancelListenConfig(w.source.param())
+}
@@ -0,0 +1,83 @@
+package nacos
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/config"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNacos is an in-process stand-in for the nacos config client.
+type fakeNacos struct {
+	config_client.IConfigClient
+
+	mu       sync.Mutex
+	content  string
+	onChange func(namespace, group, dataId, data string)
+}
+
+func (f *fakeNacos) GetConfig(vo.ConfigParam) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.content, nil
+}
+
+func (f *fakeNacos) ListenConfig(param vo.ConfigParam) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onChange = param.OnChange
+	return nil
+}
+
+func (f *fakeNacos) CancelListenConfig(vo.ConfigParam) error {
+	return nil
+}
+
+func (f *fakeNacos) publish(group, dataID, content string) {
+	f.mu.Lock()
+	f.content = content
+	onChange := f.onChange
+	f.mu.Unlock()
+	onChange("public", group, dataID, content)
+}
+
+func TestNacosSource(t *testing.T) {
+	fake := &fakeNacos{content: "limit:\n  rate: 10\n"}
+
+	source, err := NewSource(fake, WithDataID("app.yaml"))
+	require.NoError(t, err)
+
+	c := config.New(config.WithSource(source))
+	require.NoError(t, c.Load())
+	defer c.Close()
+
+	rate, err := c.Value("limit.rate").Int()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), rate)
+
+	changed := make(chan int64, 1)
+	require.NoError(t, c.Watch("limit.rate", func(_ string, v config.Value) {
+		n, _ := v.Int()
+		changed <- n
+	}))
+
+	fake.publish("DEFAULT_GROUP", "app.yaml", "limit:\n  rate: 20\n")
+
+	select {
+	case n := <-changed:
+		assert.Equal(t, int64(20), n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("observer was not called")
+	}
+}
+
+func TestNacosSourceInvalidDataID(t *testing.T) {
+	_, err := NewSource(&fakeNacos{})
+	assert.Error(t, err)
+}
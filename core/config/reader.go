@@ -0,0 +1,168 @@
+package config
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/taluos/Malt/pkg/errors"
+)
+
+// reader 保存每个配置源的最新数据，并按配置源顺序合并成一棵配置树
+type reader struct {
+	mu      sync.RWMutex
+	sources [][]*KeyValue
+	values  map[string]any
+}
+
+func newReader(n int) *reader {
+	return &reader{
+		sources: make([][]*KeyValue, n),
+		values:  make(map[string]any),
+	}
+}
+
+// apply 更新第 i 个配置源的数据并重新合并
+func (r *reader) apply(i int, kvs []*KeyValue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sources := make([][]*KeyValue, len(r.sources))
+	copy(sources, r.sources)
+	sources[i] = kvs
+
+	values := make(map[string]any)
+	for _, src := range sources {
+		for _, kv := range src {
+			if err := mergeKeyValue(values, kv); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.sources = sources
+	r.values = values
+	return nil
+}
+
+// snapshot 返回配置树的副本
+func (r *reader) snapshot() map[string]any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return cloneMap(r.values)
+}
+
+// value 按 "." 分隔的路径查找配置值
+func (r *reader) value(path string) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if path == "" {
+		return cloneMap(r.values), true
+	}
+
+	var cur any = r.values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	if m, ok := cur.(map[string]any); ok {
+		return cloneMap(m), true
+	}
+	return cur, true
+}
+
+func mergeKeyValue(dst map[string]any, kv *KeyValue) error {
+	if kv == nil {
+		return nil
+	}
+
+	// 没有格式的配置项是单个路径上的字符串值
+	if kv.Format == "" {
+		if kv.Key == "" {
+			return nil
+		}
+		setPath(dst, strings.Split(kv.Key, "."), string(kv.Value))
+		return nil
+	}
+
+	codec, ok := getCodec(kv.Format)
+	if !ok {
+		return errors.Errorf("unsupported config format %q of %s", kv.Format, kv.Key)
+	}
+	src := make(map[string]any)
+	if err := codec(kv.Value, &src); err != nil {
+		return errors.Wrapf(err, "decode config %s failed", kv.Key)
+	}
+	mergeMap(dst, normalize(src).(map[string]any))
+	return nil
+}
+
+func setPath(dst map[string]any, keys []string, v any) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := dst[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			dst[key] = next
+		}
+		dst = next
+	}
+	dst[keys[len(keys)-1]] = v
+}
+
+// mergeMap 将 src 深度合并到 dst 中，src 的值优先
+func mergeMap(dst, src map[string]any) {
+	for k, v := range src {
+		sm, sok := v.(map[string]any)
+		dm, dok := dst[k].(map[string]any)
+		if sok && dok {
+			mergeMap(dm, sm)
+			continue
+		}
+		if sok {
+			dst[k] = cloneMap(sm)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func cloneMap(src map[string]any) map[string]any {
+	dst := make(map[string]any, len(src))
+	for k, v := range src {
+		if m, ok := v.(map[string]any); ok {
+			dst[k] = cloneMap(m)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// normalize 将解码得到的 map[any]any 统一转换为 map[string]any
+func normalize(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			t[k] = normalize(val)
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[toString(k)] = normalize(val)
+		}
+		return m
+	case []any:
+		for i, val := range t {
+			t[i] = normalize(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Value 配置值
+type Value interface {
+	// Exists 配置路径是否存在
+	Exists() bool
+	Bool() (bool, error)
+	Int() (int64, error)
+	Float() (float64, error)
+	String() (string, error)
+	Duration() (time.Duration, error)
+	// Scan 将配置值解析到结构体、切片或基础类型中
+	Scan(v any) error
+	// Load 返回原始值
+	Load() any
+}
+
+type value struct {
+	key    string
+	v      any
+	exists bool
+}
+
+func newValue(key string, v any, exists bool) Value {
+	return &value{key: key, v: v, exists: exists}
+}
+
+func (v *value) Exists() bool {
+	return v.exists
+}
+
+func (v *value) Load() any {
+	return v.v
+}
+
+func (v *value) notFound() error {
+	return errors.Wrapf(ErrNotFound, "config %s", v.key)
+}
+
+func (v *value) typeAssertError() error {
+	return errors.Errorf("config %s: type assert to %T failed", v.key, v.v)
+}
+
+func (v *value) Bool() (bool, error) {
+	if !v.exists {
+		return false, v.notFound()
+	}
+	switch t := v.v.(type) {
+	case bool:
+		return t, nil
+	case string:
+		return strconv.ParseBool(t)
+	case int, int64, float64:
+		return toString(t) != "0", nil
+	}
+	return false, v.typeAssertError()
+}
+
+func (v *value) Int() (int64, error) {
+	if !v.exists {
+		return 0, v.notFound()
+	}
+	switch t := v.v.(type) {
+	case int:
+		return int64(t), nil
+	case int64:
+		return t, nil
+	case uint64:
+		return int64(t), nil
+	case float64:
+		return int64(t), nil
+	case string:
+		return strconv.ParseInt(t, 10, 64)
+	}
+	return 0, v.typeAssertError()
+}
+
+func (v *value) Float() (float64, error) {
+	if !v.exists {
+		return 0, v.notFound()
+	}
+	switch t := v.v.(type) {
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case float64:
+		return t, nil
+	case string:
+		return strconv.ParseFloat(t, 64)
+	}
+	return 0, v.typeAssertError()
+}
+
+func (v *value) String() (string, error) {
+	if !v.exists {
+		return "", v.notFound()
+	}
+	switch v.v.(type) {
+	case map[string]any, []any:
+		return "", v.typeAssertError()
+	}
+	return toString(v.v), nil
+}
+
+func (v *value) Duration() (time.Duration, error) {
+	if !v.exists {
+		return 0, v.notFound()
+	}
+	if s, ok := v.v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	}
+	n, err := v.Int()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n), nil
+}
+
+func (v *value) Scan(out any) error {
+	if !v.exists {
+		return v.notFound()
+	}
+	return decode(v.v, out)
+}
+
+// decode 使用 json 标签将配置树解析到 out 中，字符串会按目标类型做弱类型转换
+func decode(in any, out any) error {
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "json",
+		WeaklyTypedInput: true,
+		Result:           out,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	return d.Decode(in)
+}
+
+func toString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
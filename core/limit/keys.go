@@ -0,0 +1,61 @@
+package limit
+
+import (
+	"context"
+	"strings"
+
+	authJWT "github.com/taluos/Malt/pkg/auth-jwt"
+)
+
+// KeyType 限流键的维度，多个维度组合成一个限流键
+type KeyType string
+
+const (
+	// KeyRoute 按路由限流，gin 使用路由模板（例如 /api/v1/users/:id），fiber 使用请求路径，gRPC 使用方法全名
+	KeyRoute KeyType = "route"
+	// KeyIP 按客户端 IP 限流
+	KeyIP KeyType = "ip"
+	// KeyUser 按 JWT 中的用户 ID 限流，未认证的请求按客户端 IP 限流
+	KeyUser KeyType = "user"
+	// KeyMethod 按方法限流，gRPC 使用方法全名，HTTP 使用 METHOD:route
+	KeyMethod KeyType = "method"
+
+	headerKeyPrefix = "header:"
+)
+
+// globalKey 没有配置任何维度时所有请求共用的限流键
+const globalKey = "global"
+
+// KeyHeader 按请求头限流，gRPC 读取同名的 metadata，例如 KeyHeader("X-Api-Key")
+func KeyHeader(name string) KeyType {
+	return KeyType(headerKeyPrefix + name)
+}
+
+// Header 返回按请求头限流时的请求头名称
+func (t KeyType) Header() (string, bool) {
+	return strings.CutPrefix(string(t), headerKeyPrefix)
+}
+
+// BuildKey 按 types 的顺序拼接各维度的取值，value 返回某个维度在当前请求中的取值
+func BuildKey(types []KeyType, value func(KeyType) string) string {
+	if len(types) == 0 {
+		return globalKey
+	}
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, string(t)+"="+value(t))
+	}
+	return strings.Join(parts, ":")
+}
+
+// UserID 返回上下文中 JWT 的用户 ID，没有用户 ID 时使用 sub
+func UserID(ctx context.Context) string {
+	claims, ok := authJWT.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if claims.UserID != "" {
+		return claims.UserID
+	}
+	return claims.Subject
+}
@@ -0,0 +1,151 @@
+// Package limit 提供基于 Redis 的分布式限流：令牌桶和滑动窗口两种算法都通过 Lua 脚本原子执行，
+// Redis 不可用时自动退化为进程内的 x/time/rate 限流器，Redis 恢复后切回分布式限流。
+// Registry 提供单机按 key 限流，空闲的 key 按 LRU 和 TTL 淘汰
+package limit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/pkg/log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultName   = "default"
+	defaultPrefix = "malt:limit:"
+
+	// Redis 不可用时探活的间隔和超时
+	pingInterval = 100 * time.Millisecond
+	pingTimeout  = time.Second
+)
+
+// ErrLimitExceeded 请求超过限流配额时返回的错误
+var ErrLimitExceeded = errors.WithCode(code.ErrTooManyRequests, "too many requests")
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 判断 key 对应的请求是否放行，超过配额时返回 ErrLimitExceeded
+	Allow(ctx context.Context, key string) error
+}
+
+type limitOptions struct {
+	name   string
+	prefix string
+}
+
+// Option 限流器选项
+type Option func(*limitOptions)
+
+// WithName 设置限流器名称，用于指标标签
+func WithName(name string) Option {
+	return func(o *limitOptions) {
+		o.name = name
+	}
+}
+
+// WithPrefix 设置 Redis 键的前缀，默认 malt:limit:
+func WithPrefix(prefix string) Option {
+	return func(o *limitOptions) {
+		o.prefix = prefix
+	}
+}
+
+func newLimitOptions(opts []Option) limitOptions {
+	o := limitOptions{
+		name:   defaultName,
+		prefix: defaultPrefix,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// redisLimiter 令牌桶和滑动窗口共用的执行逻辑：优先执行 Lua 脚本，失败时使用本地限流器
+type redisLimiter struct {
+	name   string
+	prefix string
+	client redis.UniversalClient
+	script *redis.Script
+	// args 返回本次执行脚本的参数
+	args func(now time.Time) []any
+
+	// Redis 不可用时使用的本地令牌桶
+	local     *Registry
+	localRate Rate
+
+	redisAlive     atomic.Bool
+	monitorStarted atomic.Bool
+}
+
+func newRedisLimiter(client redis.UniversalClient, script *redis.Script, prefix string,
+	args func(now time.Time) []any, localRate Rate, o limitOptions) *redisLimiter {
+	l := &redisLimiter{
+		name:      o.name,
+		prefix:    o.prefix + prefix,
+		client:    client,
+		script:    script,
+		args:      args,
+		local:     NewRegistry(),
+		localRate: localRate,
+	}
+	l.redisAlive.Store(client != nil)
+	return l
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string) error {
+	if l.redisAlive.Load() {
+		allowed, err := l.script.Run(ctx, l.client, []string{l.prefix + key}, l.args(time.Now())...).Int()
+		if err == nil {
+			return l.result(allowed == 1, "redis")
+		}
+		// 调用方取消的请求不代表 Redis 故障
+		if ctx.Err() == nil {
+			log.Errorf("[Limit] %s redis unavailable, fallback to local limiter: %v", l.name, err)
+			l.startMonitor()
+		}
+	}
+	return l.result(l.local.Allow(key, l.localRate), "local")
+}
+
+func (l *redisLimiter) result(allowed bool, backend string) error {
+	if !allowed {
+		metricLimitReqTotal.Inc(l.name, backend, "drop")
+		return ErrLimitExceeded
+	}
+	metricLimitReqTotal.Inc(l.name, backend, "pass")
+	return nil
+}
+
+// startMonitor 标记 Redis 不可用，并在后台探活直到 Redis 恢复
+func (l *redisLimiter) startMonitor() {
+	l.redisAlive.Store(false)
+	if !l.monitorStarted.CompareAndSwap(false, true) {
+		return
+	}
+	go l.waitForRedis()
+}
+
+func (l *redisLimiter) waitForRedis() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := l.client.Ping(ctx).Err()
+		cancel()
+		if err != nil {
+			continue
+		}
+		// 切回 Redis 后丢弃故障期间的本地计数
+		l.local.Reset()
+		l.redisAlive.Store(true)
+		l.monitorStarted.Store(false)
+		log.Infof("[Limit] %s redis recovered", l.name)
+		return
+	}
+}
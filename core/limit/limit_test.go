@@ -0,0 +1,115 @@
+package limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func allowN(l Limiter, key string, n int) int {
+	passed := 0
+	for i := 0; i < n; i++ {
+		if l.Allow(context.Background(), key) == nil {
+			passed++
+		}
+	}
+	return passed
+}
+
+func TestTokenLimiter(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewTokenLimiter(client, 1, 5, WithName("test"))
+
+	assert.Equal(t, 5, allowN(l, "a", 10))
+	// 不同的键互不影响
+	assert.Equal(t, 5, allowN(l, "b", 10))
+
+	err := l.Allow(context.Background(), "a")
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.True(t, errors.IsCode(err, code.ErrTooManyRequests))
+
+	assert.True(t, mr.Exists(defaultPrefix+"token:a"))
+	assert.Greater(t, mr.TTL(defaultPrefix+"token:a"), time.Duration(0))
+}
+
+func TestTokenLimiterRefill(t *testing.T) {
+	_, client := newTestRedis(t)
+	l := NewTokenLimiter(client, 100, 1, WithPrefix("test:"))
+
+	require.NoError(t, l.Allow(context.Background(), "a"))
+	assert.Error(t, l.Allow(context.Background(), "a"))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, l.Allow(context.Background(), "a"))
+}
+
+func TestPeriodLimiter(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewPeriodLimiter(client, 50*time.Millisecond, 3)
+
+	assert.Equal(t, 3, allowN(l, "a", 10))
+	assert.Equal(t, 3, allowN(l, "b", 10))
+	members, err := mr.ZMembers(defaultPrefix + "period:a")
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	// 窗口滑过之后重新放行
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 3, allowN(l, "a", 10))
+}
+
+func TestLimiterFallback(t *testing.T) {
+	mr, client := newTestRedis(t)
+	l := NewTokenLimiter(client, 1, 2).(*redisLimiter)
+
+	mr.Close()
+	assert.Equal(t, 2, allowN(l, "a", 5))
+	assert.False(t, l.redisAlive.Load())
+
+	// Redis 恢复后切回分布式限流，故障期间的本地计数被丢弃
+	require.NoError(t, mr.Restart())
+	assert.Eventually(t, l.redisAlive.Load, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, allowN(l, "a", 5))
+	assert.True(t, mr.Exists(defaultPrefix+"token:a"))
+}
+
+func TestLocalLimiter(t *testing.T) {
+	l := NewPeriodLimiter(nil, time.Second, 2)
+	assert.Equal(t, 2, allowN(l, "a", 5))
+	assert.Equal(t, 2, allowN(l, "b", 5))
+}
+
+func TestBuildKey(t *testing.T) {
+	value := func(t KeyType) string {
+		switch t {
+		case KeyRoute:
+			return "/users/:id"
+		case KeyIP:
+			return "127.0.0.1"
+		}
+		return ""
+	}
+	assert.Equal(t, "global", BuildKey(nil, value))
+	assert.Equal(t, "route=/users/:id:ip=127.0.0.1", BuildKey([]KeyType{KeyRoute, KeyIP}, value))
+
+	name, ok := KeyHeader("X-Api-Key").Header()
+	assert.True(t, ok)
+	assert.Equal(t, "X-Api-Key", name)
+	_, ok = KeyIP.Header()
+	assert.False(t, ok)
+}
@@ -0,0 +1,18 @@
+package limit
+
+import (
+	metric "github.com/taluos/Malt/core/metrics"
+)
+
+const (
+	// Namespace defines a logical grouping of limiters.
+	Namespace = "limit"
+)
+
+var metricLimitReqTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: Namespace,
+	Subsystem: "requests",
+	Name:      "total",
+	Help:      "rate limiter requests count.",
+	Labels:    []string{"name", "backend", "result"},
+})
@@ -0,0 +1,43 @@
+package limit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// periodScript 滑动窗口，有序集合中保存窗口内每个请求的时间戳
+var periodScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local quota = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= quota then
+	return 0
+end
+
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], window)
+return 1
+`)
+
+// NewPeriodLimiter 创建滑动窗口限流器，任意 window 时长内最多放行 quota 个请求。
+// client 为 nil 时只使用本地限流器，本地限流器以 window/quota 的速率补充配额
+func NewPeriodLimiter(client redis.UniversalClient, window time.Duration, quota int, opts ...Option) Limiter {
+	o := newLimitOptions(opts)
+
+	// 有序集合的成员需要在多个实例之间唯一
+	instance := rand.Uint64()
+	var seq atomic.Uint64
+	args := func(now time.Time) []any {
+		return []any{
+			window.Milliseconds(), quota, now.UnixMilli(),
+			fmt.Sprintf("%d-%x-%d", now.UnixNano(), instance, seq.Add(1)),
+		}
+	}
+	return newRedisLimiter(client, periodScript, "period:", args, PerPeriod(quota, window), o)
+}
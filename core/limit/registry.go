@@ -0,0 +1,209 @@
+package limit
+
+import (
+	"container/list"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultMaxKeys     = 10000
+	defaultIdleTimeout = 10 * time.Minute
+)
+
+// 限流响应头，参考 IETF RateLimit header fields 草案
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// Rate 令牌桶配额，每秒补充 Limit 个令牌，桶容量为 Burst
+type Rate struct {
+	Limit float64
+	Burst int
+}
+
+// PerPeriod 返回 period 时长内最多放行 quota 个请求的配额
+func PerPeriod(quota int, period time.Duration) Rate {
+	return Rate{Limit: float64(quota) / period.Seconds(), Burst: quota}
+}
+
+// Result 一次限流判断的结果，可用于填充 RateLimit-* 响应头
+type Result struct {
+	// Allowed 是否放行
+	Allowed bool
+	// Limit 桶容量
+	Limit int
+	// Remaining 本次请求之后剩余的令牌数
+	Remaining int
+	// Reset 令牌桶补满所需的时间
+	Reset time.Duration
+	// RetryAfter 被拒绝时距离下一个可用令牌的时间
+	RetryAfter time.Duration
+}
+
+// WriteHeaders 通过 set 写入 RateLimit-* 响应头，被拒绝时附带 Retry-After，时间单位为秒
+func (r Result) WriteHeaders(set func(key, value string)) {
+	set(HeaderLimit, strconv.Itoa(r.Limit))
+	set(HeaderRemaining, strconv.Itoa(r.Remaining))
+	set(HeaderReset, strconv.Itoa(seconds(r.Reset)))
+	if !r.Allowed && r.RetryAfter != rate.InfDuration {
+		set(HeaderRetryAfter, strconv.Itoa(max(1, seconds(r.RetryAfter))))
+	}
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+type registryOptions struct {
+	maxKeys     int
+	idleTimeout time.Duration
+}
+
+// RegistryOption 限流器注册表选项
+type RegistryOption func(*registryOptions)
+
+// WithMaxKeys 设置最多保存的 key 数量，超过时淘汰最久未访问的 key，默认 10000
+func WithMaxKeys(maxKeys int) RegistryOption {
+	return func(o *registryOptions) {
+		o.maxKeys = maxKeys
+	}
+}
+
+// WithIdleTimeout 设置 key 的空闲过期时间，默认 10 分钟
+func WithIdleTimeout(timeout time.Duration) RegistryOption {
+	return func(o *registryOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+// Registry 按 key 保存进程内令牌桶，空闲的 key 按 LRU 和 TTL 淘汰
+type Registry struct {
+	maxKeys     int
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru 按最近访问时间排序，表头最新
+	lru *list.List
+}
+
+type registryEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRegistry 创建限流器注册表
+func NewRegistry(opts ...RegistryOption) *Registry {
+	o := registryOptions{
+		maxKeys:     defaultMaxKeys,
+		idleTimeout: defaultIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Registry{
+		maxKeys:     o.maxKeys,
+		idleTimeout: o.idleTimeout,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+// Take 从 key 对应的令牌桶中取一个令牌，key 第一次出现时按 rt 创建令牌桶
+func (r *Registry) Take(key string, rt Rate) Result {
+	now := time.Now()
+	limiter := r.get(key, rt, now)
+
+	res := Result{Limit: limiter.Burst()}
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); !reservation.OK() || delay > 0 {
+		// 没有令牌时归还预留，不占用后续请求的配额
+		reservation.CancelAt(now)
+		res.RetryAfter = delay
+		if !reservation.OK() {
+			res.RetryAfter = rate.InfDuration
+		}
+	} else {
+		res.Allowed = true
+	}
+
+	tokens := limiter.TokensAt(now)
+	res.Remaining = int(math.Max(0, math.Floor(tokens)))
+	if limit := float64(limiter.Limit()); limit > 0 {
+		missing := float64(limiter.Burst()) - tokens
+		res.Reset = time.Duration(math.Max(0, missing) / limit * float64(time.Second))
+	}
+	return res
+}
+
+// Allow 判断 key 对应的请求是否放行
+func (r *Registry) Allow(key string, rt Rate) bool {
+	return r.Take(key, rt).Allowed
+}
+
+// Len 返回当前保存的 key 数量
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lru.Len()
+}
+
+// Reset 清空所有令牌桶
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	r.entries = make(map[string]*list.Element)
+	r.lru.Init()
+	r.mu.Unlock()
+}
+
+func (r *Registry) get(key string, rt Rate, now time.Time) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictIdle(now)
+	if elem, ok := r.entries[key]; ok {
+		entry := elem.Value.(*registryEntry)
+		entry.lastSeen = now
+		r.lru.MoveToFront(elem)
+		return entry.limiter
+	}
+
+	entry := &registryEntry{
+		key:      key,
+		limiter:  rate.NewLimiter(rate.Limit(rt.Limit), rt.Burst),
+		lastSeen: now,
+	}
+	r.entries[key] = r.lru.PushFront(entry)
+	for r.maxKeys > 0 && r.lru.Len() > r.maxKeys {
+		r.remove(r.lru.Back())
+	}
+	return entry.limiter
+}
+
+// evictIdle 从表尾开始淘汰空闲超时的 key
+func (r *Registry) evictIdle(now time.Time) {
+	if r.idleTimeout <= 0 {
+		return
+	}
+	for elem := r.lru.Back(); elem != nil; elem = r.lru.Back() {
+		if now.Sub(elem.Value.(*registryEntry).lastSeen) < r.idleTimeout {
+			return
+		}
+		r.remove(elem)
+	}
+}
+
+func (r *Registry) remove(elem *list.Element) {
+	r.lru.Remove(elem)
+	delete(r.entries, elem.Value.(*registryEntry).key)
+}
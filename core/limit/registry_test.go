@@ -0,0 +1,71 @@
+package limit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryTake(t *testing.T) {
+	r := NewRegistry()
+	rt := Rate{Limit: 1, Burst: 2}
+
+	res := r.Take("a", rt)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Limit)
+	assert.Equal(t, 1, res.Remaining)
+
+	assert.True(t, r.Allow("a", rt))
+	res = r.Take("a", rt)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Greater(t, res.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, res.RetryAfter, time.Second)
+	assert.Greater(t, res.Reset, time.Second)
+
+	// 不同的 key 使用独立的令牌桶
+	assert.True(t, r.Allow("b", rt))
+}
+
+func TestRegistryEvict(t *testing.T) {
+	r := NewRegistry(WithMaxKeys(2), WithIdleTimeout(20*time.Millisecond))
+	rt := Rate{Limit: 1, Burst: 1}
+
+	r.Take("a", rt)
+	r.Take("b", rt)
+	// a 重新被访问，c 加入时淘汰最久未访问的 b
+	r.Take("a", rt)
+	r.Take("c", rt)
+	assert.Equal(t, 2, r.Len())
+	assert.True(t, r.Allow("b", rt))
+
+	time.Sleep(30 * time.Millisecond)
+	r.Take("d", rt)
+	assert.Equal(t, 1, r.Len())
+	// 空闲过期的 key 重新创建令牌桶
+	assert.True(t, r.Allow("a", rt))
+
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestResultWriteHeaders(t *testing.T) {
+	headers := map[string]string{}
+	set := func(k, v string) { headers[k] = v }
+
+	Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 1500 * time.Millisecond}.WriteHeaders(set)
+	assert.Equal(t, map[string]string{
+		HeaderLimit:     "10",
+		HeaderRemaining: "9",
+		HeaderReset:     "2",
+	}, headers)
+
+	Result{Limit: 10, RetryAfter: 100 * time.Millisecond}.WriteHeaders(set)
+	assert.Equal(t, strconv.Itoa(1), headers[HeaderRetryAfter])
+}
+
+func TestPerPeriod(t *testing.T) {
+	assert.Equal(t, Rate{Limit: 2, Burst: 120}, PerPeriod(120, time.Minute))
+}
@@ -0,0 +1,47 @@
+package limit
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenScript 令牌桶，按距离上次请求的时间补充令牌，
+// 键在桶填满所需时间的两倍后过期
+var tokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = math.max(1, math.ceil(capacity / rate * 2))
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+end
+if ts == nil then
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("EXPIRE", KEYS[1], ttl)
+return allowed
+`)
+
+// NewTokenLimiter 创建令牌桶限流器，每秒补充 r(>0) 个令牌，桶容量为 burst。
+// client 为 nil 时只使用本地限流器
+func NewTokenLimiter(client redis.UniversalClient, r float64, burst int, opts ...Option) Limiter {
+	o := newLimitOptions(opts)
+	args := func(now time.Time) []any {
+		return []any{r, burst, now.UnixMilli()}
+	}
+	return newRedisLimiter(client, tokenScript, "token:", args, Rate{Limit: r, Burst: burst}, o)
+}
@@ -0,0 +1,54 @@
+package load
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// cpu 采样间隔
+	cpuRefreshInterval = 250 * time.Millisecond
+	// 滑动平均的衰减系数
+	cpuBeta = 0.95
+)
+
+var (
+	cpuUsage atomic.Int64
+	cpuOnce  sync.Once
+)
+
+// CpuUsage 返回当前进程的 cpu 使用率，单位为千分之一，1000 表示 GOMAXPROCS 个核心全部占满，
+// 不支持采样的平台上始终返回 0
+func CpuUsage() int64 {
+	cpuOnce.Do(startCpuSampler)
+	return cpuUsage.Load()
+}
+
+// startCpuSampler 在后台周期性采样进程 cpu 时间，并计算滑动平均
+func startCpuSampler() {
+	last, ok := processCpuTime()
+	if !ok {
+		return
+	}
+	lastAt := time.Now()
+
+	go func() {
+		ticker := time.NewTicker(cpuRefreshInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			cur, _ := processCpuTime()
+			now := time.Now()
+
+			elapsed := now.Sub(lastAt) * time.Duration(runtime.GOMAXPROCS(0))
+			if elapsed > 0 {
+				usage := int64((cur - last) * 1000 / elapsed)
+				prev := cpuUsage.Load()
+				cpuUsage.Store(int64(float64(prev)*cpuBeta + float64(usage)*(1-cpuBeta)))
+			}
+			last, lastAt = cur, now
+		}
+	}()
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9 || js || wasip1
+
+package load
+
+import "time"
+
+// processCpuTime 当前平台不支持采样，cpu 使用率始终为 0，
+// 需要降载时可以通过 WithCpuThreshold(0) 只根据并发数和延迟判断
+func processCpuTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package load
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCpuTime 返回进程累计使用的用户态和内核态 cpu 时间
+func processCpuTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
@@ -0,0 +1,18 @@
+package load
+
+import (
+	metric "github.com/taluos/Malt/core/metrics"
+)
+
+const (
+	// Namespace defines a logical grouping of shedders.
+	Namespace = "load"
+)
+
+var metricShedderDropTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: Namespace,
+	Subsystem: "shedding",
+	Name:      "drop_total",
+	Help:      "adaptive shedder dropped requests count.",
+	Labels:    []string{"name"},
+})
@@ -0,0 +1,232 @@
+// Package load 提供服务端自适应降载：
+// cpu 使用率超过阈值时，根据最近的通过数和最小延迟估算系统容量，拒绝超出容量的请求
+package load
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/pkg/window"
+)
+
+const (
+	defaultBuckets      = 50
+	defaultWindow       = 5 * time.Second
+	defaultCpuThreshold = 900
+	defaultName         = "default"
+
+	// 没有统计数据时使用的最小延迟，单位 ms
+	defaultMinRt = float64(time.Second / time.Millisecond)
+	// 并发数滑动平均的衰减系数
+	flyingBeta = 0.9
+	// 丢弃请求之后的冷却时间，冷却期内即使 cpu 回落也继续按容量降载
+	coolOffDuration = time.Second
+)
+
+// ErrServiceOverloaded 降载拒绝请求时返回的错误
+var ErrServiceOverloaded = errors.WithCode(code.ErrServiceOverloaded, "service overloaded")
+
+// Promise 请求通过后的回调，请求结束时必须调用 Pass 或 Fail 之一
+type Promise interface {
+	// Pass 请求处理完成
+	Pass()
+	// Fail 请求处理失败，不计入通过数和延迟
+	Fail()
+}
+
+// Shedder 降载器
+type Shedder interface {
+	// Allow 判断是否放行请求，拒绝时返回 ErrServiceOverloaded
+	Allow() (Promise, error)
+}
+
+type shedderOptions struct {
+	name         string
+	window       time.Duration
+	buckets      int
+	cpuThreshold int64
+}
+
+// ShedderOption 降载器选项
+type ShedderOption func(*shedderOptions)
+
+// WithName 设置降载器名称，用于指标标签
+func WithName(name string) ShedderOption {
+	return func(o *shedderOptions) {
+		o.name = name
+	}
+}
+
+// WithWindow 设置统计窗口时长，默认 5s
+func WithWindow(window time.Duration) ShedderOption {
+	return func(o *shedderOptions) {
+		o.window = window
+	}
+}
+
+// WithBuckets 设置统计窗口的桶数，默认 50
+func WithBuckets(buckets int) ShedderOption {
+	return func(o *shedderOptions) {
+		o.buckets = buckets
+	}
+}
+
+// WithCpuThreshold 设置触发降载的 cpu 使用率，单位为千分之一，默认 900，
+// 设置为 0 时不检查 cpu，只根据并发数和容量判断
+func WithCpuThreshold(threshold int64) ShedderOption {
+	return func(o *shedderOptions) {
+		o.cpuThreshold = threshold
+	}
+}
+
+type adaptiveShedder struct {
+	name         string
+	cpuThreshold int64
+	// 每秒的桶数
+	windowScale float64
+
+	flying    atomic.Int64
+	avgFlying float64
+	avgLock   sync.RWMutex
+
+	overloadTime    atomic.Int64
+	droppedRecently atomic.Bool
+
+	passCounter *window.RollingWindow
+	rtCounter   *window.RollingWindow
+}
+
+// NewAdaptiveShedder 创建自适应降载器
+func NewAdaptiveShedder(opts ...ShedderOption) Shedder {
+	o := shedderOptions{
+		name:         defaultName,
+		window:       defaultWindow,
+		buckets:      defaultBuckets,
+		cpuThreshold: defaultCpuThreshold,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bucketDuration := o.window / time.Duration(o.buckets)
+	return &adaptiveShedder{
+		name:         o.name,
+		cpuThreshold: o.cpuThreshold,
+		windowScale:  float64(time.Second) / float64(bucketDuration),
+		passCounter:  window.NewRollingWindow(o.buckets, bucketDuration),
+		rtCounter:    window.NewRollingWindow(o.buckets, bucketDuration),
+	}
+}
+
+func (s *adaptiveShedder) Allow() (Promise, error) {
+	if s.shouldDrop() {
+		s.droppedRecently.Store(true)
+		metricShedderDropTotal.Inc(s.name)
+		return nil, ErrServiceOverloaded
+	}
+
+	s.addFlying(1)
+	return &promise{start: time.Now(), shedder: s}, nil
+}
+
+func (s *adaptiveShedder) addFlying(delta int64) {
+	flying := s.flying.Add(delta)
+	// 请求结束时更新平均并发数，避免突发请求直接触发降载
+	if delta < 0 {
+		s.avgLock.Lock()
+		s.avgFlying = s.avgFlying*flyingBeta + float64(flying)*(1-flyingBeta)
+		s.avgLock.Unlock()
+	}
+}
+
+func (s *adaptiveShedder) shouldDrop() bool {
+	if s.systemOverloaded() || s.stillHot() {
+		return s.highThru()
+	}
+	return false
+}
+
+func (s *adaptiveShedder) systemOverloaded() bool {
+	if s.cpuThreshold > 0 && CpuUsage() < s.cpuThreshold {
+		return false
+	}
+	s.overloadTime.Store(time.Now().UnixNano())
+	return true
+}
+
+// stillHot 最近丢弃过请求并且仍在冷却期内
+func (s *adaptiveShedder) stillHot() bool {
+	if !s.droppedRecently.Load() {
+		return false
+	}
+	overloadTime := s.overloadTime.Load()
+	if overloadTime == 0 {
+		return false
+	}
+	hot := time.Since(time.Unix(0, overloadTime)) < coolOffDuration
+	if !hot {
+		s.droppedRecently.Store(false)
+	}
+	return hot
+}
+
+// highThru 当前并发数和平均并发数都超过估算的最大并发数
+func (s *adaptiveShedder) highThru() bool {
+	s.avgLock.RLock()
+	avgFlying := s.avgFlying
+	s.avgLock.RUnlock()
+
+	maxFlight := s.maxFlight()
+	return int64(avgFlying) > maxFlight && s.flying.Load() > maxFlight
+}
+
+// maxFlight 根据利特尔法则估算最大并发数：每秒最大通过数 * 最小延迟
+func (s *adaptiveShedder) maxFlight() int64 {
+	return int64(math.Max(1, float64(s.maxPass())*s.windowScale*s.minRt()/1e3))
+}
+
+// maxPass 窗口内单个桶的最大通过数
+func (s *adaptiveShedder) maxPass() int64 {
+	var result float64 = 1
+	s.passCounter.Reduce(func(b *window.Bucket) {
+		if b.Sum > result {
+			result = b.Sum
+		}
+	})
+	return int64(result)
+}
+
+// minRt 窗口内单个桶的最小平均延迟，单位 ms
+func (s *adaptiveShedder) minRt() float64 {
+	result := defaultMinRt
+	s.rtCounter.Reduce(func(b *window.Bucket) {
+		if b.Count <= 0 {
+			return
+		}
+		avg := math.Round(b.Sum / float64(b.Count))
+		if avg < result {
+			result = avg
+		}
+	})
+	return result
+}
+
+type promise struct {
+	start   time.Time
+	shedder *adaptiveShedder
+}
+
+func (p *promise) Pass() {
+	rt := float64(time.Since(p.start)) / float64(time.Millisecond)
+	p.shedder.addFlying(-1)
+	p.shedder.rtCounter.Add(math.Ceil(rt))
+	p.shedder.passCounter.Add(1)
+}
+
+func (p *promise) Fail() {
+	p.shedder.addFlying(-1)
+}
@@ -0,0 +1,80 @@
+package load
+
+import (
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveShedderIdle(t *testing.T) {
+	s := NewAdaptiveShedder()
+
+	for i := 0; i < 100; i++ {
+		p, err := s.Allow()
+		require.NoError(t, err)
+		p.Pass()
+	}
+}
+
+func TestAdaptiveShedderDrop(t *testing.T) {
+	s := NewAdaptiveShedder(WithName("test"), WithCpuThreshold(0)).(*adaptiveShedder)
+
+	// 每个桶 100ms，通过 10 个平均延迟 10ms 的请求，估算的最大并发数为 10*10*10/1000 = 1
+	for i := 0; i < 10; i++ {
+		s.passCounter.Add(1)
+		s.rtCounter.Add(10)
+	}
+	assert.Equal(t, int64(1), s.maxFlight())
+
+	// 并发数没有超过容量时放行
+	p, err := s.Allow()
+	require.NoError(t, err)
+
+	s.flying.Store(10)
+	s.avgFlying = 10
+	_, err = s.Allow()
+	assert.ErrorIs(t, err, ErrServiceOverloaded)
+	assert.True(t, errors.IsCode(err, code.ErrServiceOverloaded))
+	assert.True(t, s.droppedRecently.Load())
+
+	s.flying.Store(1)
+	p.Fail()
+	assert.Equal(t, int64(0), s.flying.Load())
+}
+
+func TestAdaptiveShedderStillHot(t *testing.T) {
+	s := NewAdaptiveShedder().(*adaptiveShedder)
+
+	assert.False(t, s.stillHot())
+
+	s.droppedRecently.Store(true)
+	s.overloadTime.Store(time.Now().UnixNano())
+	assert.True(t, s.stillHot())
+
+	s.overloadTime.Store(time.Now().Add(-2 * coolOffDuration).UnixNano())
+	assert.False(t, s.stillHot())
+	assert.False(t, s.droppedRecently.Load())
+}
+
+func TestPromisePass(t *testing.T) {
+	s := NewAdaptiveShedder().(*adaptiveShedder)
+
+	p, err := s.Allow()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), s.flying.Load())
+
+	time.Sleep(5 * time.Millisecond)
+	p.Pass()
+	assert.Equal(t, int64(0), s.flying.Load())
+	assert.Equal(t, int64(1), s.maxPass())
+	assert.GreaterOrEqual(t, s.minRt(), float64(5))
+}
+
+func TestCpuUsage(t *testing.T) {
+	assert.GreaterOrEqual(t, CpuUsage(), int64(0))
+}
@@ -75,7 +75,7 @@ func defaultResolver(_ context.Context, entries []*api.ServiceEntry) []*registry
 		services = append(services, &registry.ServiceInstance{
 			ID:        entry.Service.ID,
 			Name:      entry.Service.Service,
-			Metadata:  entry.Service.Meta,
+			Metadata:  weightMetadata(entry.Service),
 			Version:   version,
 			Endpoints: endpoints,
 		})
@@ -84,6 +84,20 @@ func defaultResolver(_ context.Context, entries []*api.ServiceEntry) []*registry
 	return services
 }
 
+// weightMetadata fills registry.MetadataWeight from the consul passing weight when the metadata has none,
+// the consul default weight 1 is treated as unset.
+func weightMetadata(svc *api.AgentService) map[string]string {
+	if _, ok := svc.Meta[registry.MetadataWeight]; ok || svc.Weights.Passing <= 1 {
+		return svc.Meta
+	}
+	md := make(map[string]string, len(svc.Meta)+1)
+	for k, v := range svc.Meta {
+		md[k] = v
+	}
+	md[registry.MetadataWeight] = strconv.Itoa(svc.Weights.Passing)
+	return md
+}
+
 // ServiceResolver is used to resolve service endpoints
 type ServiceResolver func(ctx context.Context, entries []*api.ServiceEntry) []*registry.ServiceInstance
 
@@ -177,6 +191,9 @@ func (c *Client) Register(ctx context.Context, svc *registry.ServiceInstance, en
 		Tags:            tags,
 		TaggedAddresses: addresses,
 	}
+	if w, err := strconv.Atoi(svc.Metadata[registry.MetadataWeight]); err == nil && w > 0 {
+		asr.Weights = &api.AgentWeights{Passing: w, Warning: 1}
+	}
 	if len(checkAddresses) > 0 {
 		host, portRaw, _ := net.SplitHostPort(checkAddresses[0])
 		port, _ := strconv.ParseInt(portRaw, 10, 32)
@@ -1040,3 +1040,34 @@ func getIntranetIP() string {
 	}
 	return "127.0.0.1"
 }
+
+func TestWeightMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		svc  *api.AgentService
+		want map[string]string
+	}{
+		{
+			name: "default weight",
+			svc:  &api.AgentService{Meta: map[string]string{"k": "v"}, Weights: api.AgentWeights{Passing: 1}},
+			want: map[string]string{"k": "v"},
+		},
+		{
+			name: "consul weight",
+			svc:  &api.AgentService{Meta: map[string]string{"k": "v"}, Weights: api.AgentWeights{Passing: 50}},
+			want: map[string]string{"k": "v", registry.MetadataWeight: "50"},
+		},
+		{
+			name: "metadata weight",
+			svc:  &api.AgentService{Meta: map[string]string{registry.MetadataWeight: "200"}, Weights: api.AgentWeights{Passing: 50}},
+			want: map[string]string{registry.MetadataWeight: "200"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weightMetadata(tt.svc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("weightMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/model"
 	"github.com/nacos-group/nacos-sdk-go/vo"
 
 	"github.com/taluos/Malt/core/registry"
@@ -115,7 +116,7 @@ func (r *Registry) Register(_ context.Context, si *registry.ServiceInstance) err
 			}
 			rmd["kind"] = u.Scheme
 			rmd["version"] = si.Version
-			if w, ok := si.Metadata["weight"]; ok {
+			if w, ok := si.Metadata[registry.MetadataWeight]; ok {
 				weight, err = strconv.ParseFloat(w, 64)
 				if err != nil {
 					weight = r.opts.weight
@@ -172,7 +173,7 @@ func (r *Registry) Deregister(_ context.Context, service *registry.ServiceInstan
 
 // Watch creates a watcher according to the service name.
 func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
-	return newWatcher(ctx, r.cli, serviceName, r.opts.group, r.opts.kind, []string{r.opts.cluster})
+	return newWatcher(ctx, r.cli, serviceName, r.opts.group, r.opts.kind, r.opts.weight, []string{r.opts.cluster})
 }
 
 // GetService return the service instances in memory according to the service name.
@@ -188,23 +189,31 @@ func (r *Registry) GetService(_ context.Context, serviceName string) ([]*registr
 	items := make([]*registry.ServiceInstance, 0, len(res))
 	for _, in := range res {
 		kind := r.opts.kind
-		weight := r.opts.weight
 		if k, ok := in.Metadata["kind"]; ok {
 			kind = k
 		}
-		if in.Weight > 0 {
-			weight = in.Weight
-		}
 
-		r := &registry.ServiceInstance{
+		items = append(items, &registry.ServiceInstance{
 			ID:        in.InstanceId,
 			Name:      in.ServiceName,
 			Version:   in.Metadata["version"],
-			Metadata:  in.Metadata,
+			Metadata:  weightMetadata(in, r.opts.weight),
 			Endpoints: []string{fmt.Sprintf("%s://%s:%d", kind, in.Ip, in.Port)},
-		}
-		r.Metadata["weight"] = strconv.Itoa(int(math.Ceil(weight)))
-		items = append(items, r)
+		})
 	}
 	return items, nil
 }
+
+// weightMetadata 复制实例的 metadata，并把 nacos 的实例权重写入 registry.MetadataWeight，实例未设置权重时使用 defaultWeight
+func weightMetadata(in model.Instance, defaultWeight float64) map[string]string {
+	weight := defaultWeight
+	if in.Weight > 0 {
+		weight = in.Weight
+	}
+	md := make(map[string]string, len(in.Metadata)+1)
+	for k, v := range in.Metadata {
+		md[k] = v
+	}
+	md[registry.MetadataWeight] = strconv.Itoa(int(math.Ceil(weight)))
+	return md
+}
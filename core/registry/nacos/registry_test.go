@@ -8,6 +8,7 @@ import (
 
 	"github.com/nacos-group/nacos-sdk-go/clients"
 	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/model"
 	"github.com/nacos-group/nacos-sdk-go/vo"
 
 	"github.com/taluos/Malt/core/registry"
@@ -564,3 +565,29 @@ func TestRegistry_Watch(t *testing.T) {
 		})
 	}
 }
+
+func TestWeightMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		in   model.Instance
+		want map[string]string
+	}{
+		{
+			name: "nil metadata",
+			in:   model.Instance{},
+			want: map[string]string{registry.MetadataWeight: "100"},
+		},
+		{
+			name: "nacos weight",
+			in:   model.Instance{Weight: 1.5, Metadata: map[string]string{"kind": "grpc"}},
+			want: map[string]string{"kind": "grpc", registry.MetadataWeight: "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weightMetadata(tt.in, 100); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("weightMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,16 +22,18 @@ type watcher struct {
 	watchChan      chan struct{}
 	cli            naming_client.INamingClient
 	kind           string
+	weight         float64
 	subscribeParam *vo.SubscribeParam
 }
 
-func newWatcher(ctx context.Context, cli naming_client.INamingClient, serviceName, groupName, kind string, clusters []string) (*watcher, error) {
+func newWatcher(ctx context.Context, cli naming_client.INamingClient, serviceName, groupName, kind string, weight float64, clusters []string) (*watcher, error) {
 	w := &watcher{
 		serviceName: serviceName,
 		clusters:    clusters,
 		groupName:   groupName,
 		cli:         cli,
 		kind:        kind,
+		weight:      weight,
 		watchChan:   make(chan struct{}, 1),
 	}
 	w.ctx, w.cancel = context.WithCancel(ctx)
@@ -79,7 +81,7 @@ func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
 			ID:        in.InstanceId,
 			Name:      res.Name,
 			Version:   in.Metadata["version"],
-			Metadata:  in.Metadata,
+			Metadata:  weightMetadata(in, w.weight),
 			Endpoints: []string{fmt.Sprintf("%s://%s:%d", kind, in.Ip, in.Port)},
 		})
 	}
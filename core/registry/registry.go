@@ -2,6 +2,10 @@ package registry
 
 import "context"
 
+// MetadataWeight 是实例权重在 Metadata 中的键，取值为正整数，默认 100。
+// 注册中心在注册时读取，在发现时填充，selector 把它作为节点的初始权重
+const MetadataWeight = "weight"
+
 type ServiceInstance struct {
 	// ID is the unique identifier for the service instance.
 	ID string `json:"ID" mapstructure:"ID"`
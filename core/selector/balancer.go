@@ -10,6 +10,13 @@ type Balancer interface {
 	Pick(ctx context.Context, nodes []WeightedNode) (selected WeightedNode, done DoneFunc, err error)
 }
 
+// Rebuilder is implemented by balancers that precompute state from the node set, such as hash rings.
+// Default calls Rebuild with all the nodes on every Apply, before filters and outlier ejection,
+// so Pick only looks up the candidates and the state is rebuilt only when the members change.
+type Rebuilder interface {
+	Rebuild(nodes []WeightedNode)
+}
+
 // BalancerBuilder build balancer
 type BalancerBuilder interface {
 	Build() Balancer
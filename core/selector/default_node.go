@@ -1,6 +1,7 @@
 package selector
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/taluos/Malt/core/registry"
@@ -63,11 +64,27 @@ func NewNode(scheme, addr string, ins *registry.ServiceInstance) Node {
 		n.name = ins.Name
 		n.version = ins.Version
 		n.metadata = ins.Metadata
-		if str, ok := ins.Metadata["weight"]; ok {
-			if weight, err := strconv.ParseInt(str, 10, 64); err == nil {
-				n.weight = &weight
-			}
+		if weight, ok := parseWeight(ins.Metadata[registry.MetadataWeight]); ok {
+			n.weight = &weight
 		}
 	}
 	return n
 }
+
+// parseWeight parses the weight metadata, fractional weights such as nacos' are rounded up.
+func parseWeight(str string) (int64, bool) {
+	if str == "" {
+		return 0, false
+	}
+	if weight, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if weight < 0 {
+			return 0, false
+		}
+		return weight, true
+	}
+	weight, err := strconv.ParseFloat(str, 64)
+	if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return 0, false
+	}
+	return int64(math.Ceil(weight)), true
+}
@@ -2,15 +2,28 @@ package selector
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Default is composite selector.
 type Default struct {
 	NodeBuilder WeightedNodeBuilder
 	Balancer    Balancer
+	// Filters run before balancing, ahead of the filters in the call context.
+	Filters []NodeFilter
+	// Warmup is the slow-start window of nodes added to a non-empty selector, zero disables it.
+	Warmup time.Duration
+	// Outlier enables the passive health checking of nodes, nil disables it.
+	Outlier *OutlierDetection
 
-	nodes atomic.Value
+	nodes   atomic.Value
+	outlier *outlierDetector
+
+	mu       sync.Mutex
+	active   map[string]*managedNode
+	draining map[string]*managedNode // retired nodes with in-flight requests
 }
 
 // Select is select one node.
@@ -22,7 +35,10 @@ func (d *Default) Select(ctx context.Context) (selected Node, done DoneFunc, err
 		return nil, nil, ErrNoAvailable
 	}
 
-	candidates = nodes
+	if d.outlier != nil {
+		nodes = d.outlier.available(nodes)
+	}
+	candidates = applyFilters(ctx, nodes, d.Filters)
 	if len(candidates) == 0 {
 		return nil, nil, ErrNoAvailable
 	}
@@ -41,19 +57,115 @@ func (d *Default) Select(ctx context.Context) (selected Node, done DoneFunc, err
 }
 
 // Apply update nodes info.
+// Unchanged nodes keep their weighted node and its statistics, added and changed nodes are built,
+// retired and replaced nodes are no longer picked and are kept until their in-flight requests finish,
+// so a node that comes back meanwhile gets its statistics back.
 func (d *Default) Apply(nodes []Node) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.outlier == nil && d.Outlier != nil {
+		d.outlier = newOutlierDetector(*d.Outlier, func() []WeightedNode {
+			nodes, _ := d.nodes.Load().([]WeightedNode)
+			return nodes
+		})
+	}
+	if d.draining == nil {
+		d.draining = make(map[string]*managedNode)
+	}
+
+	now := time.Now()
+	active := make(map[string]*managedNode, len(nodes))
 	weightedNodes := make([]WeightedNode, 0, len(nodes))
 	for _, n := range nodes {
-		weightedNodes = append(weightedNodes, d.NodeBuilder.Build(n))
+		key := nodeKey(n)
+		if _, ok := active[key]; ok {
+			continue
+		}
+		mn, ok := d.active[key]
+		if !ok {
+			if mn, ok = d.draining[key]; ok {
+				delete(d.draining, key)
+				mn.retired.Store(false)
+			}
+		}
+		if ok && !sameNode(mn.Raw(), n) {
+			d.retire(key, mn)
+			ok = false
+		}
+		if !ok {
+			mn = &managedNode{WeightedNode: d.NodeBuilder.Build(n), addedAt: now, outlier: d.outlier, release: d.release}
+			// the first nodes share the traffic evenly, only later nodes warm up
+			if len(d.active) > 0 {
+				mn.warmup = d.Warmup
+			}
+		}
+		active[key] = mn
+		weightedNodes = append(weightedNodes, mn)
+	}
+
+	for key, mn := range d.active {
+		if _, ok := active[key]; !ok {
+			d.retire(key, mn)
+		}
+	}
+	d.active = active
+	if r, ok := d.Balancer.(Rebuilder); ok {
+		r.Rebuild(weightedNodes)
 	}
-	// TODO: Do not delete unchanged nodes
 	d.nodes.Store(weightedNodes)
 }
 
+// retire keeps mn in draining until its in-flight requests finish, d.mu must be held.
+func (d *Default) retire(key string, mn *managedNode) {
+	mn.retired.Store(true)
+	d.draining[key] = mn
+	if mn.inflight.Load() <= 0 {
+		delete(d.draining, key)
+	}
+}
+
+// release drops a retired node when its last in-flight request finishes.
+func (d *Default) release(mn *managedNode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key := nodeKey(mn); d.draining[key] == mn {
+		delete(d.draining, key)
+	}
+}
+
 // DefaultBuilder is de
 type DefaultBuilder struct {
 	Node     WeightedNodeBuilder
 	Balancer BalancerBuilder
+	Filters  []NodeFilter
+	Warmup   time.Duration
+	Outlier  *OutlierDetection
+}
+
+// BuilderOption configures a DefaultBuilder, the pickers accept it in NewBuilder.
+type BuilderOption func(db *DefaultBuilder)
+
+// WithOutlierDetection enables the passive health checking of nodes,
+// so a node that keeps failing stops receiving traffic until its ejection ends.
+func WithOutlierDetection(cfg *OutlierDetection) BuilderOption {
+	return func(db *DefaultBuilder) {
+		db.Outlier = cfg
+	}
+}
+
+// Configure returns a copy of b with opts applied, b is not modified.
+// Builders other than DefaultBuilder are returned unchanged.
+func Configure(b Builder, opts ...BuilderOption) Builder {
+	db, ok := b.(*DefaultBuilder)
+	if !ok || len(opts) == 0 {
+		return b
+	}
+	c := *db
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
 }
 
 // Build create builder
@@ -61,5 +173,8 @@ func (db *DefaultBuilder) Build() Selector {
 	return &Default{
 		NodeBuilder: db.Node,
 		Balancer:    db.Balancer.Build(),
+		Filters:     db.Filters,
+		Warmup:      db.Warmup,
+		Outlier:     db.Outlier,
 	}
 }
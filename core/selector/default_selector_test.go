@@ -0,0 +1,202 @@
+package selector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/core/registry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNode struct {
+	Node
+	picks int
+}
+
+func (n *testNode) Raw() Node                  { return n.Node }
+func (n *testNode) Weight() float64            { return 100 }
+func (n *testNode) PickElapsed() time.Duration { return 0 }
+func (n *testNode) Pick() DoneFunc {
+	n.picks++
+	return func(context.Context, DoneInfo) {}
+}
+
+// testBuilder counts the built nodes.
+type testBuilder struct{ built int }
+
+func (b *testBuilder) Build(n Node) WeightedNode {
+	b.built++
+	return &testNode{Node: n}
+}
+
+// firstBalancer picks the first node.
+type firstBalancer struct{}
+
+func (firstBalancer) Pick(_ context.Context, nodes []WeightedNode) (WeightedNode, DoneFunc, error) {
+	return nodes[0], nodes[0].Pick(), nil
+}
+
+func newTestNode(addr, version string) Node {
+	return NewNode("http", addr, &registry.ServiceInstance{ID: addr, Version: version})
+}
+
+func TestApplyIncremental(t *testing.T) {
+	builder := &testBuilder{}
+	d := &Default{NodeBuilder: builder, Balancer: firstBalancer{}}
+
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1")})
+	assert.Equal(t, 2, builder.built)
+	first := d.nodes.Load().([]WeightedNode)[0]
+
+	// unchanged nodes are kept, added nodes are built
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1"), newTestNode("127.0.0.1:3", "v1")})
+	assert.Equal(t, 3, builder.built)
+	assert.Same(t, first, d.nodes.Load().([]WeightedNode)[0])
+
+	// changed nodes are rebuilt
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v2"), newTestNode("127.0.0.1:2", "v1"), newTestNode("127.0.0.1:3", "v1")})
+	assert.Equal(t, 4, builder.built)
+	assert.NotSame(t, first, d.nodes.Load().([]WeightedNode)[0])
+
+	// duplicated nodes are applied once
+	d.Apply([]Node{newTestNode("127.0.0.1:2", "v1"), newTestNode("127.0.0.1:2", "v1")})
+	assert.Len(t, d.nodes.Load().([]WeightedNode), 1)
+	assert.Equal(t, 4, builder.built)
+}
+
+func TestApplyDraining(t *testing.T) {
+	builder := &testBuilder{}
+	d := &Default{NodeBuilder: builder, Balancer: firstBalancer{}}
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1")})
+
+	n, done, err := d.Select(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:1", n.Address())
+
+	// the retired node is no longer picked but keeps its state while the request is in flight
+	d.Apply([]Node{newTestNode("127.0.0.1:2", "v1")})
+	assert.Len(t, d.draining, 1)
+	n, _, err = d.Select(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:2", n.Address())
+
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1")})
+	assert.Equal(t, 2, builder.built)
+	assert.Empty(t, d.draining)
+	assert.Equal(t, 1, d.active["http://127.0.0.1:1"].WeightedNode.(*testNode).picks)
+
+	// drained nodes are dropped as soon as their last request finishes
+	d.Apply([]Node{newTestNode("127.0.0.1:2", "v1")})
+	assert.Len(t, d.draining, 1)
+	done(context.Background(), DoneInfo{})
+	assert.Empty(t, d.draining)
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1")})
+	assert.Equal(t, 3, builder.built)
+}
+
+func TestApplyDrainingReplaced(t *testing.T) {
+	d := &Default{NodeBuilder: &testBuilder{}, Balancer: firstBalancer{}}
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1")})
+
+	_, done, err := d.Select(context.Background())
+	require.NoError(t, err)
+	replaced := d.active["http://127.0.0.1:1"]
+
+	// the replaced node drains while the rebuilt node takes the traffic
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v2")})
+	assert.NotSame(t, replaced, d.active["http://127.0.0.1:1"])
+	assert.Same(t, replaced, d.draining["http://127.0.0.1:1"])
+
+	done(context.Background(), DoneInfo{})
+	assert.Empty(t, d.draining)
+	assert.Zero(t, replaced.inflight.Load())
+}
+
+func TestApplyWarmup(t *testing.T) {
+	d := &Default{NodeBuilder: &testBuilder{}, Balancer: firstBalancer{}, Warmup: time.Hour}
+
+	// the first nodes do not warm up
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1")})
+	assert.Equal(t, float64(100), d.active["http://127.0.0.1:1"].Weight())
+
+	d.Apply([]Node{newTestNode("127.0.0.1:1", "v1"), newTestNode("127.0.0.1:2", "v1")})
+	added := d.active["http://127.0.0.1:2"]
+	assert.InDelta(t, 100*minWarmupFactor, added.Weight(), 1)
+
+	added.addedAt = time.Now().Add(-time.Hour / 2)
+	assert.InDelta(t, 50, added.Weight(), 1)
+	added.addedAt = time.Now().Add(-time.Hour)
+	assert.Equal(t, float64(100), added.Weight())
+}
+
+type connNode struct {
+	Node
+	conn int
+}
+
+func (c *connNode) Equal(n Node) bool {
+	o, ok := n.(*connNode)
+	return ok && o.conn == c.conn
+}
+
+func TestApplyEqual(t *testing.T) {
+	builder := &testBuilder{}
+	d := &Default{NodeBuilder: builder, Balancer: firstBalancer{}}
+
+	d.Apply([]Node{&connNode{Node: newTestNode("127.0.0.1:1", "v1"), conn: 1}})
+	d.Apply([]Node{&connNode{Node: newTestNode("127.0.0.1:1", "v1"), conn: 1}})
+	assert.Equal(t, 1, builder.built)
+
+	// a new connection to the same address rebuilds the node
+	d.Apply([]Node{&connNode{Node: newTestNode("127.0.0.1:1", "v1"), conn: 2}})
+	assert.Equal(t, 2, builder.built)
+}
+
+func TestParseWeight(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"", 0, false},
+		{"200", 200, true},
+		{"1.5", 2, true},
+		{"-1", 0, false},
+		{"heavy", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseWeight(tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+	}
+
+	n := NewNode("http", "127.0.0.1:1", &registry.ServiceInstance{Metadata: map[string]string{registry.MetadataWeight: "300"}})
+	require.NotNil(t, n.InitialWeight())
+	assert.Equal(t, int64(300), *n.InitialWeight())
+}
+
+func TestRegister(t *testing.T) {
+	builder := &DefaultBuilder{}
+	Register("test-register", builder)
+	got, ok := GetBuilder("test-register")
+	assert.True(t, ok)
+	assert.Same(t, builder, got)
+	assert.Contains(t, Names(), "test-register")
+
+	_, ok = GetBuilder("missing")
+	assert.False(t, ok)
+}
+
+func TestConfigure(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	base := &DefaultBuilder{Node: &testBuilder{}}
+	got := Configure(base, WithOutlierDetection(cfg))
+
+	require.IsType(t, &DefaultBuilder{}, got)
+	assert.Same(t, cfg, got.(*DefaultBuilder).Outlier)
+	assert.Nil(t, base.Outlier, "the original builder must not be modified")
+	assert.Same(t, base, Configure(base))
+}
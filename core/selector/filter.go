@@ -0,0 +1,55 @@
+package selector
+
+import "context"
+
+// NodeFilter is run by Select before balancing.
+// It must return a subset of nodes, an empty result means no node is available.
+type NodeFilter func(ctx context.Context, nodes []Node) []Node
+
+type filterKey struct{}
+
+// NewFilterContext returns a context carrying filters for the calls made with it.
+// Filters already in ctx are kept and run first.
+func NewFilterContext(ctx context.Context, filters ...NodeFilter) context.Context {
+	if len(filters) == 0 {
+		return ctx
+	}
+	parent := FromFilterContext(ctx)
+	merged := make([]NodeFilter, 0, len(parent)+len(filters))
+	merged = append(merged, parent...)
+	merged = append(merged, filters...)
+	return context.WithValue(ctx, filterKey{}, merged)
+}
+
+// FromFilterContext returns the filters in ctx.
+func FromFilterContext(ctx context.Context) []NodeFilter {
+	filters, _ := ctx.Value(filterKey{}).([]NodeFilter)
+	return filters
+}
+
+// applyFilters runs the selector filters and then the context filters over nodes.
+func applyFilters(ctx context.Context, nodes []WeightedNode, filters []NodeFilter) []WeightedNode {
+	ctxFilters := FromFilterContext(ctx)
+	if len(filters) == 0 && len(ctxFilters) == 0 {
+		return nodes
+	}
+
+	filtered := make([]Node, len(nodes))
+	for i, n := range nodes {
+		filtered[i] = n
+	}
+	for _, f := range filters {
+		filtered = f(ctx, filtered)
+	}
+	for _, f := range ctxFilters {
+		filtered = f(ctx, filtered)
+	}
+
+	candidates := make([]WeightedNode, 0, len(filtered))
+	for _, n := range filtered {
+		if wn, ok := n.(WeightedNode); ok {
+			candidates = append(candidates, wn)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNode(addr, version string, md map[string]string) selector.Node {
+	return selector.NewNode("grpc", addr, &registry.ServiceInstance{ID: addr, Version: version, Metadata: md})
+}
+
+func addrs(nodes []selector.Node) []string {
+	result := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, n.Address())
+	}
+	return result
+}
+
+var nodes = []selector.Node{
+	newNode("a", "v1.2.0", map[string]string{MetadataZone: "az1", MetadataEnv: "prod"}),
+	newNode("b", "v1.3.1", map[string]string{MetadataZone: "az2", MetadataEnv: "prod"}),
+	newNode("c", "v2.0.0-rc.1", map[string]string{MetadataZone: "az1", MetadataEnv: "canary"}),
+	newNode("d", "v2.0.0", nil),
+	newNode("e", "latest", nil),
+}
+
+func TestVersion(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{"a", "d"}, addrs(Version("v1.2.0", "v2.0.0")(ctx, nodes)))
+	assert.Empty(t, Version("v3")(ctx, nodes))
+}
+
+func TestVersionConstraint(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		constraint string
+		want       []string
+	}{
+		{">=1.2.0, <2.0.0", []string{"a", "b"}},
+		{"^1.2", []string{"a", "b"}},
+		{"~1.2.0", []string{"a"}},
+		// pre-releases sort before the release
+		{">=2.0.0-rc.0, <2.0.0", []string{"c"}},
+		// pre-releases are excluded unless the constraint names one of the same version
+		{">1.3.1", []string{"d"}},
+		{"!=1.2.0, <2.0.0-0", []string{"b"}},
+		{"2.0.0", []string{"d"}},
+	}
+	for _, c := range cases {
+		f, err := VersionConstraint(c.constraint)
+		require.NoError(t, err, c.constraint)
+		assert.Equal(t, c.want, addrs(f(ctx, nodes)), c.constraint)
+	}
+
+	for _, bad := range []string{"", ">=x.y", "1.2.3.4", "1.2.3-"} {
+		_, err := VersionConstraint(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "v1.0.1+build.5", "1.1"}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := parseSemver(ordered[i])
+		require.NoError(t, err)
+		b, err := parseSemver(ordered[i+1])
+		require.NoError(t, err)
+		assert.Equal(t, -1, a.compare(b), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, b.compare(a), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	// ^0.x only allows patch updates of the same minor version
+	f, err := VersionConstraint("^0.2.3")
+	require.NoError(t, err)
+	zeroNodes := []selector.Node{newNode("x", "0.2.9", nil), newNode("y", "0.3.0", nil)}
+	assert.Equal(t, []string{"x"}, addrs(f(context.Background(), zeroNodes)))
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{"a", "c"}, addrs(Metadata(MetadataZone, "az1")(ctx, nodes)))
+	assert.Equal(t, []string{"a", "b", "c"}, addrs(Metadata(MetadataZone, "az1", "az2")(ctx, nodes)))
+	assert.Equal(t, []string{"a"}, addrs(MetadataMatch(map[string]string{MetadataZone: "az1", MetadataEnv: "prod"})(ctx, nodes)))
+}
+
+func TestPreferZone(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, []string{"b"}, addrs(PreferZone("az2")(ctx, nodes)))
+	// no node in az3, fall back to all nodes
+	assert.Len(t, PreferZone("az3")(ctx, nodes), len(nodes))
+}
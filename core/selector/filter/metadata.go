@@ -0,0 +1,63 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/selector"
+)
+
+// Well-known metadata keys of service instances.
+const (
+	MetadataZone    = "zone"
+	MetadataCluster = "cluster"
+	MetadataEnv     = "env"
+)
+
+// Metadata keeps the nodes whose metadata value of key equals one of values.
+func Metadata(key string, values ...string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		return keep(nodes, func(n selector.Node) bool {
+			v, ok := n.Metadata()[key]
+			if !ok {
+				return false
+			}
+			for _, value := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// MetadataMatch keeps the nodes whose metadata contains all of kvs.
+func MetadataMatch(kvs map[string]string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		return keep(nodes, func(n selector.Node) bool {
+			md := n.Metadata()
+			for k, v := range kvs {
+				if md[k] != v {
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+// Prefer runs filter and falls back to all nodes when it keeps none of them.
+func Prefer(filter selector.NodeFilter) selector.NodeFilter {
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if preferred := filter(ctx, nodes); len(preferred) > 0 {
+			return preferred
+		}
+		return nodes
+	}
+}
+
+// PreferZone routes to the nodes in zone first and falls back to all nodes
+// when none of them is in zone.
+func PreferZone(zone string) selector.NodeFilter {
+	return Prefer(Metadata(MetadataZone, zone))
+}
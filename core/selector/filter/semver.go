@@ -0,0 +1,196 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/taluos/Malt/pkg/errors"
+)
+
+// semver is a parsed semantic version, build metadata is dropped.
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+// parseSemver parses versions like "v1.2.3", "1.2.3-rc.1+build" and "1.2".
+// Missing minor and patch numbers default to zero.
+func parseSemver(v string) (semver, error) {
+	var sv semver
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if i == len(s)-1 {
+			return sv, errors.Errorf("invalid version %q", v)
+		}
+		sv.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return sv, errors.Errorf("invalid version %q", v)
+	}
+	nums := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return sv, errors.Errorf("invalid version %q", v)
+		}
+		nums[i] = n
+	}
+	sv.major, sv.minor, sv.patch = nums[0], nums[1], nums[2]
+	return sv, nil
+}
+
+// compare returns -1, 0 or 1 following the semver precedence rules.
+func (v semver) compare(o semver) int {
+	if c := compareInt(v.major, o.major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.minor, o.minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.patch, o.patch); c != 0 {
+		return c
+	}
+
+	// a release has higher precedence than its pre-releases
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := comparePre(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.pre), len(o.pre))
+}
+
+// comparePre compares pre-release identifiers, numeric ones sort before alphanumeric ones.
+func comparePre(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInt(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparator is one term of a version constraint such as ">=1.2.0".
+type comparator struct {
+	op      string
+	version semver
+}
+
+func (c comparator) match(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// constraint is a list of comparators that must all match.
+type constraint []comparator
+
+// match reports whether v satisfies the constraint. A pre-release version only matches
+// when a comparator has a pre-release on the same major.minor.patch, so ranges such as
+// ^1.2 never select 2.0.0-rc.1.
+func (c constraint) match(v semver) bool {
+	if len(v.pre) > 0 {
+		allowed := false
+		for _, cmp := range c {
+			cv := cmp.version
+			if len(cv.pre) > 0 && cv.major == v.major && cv.minor == v.minor && cv.patch == v.patch {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	for _, cmp := range c {
+		if !cmp.match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseConstraint parses comma separated comparators, all of which must match.
+// ^ and ~ are expanded into a range: ^1.2.3 is >=1.2.3 <2.0.0 (^0.2.3 is >=0.2.3 <0.3.0),
+// ~1.2.3 is >=1.2.3 <1.3.0.
+func parseConstraint(expr string) (constraint, error) {
+	var comparators constraint
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		op := "="
+		for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(term, prefix) {
+				op = prefix
+				term = strings.TrimSpace(term[len(prefix):])
+				break
+			}
+		}
+		v, err := parseSemver(term)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "^":
+			upper := semver{major: v.major + 1}
+			if v.major == 0 {
+				upper = semver{minor: v.minor + 1}
+			}
+			comparators = append(comparators, comparator{">=", v}, comparator{"<", upper})
+		case "~":
+			comparators = append(comparators, comparator{">=", v}, comparator{"<", semver{major: v.major, minor: v.minor + 1}})
+		default:
+			comparators = append(comparators, comparator{op, v})
+		}
+	}
+	if len(comparators) == 0 {
+		return nil, errors.Errorf("empty version constraint %q", expr)
+	}
+	return comparators, nil
+}
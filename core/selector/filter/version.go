@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/selector"
+)
+
+// Version keeps the nodes whose version equals one of versions.
+func Version(versions ...string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		return keep(nodes, func(n selector.Node) bool {
+			for _, v := range versions {
+				if n.Version() == v {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// VersionConstraint keeps the nodes whose semantic version satisfies expr.
+// The constraint is a comma separated list of comparators that must all match,
+// supported operators are =, !=, >, >=, <, <=, ^ and ~, e.g. ">=1.2.0, <2.0.0" or "^1.4".
+// Pre-release versions only match when the constraint names a pre-release of the same version.
+// Nodes without a valid semantic version are dropped.
+func VersionConstraint(expr string) (selector.NodeFilter, error) {
+	c, err := parseConstraint(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		return keep(nodes, func(n selector.Node) bool {
+			v, err := parseSemver(n.Version())
+			return err == nil && c.match(v)
+		})
+	}, nil
+}
+
+// keep returns the nodes matching fn.
+func keep(nodes []selector.Node, fn func(selector.Node) bool) []selector.Node {
+	filtered := make([]selector.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if fn(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
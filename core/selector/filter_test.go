@@ -0,0 +1,71 @@
+package selector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/random"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNode(addr, version string) selector.Node {
+	return selector.NewNode("grpc", addr, &registry.ServiceInstance{ID: addr, Version: version})
+}
+
+// versionFilter keeps the nodes with the given version.
+func versionFilter(version string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		var filtered []selector.Node
+		for _, n := range nodes {
+			if n.Version() == version {
+				filtered = append(filtered, n)
+			}
+		}
+		return filtered
+	}
+}
+
+func TestSelectFilters(t *testing.T) {
+	builder := random.NewBuilder().(*selector.DefaultBuilder)
+	builder.Filters = []selector.NodeFilter{func(_ context.Context, nodes []selector.Node) []selector.Node {
+		// drop the node at 127.0.0.1:3
+		var filtered []selector.Node
+		for _, n := range nodes {
+			if n.Address() != "127.0.0.1:3" {
+				filtered = append(filtered, n)
+			}
+		}
+		return filtered
+	}}
+	s := builder.Build()
+	s.Apply([]selector.Node{
+		newNode("127.0.0.1:1", "v1"),
+		newNode("127.0.0.1:2", "v2"),
+		newNode("127.0.0.1:3", "v2"),
+	})
+
+	ctx := selector.NewFilterContext(context.Background(), versionFilter("v2"))
+	for i := 0; i < 20; i++ {
+		n, done, err := s.Select(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "127.0.0.1:2", n.Address())
+		done(ctx, selector.DoneInfo{})
+	}
+
+	// filters in the context accumulate
+	ctx = selector.NewFilterContext(ctx, versionFilter("v1"))
+	assert.Len(t, selector.FromFilterContext(ctx), 2)
+	_, _, err := s.Select(ctx)
+	assert.ErrorIs(t, err, selector.ErrNoAvailable)
+
+	// without context filters only the selector filters run
+	for i := 0; i < 20; i++ {
+		n, _, err := s.Select(context.Background())
+		require.NoError(t, err)
+		assert.NotEqual(t, "127.0.0.1:3", n.Address())
+	}
+}
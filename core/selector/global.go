@@ -1,7 +1,17 @@
 package selector
 
+import (
+	"sort"
+	"sync"
+)
+
 var globalSelector Builder
 
+var (
+	buildersMu sync.RWMutex
+	builders   = make(map[string]Builder)
+)
+
 // GlobalSelector returns global selector builder.
 func GlobalSelector() Builder {
 	return globalSelector
@@ -11,3 +21,31 @@ func GlobalSelector() Builder {
 func SetGlobalSelector(builder Builder) {
 	globalSelector = builder
 }
+
+// Register registers the selector builder of a balancer name, a later registration replaces the earlier one.
+// The pickers register themselves with their default options when imported.
+func Register(name string, builder Builder) {
+	buildersMu.Lock()
+	defer buildersMu.Unlock()
+	builders[name] = builder
+}
+
+// GetBuilder returns the selector builder registered with name.
+func GetBuilder(name string) (Builder, bool) {
+	buildersMu.RLock()
+	defer buildersMu.RUnlock()
+	builder, ok := builders[name]
+	return builder, ok
+}
+
+// Names returns the registered balancer names in order.
+func Names() []string {
+	buildersMu.RLock()
+	defer buildersMu.RUnlock()
+	names := make([]string, 0, len(builders))
+	for name := range builders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
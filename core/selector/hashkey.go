@@ -0,0 +1,17 @@
+package selector
+
+import "context"
+
+type hashKeyKey struct{}
+
+// NewHashKeyContext returns a context carrying the key hashed by the hash and chash balancers,
+// such as a user ID or session ID. Calls with the same key are routed to the same node.
+func NewHashKeyContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyKey{}, key)
+}
+
+// FromHashKeyContext returns the hash key in ctx if it exists.
+func FromHashKeyContext(ctx context.Context) (key string, ok bool) {
+	key, ok = ctx.Value(hashKeyKey{}).(string)
+	return key, ok && key != ""
+}
@@ -0,0 +1,97 @@
+package selector
+
+import (
+	"context"
+	"maps"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWarmup is the slow-start window used by the weight-aware balancers.
+	DefaultWarmup = 30 * time.Second
+
+	// minWarmupFactor is the fraction of the weight a node gets right after it is added.
+	minWarmupFactor = 0.1
+)
+
+var _ WeightedNode = (*managedNode)(nil)
+
+// managedNode wraps the weighted node built by the WeightedNodeBuilder,
+// it counts the in-flight requests for draining and ramps the weight up during warmup.
+type managedNode struct {
+	WeightedNode
+
+	inflight atomic.Int64
+	addedAt  time.Time
+	warmup   time.Duration
+
+	// retired is set while the node drains, release is called when its last in-flight request finishes
+	retired atomic.Bool
+	release func(*managedNode)
+
+	// outlier detection statistics, outlier is nil when the detection is disabled
+	outlier           *outlierDetector
+	requests          atomic.Int64
+	failures          atomic.Int64
+	latency           atomic.Int64 // total nanoseconds in the current interval
+	consecutiveErrors atomic.Int64
+	ejectedUntil      atomic.Int64 // unix nanoseconds, zero when the node is not ejected
+	ejections         int          // recent ejections, guarded by outlier.mu
+}
+
+// Pick counts the request until its done func is called.
+func (n *managedNode) Pick() DoneFunc {
+	n.inflight.Add(1)
+	start := time.Now()
+	done := n.WeightedNode.Pick()
+	return func(ctx context.Context, di DoneInfo) {
+		if n.inflight.Add(-1) == 0 && n.retired.Load() && n.release != nil {
+			n.release(n)
+		}
+		if n.outlier != nil {
+			n.outlier.record(n, start, di.Err)
+		}
+		done(ctx, di)
+	}
+}
+
+func (n *managedNode) ejected(now int64) bool {
+	until := n.ejectedUntil.Load()
+	return until != 0 && now < until
+}
+
+// Weight scales the weight linearly from minWarmupFactor to 1 during warmup.
+func (n *managedNode) Weight() float64 {
+	weight := n.WeightedNode.Weight()
+	if n.warmup <= 0 {
+		return weight
+	}
+	elapsed := time.Since(n.addedAt)
+	if elapsed >= n.warmup {
+		return weight
+	}
+	return weight * max(minWarmupFactor, float64(elapsed)/float64(n.warmup))
+}
+
+// nodeKey identifies a node across Apply calls.
+func nodeKey(n Node) string {
+	return n.Scheme() + "://" + n.Address()
+}
+
+// sameNode reports whether b carries the same instance info as a,
+// a changed node is rebuilt so the builders see the new weight and metadata.
+// Nodes carrying more than the instance info, such as a connection, implement Equal.
+func sameNode(a, b Node) bool {
+	if e, ok := a.(interface{ Equal(Node) bool }); ok && !e.Equal(b) {
+		return false
+	}
+	if a.ID() != b.ID() || a.ServiceName() != b.ServiceName() || a.Version() != b.Version() {
+		return false
+	}
+	wa, wb := a.InitialWeight(), b.InitialWeight()
+	if (wa == nil) != (wb == nil) || (wa != nil && *wa != *wb) {
+		return false
+	}
+	return maps.Equal(a.Metadata(), b.Metadata())
+}
@@ -0,0 +1,263 @@
+package selector
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metric "github.com/taluos/Malt/core/metrics"
+	"github.com/taluos/Malt/pkg/log"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+)
+
+const (
+	ejectReasonConsecutiveErrors = "consecutive_errors"
+	ejectReasonSuccessRate       = "success_rate"
+	ejectReasonLatency           = "latency"
+)
+
+var (
+	metricOutlierEjections = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "selector",
+		Subsystem: "outlier",
+		Name:      "ejections_total",
+		Help:      "selector outlier ejections count.",
+		Labels:    []string{"service", "reason"},
+	})
+
+	metricOutlierEjected = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "selector",
+		Subsystem: "outlier",
+		Name:      "ejected",
+		Help:      "selector currently ejected nodes.",
+		Labels:    []string{"service"},
+	})
+)
+
+// OutlierDetection configures the passive health checking of nodes.
+// Ejected nodes are skipped by Select until their ejection time ends, with any Balancer.
+type OutlierDetection struct {
+	// Interval is how often the success rate and latency are analysed and ejections end.
+	Interval time.Duration
+	// BaseEjectionTime doubles with each recent ejection of the same node, up to MaxEjectionTime.
+	BaseEjectionTime time.Duration
+	MaxEjectionTime  time.Duration
+	// MaxEjectionPercent caps the ejected nodes, one node can always be ejected.
+	MaxEjectionPercent int
+
+	// ConsecutiveErrors ejects a node after that many server errors in a row, zero disables it.
+	ConsecutiveErrors int
+
+	// SuccessRateStdevFactor ejects the nodes whose success rate in an interval is below
+	// mean - factor*stdev of all nodes, zero disables it.
+	SuccessRateStdevFactor float64
+	SuccessRateMinRequests int
+
+	// LatencyFactor ejects the nodes whose mean latency in an interval is above
+	// factor times the median of all nodes, zero disables it.
+	LatencyFactor      float64
+	LatencyMinRequests int
+
+	// MinHosts is the number of nodes with enough requests the success rate and latency analysis need.
+	MinHosts int
+}
+
+// DefaultOutlierDetection returns the Envoy like defaults.
+func DefaultOutlierDetection() *OutlierDetection {
+	return &OutlierDetection{
+		Interval:               10 * time.Second,
+		BaseEjectionTime:       30 * time.Second,
+		MaxEjectionTime:        300 * time.Second,
+		MaxEjectionPercent:     10,
+		ConsecutiveErrors:      5,
+		SuccessRateStdevFactor: 1.9,
+		SuccessRateMinRequests: 100,
+		LatencyFactor:          3,
+		LatencyMinRequests:     100,
+		MinHosts:               5,
+	}
+}
+
+// outlierDetector tracks the nodes of one Default selector.
+type outlierDetector struct {
+	OutlierDetection
+	nodes func() []WeightedNode
+
+	mu   sync.Mutex // serializes ejections
+	next atomic.Int64
+}
+
+func newOutlierDetector(cfg OutlierDetection, nodes func() []WeightedNode) *outlierDetector {
+	o := &outlierDetector{OutlierDetection: cfg, nodes: nodes}
+	o.next.Store(time.Now().Add(cfg.Interval).UnixNano())
+	return o
+}
+
+// record updates the statistics of n when a request is done.
+func (o *outlierDetector) record(n *managedNode, start time.Time, err error) {
+	n.requests.Add(1)
+	n.latency.Add(int64(time.Since(start)))
+	if !isServerError(err) {
+		n.consecutiveErrors.Store(0)
+		return
+	}
+	n.failures.Add(1)
+	if c := n.consecutiveErrors.Add(1); o.ConsecutiveErrors > 0 && c >= int64(o.ConsecutiveErrors) {
+		o.mu.Lock()
+		o.eject(n, ejectReasonConsecutiveErrors, time.Now())
+		o.mu.Unlock()
+	}
+}
+
+// available returns the nodes that are not ejected, or all nodes if every node is ejected.
+func (o *outlierDetector) available(nodes []WeightedNode) []WeightedNode {
+	now := time.Now().UnixNano()
+	if next := o.next.Load(); now >= next && o.next.CompareAndSwap(next, now+int64(o.Interval)) {
+		o.analyze(now)
+	}
+
+	var healthy []WeightedNode
+	for i, n := range nodes {
+		if n.(*managedNode).ejected(now) {
+			if healthy == nil {
+				healthy = make([]WeightedNode, i, len(nodes))
+				copy(healthy, nodes[:i])
+			}
+			continue
+		}
+		if healthy != nil {
+			healthy = append(healthy, n)
+		}
+	}
+	if len(healthy) == 0 {
+		return nodes
+	}
+	return healthy
+}
+
+// analyze ends the expired ejections and ejects the success rate and latency outliers of the last interval.
+func (o *outlierDetector) analyze(now int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	type stat struct {
+		node    *managedNode
+		rate    float64
+		latency float64
+	}
+	var rates, latencies []stat
+	for _, wn := range o.nodes() {
+		n := wn.(*managedNode)
+		requests, failures, latency := n.requests.Swap(0), n.failures.Swap(0), n.latency.Swap(0)
+
+		if until := n.ejectedUntil.Load(); until != 0 {
+			if now < until {
+				continue
+			}
+			n.ejectedUntil.Store(0)
+			log.Infof("[Selector] node %s of %s is back from ejection", n.Address(), n.ServiceName())
+		} else if n.ejections > 0 {
+			// a healthy interval brings the ejection time back down
+			n.ejections--
+		}
+
+		if o.SuccessRateStdevFactor > 0 && requests >= int64(o.SuccessRateMinRequests) && requests > 0 {
+			rates = append(rates, stat{node: n, rate: float64(requests-failures) / float64(requests)})
+		}
+		if o.LatencyFactor > 0 && requests >= int64(o.LatencyMinRequests) && requests > 0 {
+			latencies = append(latencies, stat{node: n, latency: float64(latency) / float64(requests)})
+		}
+	}
+
+	if len(rates) > 0 && len(rates) >= o.MinHosts {
+		var sum, squares float64
+		for _, s := range rates {
+			sum += s.rate
+		}
+		mean := sum / float64(len(rates))
+		for _, s := range rates {
+			squares += (s.rate - mean) * (s.rate - mean)
+		}
+		threshold := mean - o.SuccessRateStdevFactor*math.Sqrt(squares/float64(len(rates)))
+		for _, s := range rates {
+			if s.rate < threshold {
+				o.eject(s.node, ejectReasonSuccessRate, time.Unix(0, now))
+			}
+		}
+	}
+
+	if len(latencies) > 0 && len(latencies) >= o.MinHosts {
+		sorted := make([]float64, 0, len(latencies))
+		for _, s := range latencies {
+			sorted = append(sorted, s.latency)
+		}
+		sort.Float64s(sorted)
+		median := sorted[len(sorted)/2]
+		if len(sorted)%2 == 0 {
+			median = (sorted[len(sorted)/2-1] + median) / 2
+		}
+		for _, s := range latencies {
+			if s.latency > o.LatencyFactor*median {
+				o.eject(s.node, ejectReasonLatency, time.Unix(0, now))
+			}
+		}
+	}
+
+	o.updateEjected(now)
+}
+
+// eject ejects n unless it is ejected already or MaxEjectionPercent is reached, o.mu must be held.
+func (o *outlierDetector) eject(n *managedNode, reason string, now time.Time) {
+	if n.ejected(now.UnixNano()) {
+		return
+	}
+	nodes := o.nodes()
+	ejected := 0
+	for _, wn := range nodes {
+		if wn.(*managedNode).ejected(now.UnixNano()) {
+			ejected++
+		}
+	}
+	if ejected >= max(1, len(nodes)*o.MaxEjectionPercent/100) {
+		return
+	}
+
+	// the ejection time doubles with each recent ejection
+	n.ejections++
+	duration := o.BaseEjectionTime << min(n.ejections-1, 30)
+	if o.MaxEjectionTime > 0 && (duration > o.MaxEjectionTime || duration <= 0) {
+		duration = o.MaxEjectionTime
+	}
+	n.ejectedUntil.Store(now.Add(duration).UnixNano())
+	n.consecutiveErrors.Store(0)
+
+	metricOutlierEjections.Inc(n.ServiceName(), reason)
+	o.updateEjected(now.UnixNano())
+	log.Warnf("[Selector] eject node %s of %s for %s, reason: %s", n.Address(), n.ServiceName(), duration, reason)
+}
+
+func (o *outlierDetector) updateEjected(now int64) {
+	nodes := o.nodes()
+	if len(nodes) == 0 {
+		return
+	}
+	ejected := 0
+	for _, wn := range nodes {
+		if wn.(*managedNode).ejected(now) {
+			ejected++
+		}
+	}
+	metricOutlierEjected.Set(float64(ejected), nodes[0].ServiceName())
+}
+
+// isServerError reports whether err is caused by the node rather than the request or the caller.
+func isServerError(err error) bool {
+	if err == nil || kerrors.Is(err, context.Canceled) {
+		return false
+	}
+	return kerrors.FromError(err).Code >= 500
+}
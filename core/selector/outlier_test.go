@@ -0,0 +1,155 @@
+package selector
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutlierSelector(t *testing.T, cfg *OutlierDetection, count int) *Default {
+	t.Helper()
+	d := &Default{NodeBuilder: &testBuilder{}, Balancer: firstBalancer{}, Outlier: cfg}
+	nodes := make([]Node, 0, count)
+	for i := 0; i < count; i++ {
+		nodes = append(nodes, newTestNode("127.0.0.1:"+strconv.Itoa(i+1), "v1"))
+	}
+	d.Apply(nodes)
+	return d
+}
+
+func managed(d *Default, i int) *managedNode {
+	return d.nodes.Load().([]WeightedNode)[i].(*managedNode)
+}
+
+func selectDone(t *testing.T, d *Default, err error) string {
+	t.Helper()
+	n, done, e := d.Select(context.Background())
+	require.NoError(t, e)
+	done(context.Background(), DoneInfo{Err: err})
+	return n.Address()
+}
+
+func TestOutlierConsecutiveErrors(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.ConsecutiveErrors = 3
+	d := newOutlierSelector(t, cfg, 2)
+
+	// client errors and successes do not count
+	selectDone(t, d, kerrors.NotFound("", ""))
+	selectDone(t, d, kerrors.ServiceUnavailable("", ""))
+	selectDone(t, d, kerrors.ServiceUnavailable("", ""))
+	selectDone(t, d, nil)
+	selectDone(t, d, context.Canceled)
+	assert.Equal(t, "127.0.0.1:1", selectDone(t, d, kerrors.ServiceUnavailable("", "")))
+	assert.Equal(t, "127.0.0.1:1", selectDone(t, d, kerrors.ServiceUnavailable("", "")))
+	assert.Equal(t, "127.0.0.1:1", selectDone(t, d, kerrors.ServiceUnavailable("", "")))
+
+	assert.Equal(t, "127.0.0.1:2", selectDone(t, d, nil))
+	until := time.Unix(0, managed(d, 0).ejectedUntil.Load())
+	assert.WithinDuration(t, time.Now().Add(cfg.BaseEjectionTime), until, time.Second)
+}
+
+func TestOutlierMaxEjectionPercent(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.ConsecutiveErrors = 1
+	d := newOutlierSelector(t, cfg, 2)
+
+	assert.Equal(t, "127.0.0.1:1", selectDone(t, d, kerrors.ServiceUnavailable("", "")))
+	assert.Equal(t, "127.0.0.1:2", selectDone(t, d, kerrors.ServiceUnavailable("", "")))
+	// one node at most is ejected
+	assert.Equal(t, "127.0.0.1:2", selectDone(t, d, nil))
+	assert.Zero(t, managed(d, 1).ejectedUntil.Load())
+
+	// every node is used when all nodes are ejected
+	managed(d, 1).ejectedUntil.Store(time.Now().Add(time.Minute).UnixNano())
+	assert.Equal(t, "127.0.0.1:1", selectDone(t, d, nil))
+}
+
+func TestOutlierBackoff(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.BaseEjectionTime = time.Minute
+	cfg.MaxEjectionTime = 3 * time.Minute
+	d := newOutlierSelector(t, cfg, 2)
+	n := managed(d, 0)
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		now := time.Now()
+		d.outlier.mu.Lock()
+		d.outlier.eject(n, ejectReasonConsecutiveErrors, now)
+		d.outlier.mu.Unlock()
+		assert.Equal(t, now.Add(want).UnixNano(), n.ejectedUntil.Load())
+		n.ejectedUntil.Store(0)
+	}
+
+	// an expired ejection ends at the next analysis, healthy intervals lower the backoff
+	n.ejectedUntil.Store(time.Now().Add(-time.Second).UnixNano())
+	d.outlier.analyze(time.Now().UnixNano())
+	assert.Zero(t, n.ejectedUntil.Load())
+	assert.Equal(t, 3, n.ejections)
+	d.outlier.analyze(time.Now().UnixNano())
+	assert.Equal(t, 2, n.ejections)
+}
+
+func TestOutlierSuccessRate(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.SuccessRateMinRequests = 10
+	d := newOutlierSelector(t, cfg, 5)
+
+	for i := 0; i < 5; i++ {
+		n := managed(d, i)
+		n.requests.Store(100)
+		if i == 3 {
+			n.failures.Store(50)
+		}
+	}
+	d.outlier.analyze(time.Now().UnixNano())
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, i == 3, managed(d, i).ejected(time.Now().UnixNano()), i)
+	}
+
+	// too few nodes with enough requests
+	d = newOutlierSelector(t, cfg, 5)
+	managed(d, 0).requests.Store(100)
+	managed(d, 0).failures.Store(100)
+	d.outlier.analyze(time.Now().UnixNano())
+	assert.False(t, managed(d, 0).ejected(time.Now().UnixNano()))
+}
+
+func TestOutlierLatency(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.LatencyMinRequests = 10
+	d := newOutlierSelector(t, cfg, 5)
+
+	for i := 0; i < 5; i++ {
+		n := managed(d, i)
+		n.requests.Store(100)
+		latency := 10 * time.Millisecond
+		if i == 2 {
+			latency = 100 * time.Millisecond
+		}
+		n.latency.Store(100 * int64(latency))
+	}
+	d.outlier.analyze(time.Now().UnixNano())
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, i == 2, managed(d, i).ejected(time.Now().UnixNano()), i)
+	}
+	// the interval statistics are reset
+	assert.Zero(t, managed(d, 0).requests.Load())
+}
+
+func TestOutlierInterval(t *testing.T) {
+	cfg := DefaultOutlierDetection()
+	cfg.Interval = time.Millisecond
+	d := newOutlierSelector(t, cfg, 2)
+	n := managed(d, 0)
+	n.ejectedUntil.Store(time.Now().Add(time.Millisecond).UnixNano())
+
+	time.Sleep(2 * time.Millisecond)
+	selectDone(t, d, nil)
+	assert.Zero(t, n.ejectedUntil.Load())
+}
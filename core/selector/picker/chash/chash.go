@@ -2,96 +2,279 @@ package chash
 
 import (
 	"context"
-	"fmt"
-	"hash/crc32"
+	"math"
+	"math/rand"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"github.com/taluos/Malt/core/selector/picker/node/direct"
 
 	"github.com/taluos/Malt/core/selector"
+
+	"github.com/cespare/xxhash/v2"
 )
 
 const (
-	// Name is the name of the random balancer.
+	// Name is the name of the consistent hash balancer.
 	Name = "chash"
+
+	defaultReplicas   = 160
+	defaultLoadFactor = 1.25
+	// defaultWeight is the node weight that gets exactly replicas virtual nodes.
+	defaultWeight = 100
 )
 
+var _ selector.Balancer = (*Balancer)(nil)
+
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
+type options struct {
+	replicas   int
+	loadFactor float64
+
+	selectorOpts []selector.BuilderOption
+}
+
+// Option is chash builder option.
+type Option func(o *options)
+
+// WithSelectorOptions applies selector options such as selector.WithOutlierDetection to the built selector.
+func WithSelectorOptions(opts ...selector.BuilderOption) Option {
+	return func(o *options) {
+		o.selectorOpts = append(o.selectorOpts, opts...)
+	}
+}
+
+// WithReplicas sets the number of virtual nodes of a node with the default weight 100.
+// A node gets replicas*weight/100 virtual nodes, at least one.
+func WithReplicas(replicas int) Option {
+	return func(o *options) {
+		if replicas > 0 {
+			o.replicas = replicas
+		}
+	}
+}
+
+// WithLoadFactor bounds the in-flight requests of every node to loadFactor times the average,
+// keys of an overloaded node move clockwise to the next node on the ring.
+// A factor <= 1 disables the bound.
+func WithLoadFactor(loadFactor float64) Option {
+	return func(o *options) {
+		o.loadFactor = loadFactor
+	}
+}
+
+// Balancer is a consistent hash balancer with bounded loads.
+// Calls carrying the same selector hash key go to the same node while the node set is stable,
+// calls without a key go to a random node.
+// The ring is built from all the nodes of the selector and filtered candidates are looked up on it,
+// so filters and outlier ejection only move the keys of the nodes they remove.
 type Balancer struct {
-	ring *hashRing
+	opts options
+
+	mu   sync.Mutex // serializes ring rebuilds
+	ring atomic.Pointer[hashRing]
+
+	loads    sync.Map // node address -> *atomic.Int64 in-flight requests
+	inflight atomic.Int64
 }
 
 type hashRing struct {
-	replicas int
-	keys     []uint32
-	hashMap  map[uint32]selector.WeightedNode
-	mu       sync.RWMutex
+	nodes  []selector.WeightedNode // sorted by address
+	index  map[string]int          // address -> index in nodes
+	points []point                 // sorted by hash
+}
+
+type point struct {
+	hash uint64
+	node int // index in nodes
 }
 
-func NewSelector() selector.Selector {
-	return NewBuilder().Build()
+func NewSelector(opts ...Option) selector.Selector {
+	return NewBuilder(opts...).Build()
 }
 
+// Pick picks the node owning the hash key in ctx.
 func (p *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
 	if len(nodes) == 0 {
 		return nil, nil, selector.ErrNoAvailable
 	}
 
-	p.ring = &hashRing{
-		replicas: 5,
-		keys:     make([]uint32, 0, len(nodes)),
-		hashMap:  make(map[uint32]selector.WeightedNode),
+	var selected selector.WeightedNode
+	if key, ok := selector.FromHashKeyContext(ctx); ok {
+		selected = p.lookup(p.getRing(nodes), nodes, xxhash.Sum64String(key))
+	} else {
+		selected = nodes[rand.Intn(len(nodes))]
+	}
+
+	load := p.load(selected.Address())
+	load.Add(1)
+	p.inflight.Add(1)
+	d := selected.Pick()
+	return selected, func(ctx context.Context, di selector.DoneInfo) {
+		load.Add(-1)
+		p.inflight.Add(-1)
+		d(ctx, di)
+	}, nil
+}
+
+// Rebuild builds the ring from all the nodes of the selector,
+// the ring points are kept while the addresses and weights stay the same.
+func (p *Balancer) Rebuild(nodes []selector.WeightedNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sorted := sortNodes(nodes)
+	r := p.ring.Load()
+	if r != nil && r.sameMembers(sorted) {
+		// Apply rebuilt the weighted nodes without changing membership, keep the ring points.
+		r = &hashRing{nodes: sorted, index: r.index, points: r.points}
+	} else {
+		r = p.build(sorted)
+		p.pruneLoads(sorted)
+	}
+	p.ring.Store(r)
+}
+
+// getRing returns the ring, a balancer used without Default builds it from the first candidates.
+func (p *Balancer) getRing(nodes []selector.WeightedNode) *hashRing {
+	if r := p.ring.Load(); r != nil {
+		return r
 	}
 
-	p.ring.mu.Lock()
-	for _, node := range nodes {
-		for i := 0; i < p.ring.replicas; i++ {
-			key := fmt.Sprintf("%s#%d", node.ID(), i)
-			h := crc32.ChecksumIEEE([]byte(key))
-			p.ring.keys = append(p.ring.keys, h)
-			p.ring.hashMap[h] = node
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r := p.ring.Load(); r != nil {
+		return r
+	}
+	r := p.build(sortNodes(nodes))
+	p.ring.Store(r)
+	return r
+}
+
+// build builds the ring of nodes sorted by address.
+func (p *Balancer) build(nodes []selector.WeightedNode) *hashRing {
+	r := &hashRing{nodes: nodes, index: make(map[string]int, len(nodes))}
+	for i, n := range nodes {
+		r.index[n.Address()] = i
+		for j := 0; j < p.virtualNodes(n); j++ {
+			h := xxhash.Sum64String(n.Address() + "#" + strconv.Itoa(j))
+			r.points = append(r.points, point{hash: h, node: i})
 		}
 	}
-	sort.Slice(p.ring.keys, func(i, j int) bool { return p.ring.keys[i] < p.ring.keys[j] })
-	p.ring.mu.Unlock()
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
 
-	key, ok := ctx.Value("FullMethod").(string)
-	if !ok {
-		return nil, nil, selector.ErrNoAvailable
+// virtualNodes uses the static weight of the node, so runtime weights such as ewma do not rebuild the ring.
+func (p *Balancer) virtualNodes(n selector.WeightedNode) int {
+	count := int(int64(p.opts.replicas) * weight(n) / defaultWeight)
+	if count < 1 {
+		count = 1
 	}
+	return count
+}
 
-	h := crc32.ChecksumIEEE([]byte(key))
+// lookup walks the ring clockwise from h to the first candidate under the load bound.
+func (p *Balancer) lookup(r *hashRing, candidates []selector.WeightedNode, h uint64) selector.WeightedNode {
+	allowed := make([]selector.WeightedNode, len(r.nodes))
+	for _, n := range candidates {
+		i, ok := r.index[n.Address()]
+		if !ok {
+			// candidates outside the ring, such as a pick racing with Apply, get a ring of their own
+			return p.lookup(p.build(sortNodes(candidates)), candidates, h)
+		}
+		allowed[i] = n
+	}
 
-	p.ring.mu.RLock()
-	idx := sort.Search(len(p.ring.keys), func(i int) bool {
-		return p.ring.keys[i] >= h
-	})
-	if idx == len(p.ring.keys) {
-		idx = 0
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	var first selector.WeightedNode
+	// capacity = ceil(loadFactor * (in-flight + 1) / candidates)
+	capacity := int64(math.Ceil(p.opts.loadFactor * float64(p.inflight.Load()+1) / float64(len(candidates))))
+	for i := 0; i < len(r.points); i++ {
+		n := allowed[r.points[(start+i)%len(r.points)].node]
+		if n == nil {
+			continue
+		}
+		if p.opts.loadFactor <= 1 || p.load(n.Address()).Load() < capacity {
+			return n
+		}
+		if first == nil {
+			first = n
+		}
 	}
-	selected := p.ring.hashMap[p.ring.keys[idx]]
-	p.ring.mu.RUnlock()
+	return first
+}
 
-	if selected == nil {
-		return nil, nil, selector.ErrNoAvailable
+func weight(n selector.WeightedNode) int64 {
+	if w := n.InitialWeight(); w != nil {
+		return *w
 	}
+	return defaultWeight
+}
 
-	d := selected.Pick()
+func (p *Balancer) load(addr string) *atomic.Int64 {
+	if v, ok := p.loads.Load(addr); ok {
+		return v.(*atomic.Int64)
+	}
+	v, _ := p.loads.LoadOrStore(addr, new(atomic.Int64))
+	return v.(*atomic.Int64)
+}
 
-	return selected, d, nil
+// pruneLoads drops the idle counters of removed nodes.
+func (p *Balancer) pruneLoads(nodes []selector.WeightedNode) {
+	alive := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		alive[n.Address()] = struct{}{}
+	}
+	p.loads.Range(func(key, value any) bool {
+		if _, ok := alive[key.(string)]; !ok && value.(*atomic.Int64).Load() == 0 {
+			p.loads.Delete(key)
+		}
+		return true
+	})
 }
 
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
-		Balancer: &Builder{},
-		Node:     &direct.Builder{},
+func NewBuilder(opts ...Option) selector.Builder {
+	o := options{replicas: defaultReplicas, loadFactor: defaultLoadFactor}
+	for _, opt := range opts {
+		opt(&o)
 	}
+	return selector.Configure(&selector.DefaultBuilder{
+		Balancer: &Builder{opts: o},
+		Node:     &direct.Builder{},
+	}, o.selectorOpts...)
 }
 
-// Builder is wrr builder
-type Builder struct{}
+// Builder is chash builder
+type Builder struct {
+	opts options
+}
 
 // Build creates Balancer
 func (b *Builder) Build() selector.Balancer {
-	return &Balancer{}
+	return &Balancer{opts: b.opts}
+}
+
+// sameMembers reports whether the sorted nodes have the same addresses and weights as the ring.
+func (r *hashRing) sameMembers(nodes []selector.WeightedNode) bool {
+	if len(r.nodes) != len(nodes) {
+		return false
+	}
+	for i, n := range nodes {
+		if r.nodes[i].Address() != n.Address() || weight(r.nodes[i]) != weight(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortNodes(nodes []selector.WeightedNode) []selector.WeightedNode {
+	sorted := append([]selector.WeightedNode(nil), nodes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Address() < sorted[j].Address() })
+	return sorted
 }
@@ -0,0 +1,175 @@
+package chash
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNodes(weights ...int) []selector.WeightedNode {
+	nodes := make([]selector.WeightedNode, 0, len(weights))
+	for i, w := range weights {
+		addr := "127.0.0.1:" + strconv.Itoa(9000+i)
+		ins := &registry.ServiceInstance{ID: addr, Metadata: map[string]string{"weight": strconv.Itoa(w)}}
+		nodes = append(nodes, (&direct.Builder{}).Build(selector.NewNode("grpc", addr, ins)))
+	}
+	return nodes
+}
+
+func newBalancer(opts ...Option) *Balancer {
+	return NewBuilder(opts...).(*selector.DefaultBuilder).Balancer.Build().(*Balancer)
+}
+
+func pick(t *testing.T, b *Balancer, key string, nodes []selector.WeightedNode) (string, selector.DoneFunc) {
+	t.Helper()
+	n, done, err := b.Pick(selector.NewHashKeyContext(context.Background(), key), nodes)
+	require.NoError(t, err)
+	return n.Address(), done
+}
+
+func TestPickSticky(t *testing.T) {
+	b := newBalancer()
+	nodes := newNodes(100, 100, 100, 100)
+
+	owners := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		key := "user-" + strconv.Itoa(i)
+		addr, done := pick(t, b, key, nodes)
+		done(context.Background(), selector.DoneInfo{})
+		owners[key] = addr
+	}
+	seen := make(map[string]struct{})
+	for key, addr := range owners {
+		got, done := pick(t, b, key, nodes)
+		done(context.Background(), selector.DoneInfo{})
+		assert.Equal(t, addr, got)
+		seen[addr] = struct{}{}
+	}
+	assert.Len(t, seen, 4)
+
+	// only the keys of the removed node move
+	removed := nodes[3].Address()
+	for key, addr := range owners {
+		got, done := pick(t, b, key, nodes[:3])
+		done(context.Background(), selector.DoneInfo{})
+		if addr != removed {
+			assert.Equal(t, addr, got, key)
+		} else {
+			assert.NotEqual(t, removed, got)
+		}
+	}
+}
+
+func TestRingReuse(t *testing.T) {
+	b := newBalancer()
+	nodes := newNodes(100, 100, 100)
+	b.Rebuild(nodes)
+	r := b.ring.Load()
+
+	// filtered candidates and another order look up the same ring
+	pick(t, b, "k", nodes[:2])
+	pick(t, b, "k", []selector.WeightedNode{nodes[2], nodes[1], nodes[0]})
+	assert.Same(t, r, b.ring.Load())
+
+	// rebuilt weighted nodes with the same members keep the ring points
+	renewed := newNodes(100, 100, 100)
+	b.Rebuild([]selector.WeightedNode{renewed[1], renewed[2], renewed[0]})
+	assert.NotSame(t, r, b.ring.Load())
+	assert.Same(t, &r.points[0], &b.ring.Load().points[0])
+
+	b.Rebuild(newNodes(100, 100))
+	assert.NotSame(t, &r.points[0], &b.ring.Load().points[0])
+}
+
+func TestSelectorRebuildsOnApply(t *testing.T) {
+	s := NewSelector()
+	var nodes []selector.Node
+	for _, n := range newNodes(100, 100, 100, 100) {
+		nodes = append(nodes, n.Raw())
+	}
+	s.Apply(nodes)
+	b := s.(*selector.Default).Balancer.(*Balancer)
+	r := b.ring.Load()
+	require.NotNil(t, r)
+
+	ctx := selector.NewHashKeyContext(context.Background(), "user-1")
+	first, done, err := s.Select(ctx)
+	require.NoError(t, err)
+	done(ctx, selector.DoneInfo{})
+
+	// the same members in another order keep the ring and the owner of the key
+	s.Apply([]selector.Node{nodes[3], nodes[1], nodes[0], nodes[2]})
+	assert.Same(t, &r.points[0], &b.ring.Load().points[0])
+	got, done, err := s.Select(ctx)
+	require.NoError(t, err)
+	done(ctx, selector.DoneInfo{})
+	assert.Equal(t, first.Address(), got.Address())
+}
+
+func TestPickBoundedLoad(t *testing.T) {
+	const picks = 40
+	nodes := newNodes(100, 100, 100, 100)
+
+	b := newBalancer()
+	loads := make(map[string]int)
+	for i := 0; i < picks; i++ {
+		addr, _ := pick(t, b, "hot", nodes)
+		loads[addr]++
+	}
+	for _, load := range loads {
+		assert.LessOrEqual(t, load, int(math.Ceil(defaultLoadFactor*picks/4)))
+	}
+
+	b = newBalancer(WithLoadFactor(0))
+	loads = make(map[string]int)
+	dones := make([]selector.DoneFunc, 0, picks)
+	for i := 0; i < picks; i++ {
+		addr, done := pick(t, b, "hot", nodes)
+		dones = append(dones, done)
+		loads[addr]++
+	}
+	assert.Len(t, loads, 1)
+	assert.Equal(t, int64(picks), b.inflight.Load())
+	for _, done := range dones {
+		done(context.Background(), selector.DoneInfo{})
+	}
+	assert.Zero(t, b.inflight.Load())
+}
+
+func TestPickWeighted(t *testing.T) {
+	const keys = 10000
+	b := newBalancer(WithLoadFactor(0))
+	nodes := newNodes(300, 100, 100, 100)
+
+	heavy := 0
+	for i := 0; i < keys; i++ {
+		addr, done := pick(t, b, "user-"+strconv.Itoa(i), nodes)
+		done(context.Background(), selector.DoneInfo{})
+		if addr == nodes[0].Address() {
+			heavy++
+		}
+	}
+	// the heavy node owns about half of the keys
+	assert.InDelta(t, 0.5, float64(heavy)/keys, 0.1)
+}
+
+func TestPickWithoutKey(t *testing.T) {
+	b := newBalancer()
+	_, _, err := b.Pick(context.Background(), nil)
+	assert.ErrorIs(t, err, selector.ErrNoAvailable)
+
+	n, done, err := b.Pick(context.Background(), newNodes(100, 100))
+	require.NoError(t, err)
+	assert.NotNil(t, n)
+	done(context.Background(), selector.DoneInfo{})
+	assert.Nil(t, b.ring.Load())
+	assert.Zero(t, b.inflight.Load())
+}
@@ -2,18 +2,30 @@ package hash
 
 import (
 	"context"
-	"hash/crc32"
+	"math/rand"
+	"sort"
 
 	"github.com/taluos/Malt/core/selector/picker/node/direct"
 
 	"github.com/taluos/Malt/core/selector"
+
+	"github.com/cespare/xxhash/v2"
 )
 
 const (
-	// DefaultReplicas is the default number of replicas.
+	// Name is hash balancer name
 	Name = "hash"
 )
 
+var _ selector.Balancer = (*Balancer)(nil)
+
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
+// Balancer picks nodes[hash(key) % len(nodes)] for the selector hash key in ctx, with nodes sorted
+// by address so the pick does not depend on their order. Calls without a key go to a random node. Most keys move when the node set changes,
+// use chash when that matters.
 type Balancer struct{}
 
 func NewSelector() selector.Selector {
@@ -24,31 +36,27 @@ func (p *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (sel
 	if len(nodes) == 0 {
 		return nil, nil, selector.ErrNoAvailable
 	}
-	// 使用CRC32哈希算法计算方法名的哈希值，并取模得到节点索引
-	methodName, ok := ctx.Value("FullMethod").(string)
-	if !ok {
-		return nil, nil, selector.ErrNoAvailable
-	}
-	hashVal := crc32.ChecksumIEEE([]byte(methodName))
-	key := int(hashVal) % len(nodes)
 
-	if key < 0 || key >= len(nodes) {
-		key = 0
+	var selected selector.WeightedNode
+	if key, ok := selector.FromHashKeyContext(ctx); ok {
+		sorted := append([]selector.WeightedNode(nil), nodes...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Address() < sorted[j].Address() })
+		selected = sorted[xxhash.Sum64String(key)%uint64(len(sorted))]
+	} else {
+		selected = nodes[rand.Intn(len(nodes))]
 	}
-
-	selected := nodes[key]
 	d := selected.Pick()
 	return selected, d, nil
 }
 
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
-	}
+	}, opts...)
 }
 
-// Builder is wrr builder
+// Builder is hash builder
 type Builder struct{}
 
 // Build creates Balancer
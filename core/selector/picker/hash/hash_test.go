@@ -0,0 +1,50 @@
+package hash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPick(t *testing.T) {
+	b := &Balancer{}
+	_, _, err := b.Pick(context.Background(), nil)
+	assert.ErrorIs(t, err, selector.ErrNoAvailable)
+
+	var nodes []selector.WeightedNode
+	for i := 0; i < 3; i++ {
+		addr := "127.0.0.1:" + strconv.Itoa(9000+i)
+		nodes = append(nodes, (&direct.Builder{}).Build(selector.NewNode("grpc", addr, nil)))
+	}
+
+	ctx := selector.NewHashKeyContext(context.Background(), "user-1")
+	first, done, err := b.Pick(ctx, nodes)
+	require.NoError(t, err)
+	done(ctx, selector.DoneInfo{})
+	for i := 0; i < 20; i++ {
+		n, done, err := b.Pick(ctx, nodes)
+		require.NoError(t, err)
+		done(ctx, selector.DoneInfo{})
+		assert.Equal(t, first.Address(), n.Address())
+	}
+
+	// the same key picks the same node whatever the order of nodes
+	reversed := []selector.WeightedNode{nodes[2], nodes[1], nodes[0]}
+	for i := 0; i < 20; i++ {
+		key := "user-" + strconv.Itoa(i)
+		ctx := selector.NewHashKeyContext(context.Background(), key)
+		a, done, err := b.Pick(ctx, nodes)
+		require.NoError(t, err)
+		done(ctx, selector.DoneInfo{})
+		c, done, err := b.Pick(ctx, reversed)
+		require.NoError(t, err)
+		done(ctx, selector.DoneInfo{})
+		assert.Equal(t, a.Address(), c.Address(), key)
+	}
+}
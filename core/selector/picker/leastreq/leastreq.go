@@ -0,0 +1,97 @@
+package leastreq
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/taluos/Malt/core/selector"
+)
+
+const (
+	// Name is the name of the least request balancer.
+	Name = "leastreq"
+)
+
+var _ selector.Balancer = (*Balancer)(nil)
+
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
+// Balancer picks the node with the least outstanding requests relative to its weight,
+// ties are broken randomly.
+type Balancer struct {
+	active sync.Map // node address -> *atomic.Int64 outstanding requests
+}
+
+// New creates a least request selector.
+func New() selector.Selector {
+	return NewBuilder().Build()
+}
+
+func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+
+	var (
+		selected selector.WeightedNode
+		active   *atomic.Int64
+		best     float64
+	)
+	// start at a random offset so equal nodes share the requests
+	offset := rand.Intn(len(nodes))
+	for i := range nodes {
+		n := nodes[(offset+i)%len(nodes)]
+		a := p.outstanding(n.Address())
+		weight := n.Weight()
+		if weight <= 0 {
+			continue
+		}
+		// the request to pick counts, so heavier nodes win when all nodes are idle
+		score := float64(a.Load()+1) / weight
+		if selected == nil || score < best {
+			selected, active, best = n, a, score
+		}
+	}
+	if selected == nil {
+		selected = nodes[offset]
+		active = p.outstanding(selected.Address())
+	}
+
+	active.Add(1)
+	d := selected.Pick()
+	return selected, func(ctx context.Context, di selector.DoneInfo) {
+		active.Add(-1)
+		d(ctx, di)
+	}, nil
+}
+
+func (p *Balancer) outstanding(addr string) *atomic.Int64 {
+	if v, ok := p.active.Load(addr); ok {
+		return v.(*atomic.Int64)
+	}
+	v, _ := p.active.LoadOrStore(addr, new(atomic.Int64))
+	return v.(*atomic.Int64)
+}
+
+// NewBuilder returns a selector builder with least request balancer
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
+		Balancer: &Builder{},
+		Node:     &direct.Builder{},
+		Warmup:   selector.DefaultWarmup,
+	}, opts...)
+}
+
+// Builder is least request builder
+type Builder struct{}
+
+// Build creates Balancer
+func (b *Builder) Build() selector.Balancer {
+	return &Balancer{}
+}
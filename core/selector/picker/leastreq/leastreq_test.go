@@ -0,0 +1,63 @@
+package leastreq
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNodes(weights ...int) []selector.WeightedNode {
+	nodes := make([]selector.WeightedNode, 0, len(weights))
+	for i, w := range weights {
+		addr := "127.0.0.1:" + strconv.Itoa(9000+i)
+		ins := &registry.ServiceInstance{ID: addr, Metadata: map[string]string{registry.MetadataWeight: strconv.Itoa(w)}}
+		nodes = append(nodes, (&direct.Builder{}).Build(selector.NewNode("grpc", addr, ins)))
+	}
+	return nodes
+}
+
+func TestPick(t *testing.T) {
+	b := &Balancer{}
+	_, _, err := b.Pick(context.Background(), nil)
+	assert.ErrorIs(t, err, selector.ErrNoAvailable)
+
+	nodes := newNodes(100, 100, 100)
+	dones := make(map[string][]selector.DoneFunc)
+	for i := 0; i < 6; i++ {
+		n, done, err := b.Pick(context.Background(), nodes)
+		require.NoError(t, err)
+		dones[n.Address()] = append(dones[n.Address()], done)
+	}
+	// outstanding requests spread evenly
+	for _, addr := range []string{"127.0.0.1:9000", "127.0.0.1:9001", "127.0.0.1:9002"} {
+		assert.Len(t, dones[addr], 2, addr)
+	}
+
+	// the node with the fewest outstanding requests wins
+	for _, done := range dones["127.0.0.1:9001"] {
+		done(context.Background(), selector.DoneInfo{})
+	}
+	n, _, err := b.Pick(context.Background(), nodes)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9001", n.Address())
+}
+
+func TestPickWeighted(t *testing.T) {
+	b := &Balancer{}
+	nodes := newNodes(300, 100)
+	picked := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		n, _, err := b.Pick(context.Background(), nodes)
+		require.NoError(t, err)
+		picked[n.Address()]++
+	}
+	assert.Equal(t, 6, picked["127.0.0.1:9000"])
+	assert.Equal(t, 2, picked["127.0.0.1:9001"])
+}
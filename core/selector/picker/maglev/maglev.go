@@ -0,0 +1,281 @@
+package maglev
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/taluos/Malt/core/selector"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// Name is the name of the maglev balancer.
+	Name = "maglev"
+
+	defaultTableSize = 65537
+	// defaultWeight is the weight of nodes without an initial weight.
+	defaultWeight = 100
+)
+
+var _ selector.Balancer = (*Balancer)(nil)
+
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
+type options struct {
+	tableSize uint64
+
+	selectorOpts []selector.BuilderOption
+}
+
+// Option is maglev builder option.
+type Option func(o *options)
+
+// WithSelectorOptions applies selector options such as selector.WithOutlierDetection to the built selector.
+func WithSelectorOptions(opts ...selector.BuilderOption) Option {
+	return func(o *options) {
+		o.selectorOpts = append(o.selectorOpts, opts...)
+	}
+}
+
+// WithTableSize sets the lookup table size, it should be much larger than the number of nodes.
+// A size that is not a prime is rounded up to the next prime, so every preference list covers the table.
+func WithTableSize(size uint64) Option {
+	return func(o *options) {
+		if size > 1 {
+			o.tableSize = nextPrime(size)
+		}
+	}
+}
+
+// nextPrime returns the smallest prime not less than n.
+func nextPrime(n uint64) uint64 {
+	for ; ; n++ {
+		if isPrime(n) {
+			return n
+		}
+	}
+}
+
+func isPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for d := uint64(2); d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Balancer is a maglev consistent hash balancer.
+// Calls carrying the same selector hash key go to the same node, and a membership change
+// moves little more than the keys of the changed nodes. Calls without a key go to a random node.
+// The table is built from all the nodes of the selector, keys of a filtered out node go to
+// the next allowed entry of the table.
+type Balancer struct {
+	opts options
+
+	mu    sync.Mutex // serializes table rebuilds
+	table atomic.Pointer[lookupTable]
+}
+
+type lookupTable struct {
+	nodes   []selector.WeightedNode // sorted by address
+	index   map[string]int          // address -> index in nodes
+	entries []int32                 // index in nodes
+}
+
+// New creates a maglev selector.
+func New(opts ...Option) selector.Selector {
+	return NewBuilder(opts...).Build()
+}
+
+// Pick picks the node owning the hash key in ctx.
+func (p *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (selector.WeightedNode, selector.DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, selector.ErrNoAvailable
+	}
+
+	var selected selector.WeightedNode
+	if key, ok := selector.FromHashKeyContext(ctx); ok {
+		selected = p.lookup(p.getTable(nodes), nodes, xxhash.Sum64String(key))
+	} else {
+		selected = nodes[rand.Intn(len(nodes))]
+	}
+	d := selected.Pick()
+	return selected, d, nil
+}
+
+// Rebuild builds the table from all the nodes of the selector,
+// the entries are kept while the addresses and weights stay the same.
+func (p *Balancer) Rebuild(nodes []selector.WeightedNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sorted := sortNodes(nodes)
+	t := p.table.Load()
+	if t != nil && t.sameMembers(sorted) {
+		// Apply rebuilt the weighted nodes without changing membership, keep the entries.
+		t = &lookupTable{nodes: sorted, index: t.index, entries: t.entries}
+	} else {
+		t = p.build(sorted)
+	}
+	p.table.Store(t)
+}
+
+// getTable returns the lookup table, a balancer used without Default builds it from the first candidates.
+func (p *Balancer) getTable(nodes []selector.WeightedNode) *lookupTable {
+	if t := p.table.Load(); t != nil {
+		return t
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t := p.table.Load(); t != nil {
+		return t
+	}
+	t := p.build(sortNodes(nodes))
+	p.table.Store(t)
+	return t
+}
+
+// lookup returns the candidate of the first entry from h that belongs to a candidate.
+func (p *Balancer) lookup(t *lookupTable, candidates []selector.WeightedNode, h uint64) selector.WeightedNode {
+	allowed := make([]selector.WeightedNode, len(t.nodes))
+	for _, n := range candidates {
+		i, ok := t.index[n.Address()]
+		if !ok {
+			// candidates outside the table, such as a pick racing with Apply, get a table of their own
+			return p.lookup(p.build(sortNodes(candidates)), candidates, h)
+		}
+		allowed[i] = n
+	}
+
+	size := uint64(len(t.entries))
+	for i := uint64(0); i < size; i++ {
+		if n := allowed[t.entries[(h+i)%size]]; n != nil {
+			return n
+		}
+	}
+	return candidates[0]
+}
+
+// build fills the table from the preference list of every node sorted by address,
+// heavier nodes take turns more often.
+func (p *Balancer) build(nodes []selector.WeightedNode) *lookupTable {
+	size := p.opts.tableSize
+	if len(nodes) == 0 {
+		return &lookupTable{index: map[string]int{}}
+	}
+	var (
+		offsets = make([]uint64, len(nodes))
+		skips   = make([]uint64, len(nodes))
+		next    = make([]uint64, len(nodes))
+		weights = make([]float64, len(nodes))
+		turns   = make([]float64, len(nodes))
+	)
+	maxWeight := int64(1)
+	for _, n := range nodes {
+		maxWeight = max(maxWeight, weight(n))
+	}
+	for i, n := range nodes {
+		offsets[i] = xxhash.Sum64String(n.Address()) % size
+		skips[i] = xxhash.Sum64String(n.Address()+"#skip")%(size-1) + 1
+		weights[i] = float64(weight(n)) / float64(maxWeight)
+	}
+
+	entries := make([]int32, size)
+	for i := range entries {
+		entries[i] = -1
+	}
+	filled := uint64(0)
+	// with a prime size every preference list visits each entry once, the probes and
+	// iterations are bounded anyway so the table is always built, the rest is filled in turn
+	for iteration := 1; filled < size && iteration <= int(size); iteration++ {
+		for i := range nodes {
+			if float64(iteration)*weights[i] < turns[i] {
+				continue
+			}
+			turns[i]++
+			for next[i] < size {
+				c := (offsets[i] + next[i]*skips[i]) % size
+				next[i]++
+				if entries[c] < 0 {
+					entries[c] = int32(i)
+					filled++
+					break
+				}
+			}
+			if filled == size {
+				break
+			}
+		}
+	}
+	for c := range entries {
+		if entries[c] < 0 {
+			entries[c] = int32(c % len(nodes))
+		}
+	}
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		index[n.Address()] = i
+	}
+	return &lookupTable{nodes: nodes, index: index, entries: entries}
+}
+
+func weight(n selector.WeightedNode) int64 {
+	if w := n.InitialWeight(); w != nil {
+		return max(*w, 1)
+	}
+	return defaultWeight
+}
+
+// sameMembers reports whether the sorted nodes have the same addresses and weights as the table.
+func (t *lookupTable) sameMembers(nodes []selector.WeightedNode) bool {
+	if len(t.nodes) != len(nodes) {
+		return false
+	}
+	for i, n := range nodes {
+		if t.nodes[i].Address() != n.Address() || weight(t.nodes[i]) != weight(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortNodes(nodes []selector.WeightedNode) []selector.WeightedNode {
+	sorted := append([]selector.WeightedNode(nil), nodes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Address() < sorted[j].Address() })
+	return sorted
+}
+
+// NewBuilder returns a selector builder with maglev balancer
+func NewBuilder(opts ...Option) selector.Builder {
+	o := options{tableSize: defaultTableSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return selector.Configure(&selector.DefaultBuilder{
+		Balancer: &Builder{opts: o},
+		Node:     &direct.Builder{},
+	}, o.selectorOpts...)
+}
+
+// Builder is maglev builder
+type Builder struct {
+	opts options
+}
+
+// Build creates Balancer
+func (b *Builder) Build() selector.Balancer {
+	return &Balancer{opts: b.opts}
+}
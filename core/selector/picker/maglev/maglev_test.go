@@ -0,0 +1,123 @@
+package maglev
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/registry"
+	"github.com/taluos/Malt/core/selector"
+	"github.com/taluos/Malt/core/selector/picker/node/direct"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNodes(weights ...int) []selector.WeightedNode {
+	nodes := make([]selector.WeightedNode, 0, len(weights))
+	for i, w := range weights {
+		addr := "127.0.0.1:" + strconv.Itoa(9000+i)
+		ins := &registry.ServiceInstance{ID: addr, Metadata: map[string]string{registry.MetadataWeight: strconv.Itoa(w)}}
+		nodes = append(nodes, (&direct.Builder{}).Build(selector.NewNode("grpc", addr, ins)))
+	}
+	return nodes
+}
+
+func owners(t *testing.T, b *Balancer, nodes []selector.WeightedNode, keys int) map[string]string {
+	t.Helper()
+	owners := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := "user-" + strconv.Itoa(i)
+		n, done, err := b.Pick(selector.NewHashKeyContext(context.Background(), key), nodes)
+		require.NoError(t, err)
+		done(context.Background(), selector.DoneInfo{})
+		owners[key] = n.Address()
+	}
+	return owners
+}
+
+func TestPickSticky(t *testing.T) {
+	b := &Balancer{opts: options{tableSize: defaultTableSize}}
+	_, _, err := b.Pick(context.Background(), nil)
+	assert.ErrorIs(t, err, selector.ErrNoAvailable)
+
+	nodes := newNodes(100, 100, 100, 100, 100)
+	before := owners(t, b, nodes, 10000)
+	assert.Equal(t, before, owners(t, b, nodes, 10000))
+
+	// filtering a node out moves only its keys
+	remaining := append(nodes[:2:2], nodes[3:]...)
+	after := owners(t, b, remaining, 10000)
+	for key, addr := range before {
+		if addr == nodes[2].Address() {
+			assert.NotEqual(t, addr, after[key])
+		} else {
+			assert.Equal(t, addr, after[key])
+		}
+	}
+
+	// removing a node moves its keys and few others
+	b.Rebuild(remaining)
+	after = owners(t, b, remaining, 10000)
+	moved := 0
+	for key, addr := range before {
+		if addr == nodes[2].Address() {
+			assert.NotEqual(t, addr, after[key])
+		} else if after[key] != addr {
+			moved++
+		}
+	}
+	assert.Less(t, moved, 10000/50)
+}
+
+func TestTableReuse(t *testing.T) {
+	b := &Balancer{opts: options{tableSize: 251}}
+	nodes := newNodes(100, 100, 100)
+	b.Rebuild(nodes)
+	table := b.table.Load()
+
+	// filtered candidates and another order look up the same table
+	owners(t, b, nodes[1:], 1)
+	owners(t, b, []selector.WeightedNode{nodes[2], nodes[0], nodes[1]}, 1)
+	assert.Same(t, table, b.table.Load())
+
+	// rebuilt weighted nodes with the same members keep the entries
+	renewed := newNodes(100, 100, 100)
+	b.Rebuild([]selector.WeightedNode{renewed[2], renewed[1], renewed[0]})
+	assert.Same(t, &table.entries[0], &b.table.Load().entries[0])
+
+	b.Rebuild(newNodes(100, 100))
+	assert.NotSame(t, &table.entries[0], &b.table.Load().entries[0])
+}
+
+func TestPickWeighted(t *testing.T) {
+	b := &Balancer{opts: options{tableSize: defaultTableSize}}
+	nodes := newNodes(300, 100, 100, 100)
+	heavy := 0
+	for _, addr := range owners(t, b, nodes, 10000) {
+		if addr == nodes[0].Address() {
+			heavy++
+		}
+	}
+	assert.InDelta(t, 0.5, float64(heavy)/10000, 0.05)
+}
+
+func TestTableSizeNotPrime(t *testing.T) {
+	o := options{tableSize: defaultTableSize}
+	WithTableSize(4)(&o)
+	assert.Equal(t, uint64(5), o.tableSize)
+	WithTableSize(65536)(&o)
+	assert.Equal(t, uint64(65537), o.tableSize)
+
+	// a non-prime size set directly still builds a full table
+	b := &Balancer{opts: options{tableSize: 4}}
+	nodes := newNodes(100, 100)
+	b.Rebuild(nodes)
+	for _, e := range b.table.Load().entries {
+		assert.GreaterOrEqual(t, e, int32(0))
+	}
+	owners(t, b, nodes, 100)
+
+	b.Rebuild(nil)
+	assert.Empty(t, b.table.Load().entries)
+}
@@ -20,6 +20,10 @@ const (
 
 var _ selector.Balancer = &Balancer{}
 
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
 // New creates a p2c selector.
 func New() selector.Selector {
 	return NewBuilder().Build()
@@ -75,11 +79,12 @@ func (s *Balancer) Pick(ctx context.Context, nodes []selector.WeightedNode) (sel
 }
 
 // NewBuilder returns a selector builder with p2c balancer
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &ewma.Builder{},
-	}
+		Warmup:   selector.DefaultWarmup,
+	}, opts...)
 }
 
 // Builder is p2c builder
@@ -16,6 +16,10 @@ const (
 
 var _ selector.Balancer = (*Balancer)(nil) // Name is balancer name
 
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
 // Balancer is a random balancer.
 type Balancer struct{}
 
@@ -36,11 +40,11 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 }
 
 // NewBuilder returns a selector builder with random balancer
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
-	}
+	}, opts...)
 }
 
 // Builder is random builder
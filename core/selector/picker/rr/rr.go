@@ -18,6 +18,10 @@ const (
 
 var _ selector.Balancer = &Balancer{} // Name is balancer name
 
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
 // Balancer is a random balancer.
 type Balancer struct {
 	mu sync.Mutex
@@ -53,11 +57,11 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 type Builder struct{}
 
 // NewBuilder returns a selector builder with wrr balancer
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
-	}
+	}, opts...)
 }
 
 // Build creates Balancer
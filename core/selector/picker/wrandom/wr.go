@@ -17,6 +17,10 @@ const (
 
 var _ selector.Balancer = &Balancer{} // Name is balancer name
 
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
 type Balancer struct {
 	mu            sync.RWMutex
 	currentWeight map[string]wrange
@@ -78,11 +82,12 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 }
 
 // NewBuilder returns a selector builder with wrr balancer
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
-	}
+		Warmup:   selector.DefaultWarmup,
+	}, opts...)
 }
 
 // Builder is wrr builder
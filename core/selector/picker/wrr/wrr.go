@@ -16,6 +16,10 @@ const (
 
 var _ selector.Balancer = &Balancer{} // Name is balancer name
 
+func init() {
+	selector.Register(Name, NewBuilder())
+}
+
 // Option is random builder option.
 
 // Balancer is a random balancer.
@@ -59,11 +63,12 @@ func (p *Balancer) Pick(_ context.Context, nodes []selector.WeightedNode) (selec
 }
 
 // NewBuilder returns a selector builder with wrr balancer
-func NewBuilder() selector.Builder {
-	return &selector.DefaultBuilder{
+func NewBuilder(opts ...selector.BuilderOption) selector.Builder {
+	return selector.Configure(&selector.DefaultBuilder{
 		Balancer: &Builder{},
 		Node:     &direct.Builder{},
-	}
+		Warmup:   selector.DefaultWarmup,
+	}, opts...)
 }
 
 // Builder is wrr builder
@@ -0,0 +1,32 @@
+package trace
+
+import (
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ propagation.TextMapCarrier = MetadataCarrier{}
+
+// MetadataCarrier 让 gRPC metadata 作为链路上下文的载体。
+// metadata 的键都是小写，不能直接使用按 http 规范改写键名的 propagation.HeaderCarrier
+type MetadataCarrier metadata.MD
+
+func (c MetadataCarrier) Get(key string) string {
+	vals := metadata.MD(c).Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
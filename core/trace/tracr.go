@@ -73,8 +73,9 @@ func (t *Tracer) End(ctx context.Context, span trace.Span, err error) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "OK")
 	}
-	span.SetStatus(codes.Ok, "OK")
 
 	span.End()
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"net/url"
 	"time"
 
 	consulApi "github.com/hashicorp/consul/api"
@@ -68,14 +67,10 @@ func main() {
 		consulRegistry.WithHealthCheckInterval(10),
 	)
 
-	// 创建URL端点
-	restEndpoint, _ := url.Parse(fmt.Sprintf("http://%s", address1))
-	rpcEndpoint, _ := url.Parse(fmt.Sprintf("grpc://%s", address2))
-
 	var App = malt.New(
 		malt.WithId(uuid.New().String()),
 		malt.WithName("Malt"),
-		malt.WithEndpoints([]*url.URL{restEndpoint, rpcEndpoint}),
+		malt.WithTags([]string{"example"}),
 		malt.WithRegistrarTimeout(5*time.Second),
 		malt.WithStopTimeout(5*time.Second),
 		malt.WithServer(ServerSet...),
@@ -1,6 +1,7 @@
 package Malt
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"time"
@@ -24,9 +25,18 @@ type options struct {
 	registrar        registry.Registrar
 	registrarTimeout time.Duration
 	stopTimeout      time.Duration
+	drainDelay       time.Duration // 注销后等待的传播时间
+
+	adminAddress string // 运维端口监听地址，为空时不启动
 
 	server []malitServer.Server
 
+	// 生命周期钩子
+	beforeStart []func(context.Context) error
+	afterStart  []func(context.Context) error
+	beforeStop  []func(context.Context) error
+	afterStop   []func(context.Context) error
+
 	// restserver []restserver.Server
 	// rpcserver  []rpcserver.Server
 }
@@ -86,6 +96,20 @@ func WithStopTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithDrainDelay 注销服务后等待 delay 再停止服务器，让客户端有时间更新缓存
+func WithDrainDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.drainDelay = delay
+	}
+}
+
+// WithAdminAddress 启动应用级运维端口，提供健康检查、实例信息、pprof 和指标
+func WithAdminAddress(address string) Option {
+	return func(o *options) {
+		o.adminAddress = address
+	}
+}
+
 func WithLogger(logger log.Logger) Option {
 	return func(o *options) {
 		o.logger = logger
@@ -103,6 +127,34 @@ func WithServer(server ...malitServer.Server) Option {
 	}
 }
 
+// WithBeforeStart 在服务器启动之前执行，返回错误时终止启动
+func WithBeforeStart(fn ...func(context.Context) error) Option {
+	return func(o *options) {
+		o.beforeStart = append(o.beforeStart, fn...)
+	}
+}
+
+// WithAfterStart 在服务注册完成之后执行，返回错误时回滚注册并停止服务器
+func WithAfterStart(fn ...func(context.Context) error) Option {
+	return func(o *options) {
+		o.afterStart = append(o.afterStart, fn...)
+	}
+}
+
+// WithBeforeStop 在注销服务之前执行
+func WithBeforeStop(fn ...func(context.Context) error) Option {
+	return func(o *options) {
+		o.beforeStop = append(o.beforeStop, fn...)
+	}
+}
+
+// WithAfterStop 在所有服务器停止之后执行
+func WithAfterStop(fn ...func(context.Context) error) Option {
+	return func(o *options) {
+		o.afterStop = append(o.afterStop, fn...)
+	}
+}
+
 /*
 func WithRESTServer(restserver ...restserver.Server) Option {
 	return func(o *options) {
@@ -67,21 +67,33 @@ func (auth *Authenticator) RPCAuthenticate(ctx context.Context, userID, fullMeth
 
 // HTTPAuthenticate HTTP认证
 func (auth *Authenticator) HTTPAuthenticate(authHeader, userID, fullMethod, role string) error {
+	_, err := auth.AuthenticateHeader(authHeader, userID, fullMethod, role)
+	return err
+}
+
+// AuthenticateHeader 校验 "Bearer <token>" 形式的认证头，成功时返回解析出的 claims
+func (auth *Authenticator) AuthenticateHeader(authHeader, userID, fullMethod, role string) (*JWT.CustomClaims, error) {
 	tokenString, err := JWT.ParseTokenFromHTTPContext(authHeader)
 	if err != nil {
-		return errors.WithCode(code.ErrInvalidAuthHeader, "missing or invalid authorization token")
+		return nil, errors.WithCode(code.ErrInvalidAuthHeader, "missing or invalid authorization token")
 	}
-	return auth.validateToken(tokenString, userID, fullMethod, role)
+	return auth.parseToken(tokenString, userID, fullMethod, role)
 }
 
 // validateToken 验证token
 func (auth *Authenticator) validateToken(tokenString, userID, fullMethod, role string) error {
+	_, err := auth.parseToken(tokenString, userID, fullMethod, role)
+	return err
+}
+
+// parseToken 验证token并返回其中的claims
+func (auth *Authenticator) parseToken(tokenString, userID, fullMethod, role string) (*JWT.CustomClaims, error) {
 	// 如果启用缓存，先检查缓存
 	if auth.useCache && auth.cache != nil {
 		cacheKey := auth.generateCacheKey(tokenString, fullMethod)
 		if cached, found := auth.cache.Get(cacheKey); found {
-			if result, ok := cached.(bool); ok && result {
-				return nil // 缓存中存在且验证通过
+			if claims, ok := cached.(*JWT.CustomClaims); ok && checkClaims(claims, userID, fullMethod, role) == nil {
+				return claims, nil // 缓存中存在且验证通过
 			}
 			// 如果缓存中存在但验证失败，继续进行完整验证
 		}
@@ -97,14 +109,29 @@ func (auth *Authenticator) validateToken(tokenString, userID, fullMethod, role s
 			cacheKey := auth.generateCacheKey(tokenString, fullMethod)
 			auth.cache.Set(cacheKey, false)
 		}
-		return errors.WithCode(code.UserNoAuthority, "Invalid JWT token")
+		return nil, errors.WithCode(code.UserNoAuthority, "Invalid JWT token")
 	}
 
 	claims, ok := token.Claims.(*JWT.CustomClaims)
 	if !ok {
-		return errors.WithCode(code.UserNoAuthority, "invalid claims")
+		return nil, errors.WithCode(code.UserNoAuthority, "invalid claims")
+	}
+
+	if err = checkClaims(claims, userID, fullMethod, role); err != nil {
+		return nil, err
+	}
+
+	// 验证成功，如果使用缓存则缓存成功结果
+	if auth.useCache && auth.cache != nil {
+		cacheKey := auth.generateCacheKey(tokenString, fullMethod)
+		auth.cache.Set(cacheKey, claims)
 	}
 
+	return claims, nil
+}
+
+// checkClaims 校验claims中的方法、用户、角色以及有效期
+func checkClaims(claims *JWT.CustomClaims, userID, fullMethod, role string) error {
 	// FullMethod校验
 	if fullMethod != "" && claims.GetFullMethod() != fullMethod {
 		return errors.WithCode(code.UserNoAuthority, "Not permitted for this method")
@@ -131,12 +158,6 @@ func (auth *Authenticator) validateToken(tokenString, userID, fullMethod, role s
 		return errors.WithCode(code.UserNoAuthority, "the token is not yet valid (via nbf)")
 	}
 
-	// 验证成功，如果使用缓存则缓存成功结果
-	if auth.useCache && auth.cache != nil {
-		cacheKey := auth.generateCacheKey(tokenString, fullMethod)
-		auth.cache.Set(cacheKey, true)
-	}
-
 	return nil
 }
 
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"context"
+
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+)
+
+type claimsKey struct{}
+
+// NewContext returns a new Context that carries the authenticated claims.
+func NewContext(ctx context.Context, claims *JWT.CustomClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims stored in ctx, if any.
+// 服务可以通过它获取调用方的用户ID和角色
+func FromContext(ctx context.Context) (claims *JWT.CustomClaims, ok bool) {
+	claims, ok = ctx.Value(claimsKey{}).(*JWT.CustomClaims)
+	return
+}
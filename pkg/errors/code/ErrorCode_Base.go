@@ -88,3 +88,21 @@ const (
 	// ErrDecodingYAML - 500: YAML data could not be decoded.
 	ErrDecodingYAML
 )
+
+// common: service governance errors.
+const (
+	// ErrBreakerOpen - 503: Circuit breaker is open.
+	ErrBreakerOpen int = iota + 100501
+
+	// ErrServiceOverloaded - 503: Service is overloaded.
+	ErrServiceOverloaded
+
+	// ErrTooManyRequests - 429: Too many requests.
+	ErrTooManyRequests
+
+	// ErrServiceDraining - 503: Service is draining.
+	ErrServiceDraining
+
+	// ErrDeadlineExceeded - 504: Deadline exceeded.
+	ErrDeadlineExceeded
+)
@@ -11,7 +11,7 @@ import (
 	"github.com/novalagung/gubrak"
 )
 
-var IncludeErrCode = []int{200, 400, 401, 403, 404, 500}
+var IncludeErrCode = []int{200, 400, 401, 403, 404, 429, 500, 503, 504}
 
 type errCode struct {
 	// code 错误码
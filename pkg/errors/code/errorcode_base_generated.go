@@ -54,4 +54,14 @@ func init() {
 
 	register(ErrDecodingYAML, 500, "YAML data could not be decoded")
 
+	register(ErrBreakerOpen, 503, "Circuit breaker is open")
+
+	register(ErrServiceOverloaded, 503, "Service is overloaded")
+
+	register(ErrTooManyRequests, 429, "Too many requests")
+
+	register(ErrServiceDraining, 503, "Service is draining")
+
+	register(ErrDeadlineExceeded, 504, "Deadline exceeded")
+
 }
\ No newline at end of file
@@ -64,7 +64,7 @@ func main() {
 // It reads the source file, collects error codes, and writes the generated code.
 func generate(typeName string, outputFile string) {
 	// 构建输入文件路径
-	inputFile := fmt.Sprintf("pkg\\errors\\code\\%s.go", typeName)
+	inputFile := filepath.Join("pkg", "errors", "code", typeName+".go")
 
 	// 解析文件
 	f := ReadFile(inputFile)
@@ -105,6 +105,13 @@ func ZapLogger() *zap.Logger {
 	return std.Logger
 }
 
+// GetLevel returns the minimum enabled level of the std logger.
+func GetLevel() Level {
+	mu.Lock()
+	defer mu.Unlock()
+	return std.Logger.Level()
+}
+
 // CheckIntLevel used for other log wrapper such as klog which return if logging a
 // message at the specified level is enabled.
 func CheckIntLevel(level int32) bool {
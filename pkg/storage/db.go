@@ -13,12 +13,15 @@ import (
 )
 
 type SQLiteStorage struct {
+	gormStore
 	path string // 数据库文件路径
-	db   *gorm.DB
 }
 
-func NewSQLiteStorage(dbPath string, db *gorm.DB) *SQLiteStorage {
-	return &SQLiteStorage{path: dbPath, db: db}
+func NewSQLiteStorage(dbPath string, db *gorm.DB, opts ...Option) *SQLiteStorage {
+	s := &SQLiteStorage{path: dbPath}
+	s.db = db
+	s.asyncRecorder = newAsyncRecorder(&s.gormStore, opts...)
+	return s
 }
 
 // Init db and check the path and permission
@@ -61,54 +64,6 @@ func (s *SQLiteStorage) Init() error {
 	return nil
 }
 
-func (s *SQLiteStorage) HasTable(table any) (bool, error) {
-	if s.db == nil {
-		return false, errors.New("database not initialized")
-	}
-	return s.db.Migrator().HasTable(table), nil
-}
-
-func (s *SQLiteStorage) CreateTable(table any) error {
-	if s.db == nil {
-		return errors.New("database not initialized")
-	}
-	return s.db.AutoMigrate(table)
-}
-
-func (s *SQLiteStorage) Insert(record any) error {
-	if s.db == nil {
-		return errors.New("database not initialized")
-	}
-	return s.db.Create(record).Error
-}
-
-func (s *SQLiteStorage) Delete(record any) error {
-	if s.db == nil {
-		return errors.New("database not initialized")
-	}
-	return s.db.Delete(record).Error
-}
-
-/*
-	func (s *SQLiteStorage) Find(record any) error {
-		if s.db == nil {
-			return errors.New("database not initialized")
-		}
-		return s.db.First(record).Error
-	}
-*/
-
-func (s *SQLiteStorage) Close() error {
-	if s.db == nil {
-		return errors.New("database not initialized")
-	}
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		return errors.New("failed to close db")
-	}
-	return sqlDB.Close()
-}
-
 // ensureDir 确保目录存在
 func ensureDir(dir string) error {
 	info, err := os.Stat(dir)
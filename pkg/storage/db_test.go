@@ -5,20 +5,15 @@ import (
 
 	"github.com/taluos/Malt/pkg/storage/models"
 
-	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
-const testDBFile = "./test_rpc_calls.db"
-
-func cleanupTestDB() {
-	_ = os.Remove(testDBFile)
-}
+const testDBFile = "test_rpc_calls.db"
 
 func setupTestStorage(t *testing.T) *SQLiteStorage {
-	cleanupTestDB()
-	storage := NewSQLiteStorage(testDBFile, nil)
+	storage := NewSQLiteStorage(filepath.Join(t.TempDir(), testDBFile), nil)
 	return storage
 }
 
@@ -82,9 +77,9 @@ func TestSQLiteStorage_Insert(t *testing.T) {
 
 	// 插入一条记录，字段对齐你的结构体
 	record := models.RpcCallRecord{
-		Method: "test.service/Method",
-		// Request:   `{"input":"hello"}`,
-		// Response:  `{"output":"world"}`,
+		Method:    "test.service/Method",
+		Request:   `{"input":"hello"}`,
+		Response:  `{"output":"world"}`,
 		Duration:  120,
 		Error:     "there is no error",
 		Timestamp: time.Now(),
@@ -111,7 +106,7 @@ func TestSQLiteStorage_Insert(t *testing.T) {
 	}
 
 	// 简单验证下字段
-	if result.Method != record.Method {
+	if result.Method != record.Method || result.Request != record.Request || result.Response != record.Response {
 		t.Fatalf("inserted record not match: got %+v", result)
 	}
 }
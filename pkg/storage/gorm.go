@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// gormStore 是基于 gorm 的后端的公共实现，SQLite、MySQL 和 Postgres 共用建表、写入、查询和保留策略
+type gormStore struct {
+	asyncRecorder
+	db *gorm.DB
+}
+
+func (s *gormStore) HasTable(table any) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("database not initialized")
+	}
+	return s.db.Migrator().HasTable(table), nil
+}
+
+func (s *gormStore) CreateTable(table any) error {
+	if s.db == nil {
+		return errors.New("database not initialized")
+	}
+	return s.db.AutoMigrate(table)
+}
+
+func (s *gormStore) Insert(record any) error {
+	if s.db == nil {
+		return errors.New("database not initialized")
+	}
+	return s.db.Create(record).Error
+}
+
+func (s *gormStore) insertBatch(records []*models.RpcCallRecord) error {
+	if s.db == nil {
+		return errors.New("database not initialized")
+	}
+	return s.db.CreateInBatches(records, s.opts.batchSize).Error
+}
+
+func (s *gormStore) Delete(record any) error {
+	if s.db == nil {
+		return errors.New("database not initialized")
+	}
+	return s.db.Delete(record).Error
+}
+
+// Close 写入缓冲区中剩余的记录之后关闭数据库
+func (s *gormStore) Close() error {
+	s.stop()
+	if s.db == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return errors.New("failed to close db")
+	}
+	return sqlDB.Close()
+}
+
+// GormStorage 把调用记录写入任意 gorm 支持的数据库，如 MySQL、Postgres
+type GormStorage struct {
+	gormStore
+}
+
+// NewGormStorage 使用已经打开的 db 创建存储，需要调用 CreateTable 建表
+func NewGormStorage(db *gorm.DB, opts ...Option) *GormStorage {
+	s := &GormStorage{}
+	s.db = db
+	s.asyncRecorder = newAsyncRecorder(&s.gormStore, opts...)
+	return s
+}
+
+// NewMySQLStorage 连接 MySQL 并创建调用记录表
+func NewMySQLStorage(dsn string, opts ...Option) (*GormStorage, error) {
+	return openGormStorage(mysql.Open(dsn), opts...)
+}
+
+// NewPostgresStorage 连接 Postgres 并创建调用记录表
+func NewPostgresStorage(dsn string, opts ...Option) (*GormStorage, error) {
+	return openGormStorage(postgres.Open(dsn), opts...)
+}
+
+func openGormStorage(dialector gorm.Dialector, opts ...Option) (*GormStorage, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s db", dialector.Name())
+	}
+	s := NewGormStorage(db, opts...)
+	if err = s.CreateTable(&models.RpcCallRecord{}); err != nil {
+		_ = s.Close()
+		return nil, errors.Wrapf(err, "failed to create rpc call record table")
+	}
+	return s, nil
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestGormStorage(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "gorm.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	s := NewGormStorage(db, WithFlushInterval(time.Hour))
+	require.NoError(t, s.CreateTable(&models.RpcCallRecord{}))
+
+	for i := 0; i < 3; i++ {
+		s.Record(&models.RpcCallRecord{Method: "/test.Service/A", Duration: int64(i), Timestamp: time.Now()})
+	}
+	s.Flush()
+
+	stats, err := s.MethodLatencies(QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(3), stats[0].Count)
+
+	require.NoError(t, s.Close())
+	assert.False(t, s.Record(&models.RpcCallRecord{}))
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/storage/models"
+)
+
+// JSONLStorage 把调用记录以每行一个 JSON 对象的格式追加写入文件，不支持查询和保留策略，
+// 适合交给日志采集系统处理
+type JSONLStorage struct {
+	asyncRecorder
+
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// NewJSONLStorage 以追加模式打开 path，文件不存在时创建
+func NewJSONLStorage(path string, opts ...Option) (*JSONLStorage, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get absolute path")
+	}
+	if err = ensureDir(filepath.Dir(absPath)); err != nil {
+		return nil, errors.Wrapf(err, "failed to ensure directory")
+	}
+	f, err := os.OpenFile(absPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open jsonl file")
+	}
+
+	s := &JSONLStorage{file: f, buf: bufio.NewWriter(f)}
+	s.asyncRecorder = newAsyncRecorder(s, opts...)
+	return s, nil
+}
+
+func (s *JSONLStorage) insertBatch(records []*models.RpcCallRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("jsonl file closed")
+	}
+
+	enc := json.NewEncoder(s.buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return s.buf.Flush()
+}
+
+// Close 写入缓冲区中剩余的记录之后关闭文件
+func (s *JSONLStorage) Close() error {
+	s.stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.buf.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
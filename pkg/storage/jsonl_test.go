@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readJSONL(t *testing.T, path string) []models.RpcCallRecord {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []models.RpcCallRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record models.RpcCallRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestJSONLStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records", "calls.jsonl")
+	s, err := NewJSONLStorage(path, WithFlushInterval(time.Hour))
+	require.NoError(t, err)
+
+	s.Record(&models.RpcCallRecord{Protocol: "grpc", Method: "/test.Service/A", Code: 5, Timestamp: time.Now()})
+	s.Flush()
+	records := readJSONL(t, path)
+	require.Len(t, records, 1)
+	assert.Equal(t, "/test.Service/A", records[0].Method)
+	assert.Equal(t, uint32(5), records[0].Code)
+
+	s.Record(&models.RpcCallRecord{Protocol: "http", Method: "GET /users/:id"})
+	require.NoError(t, s.Close())
+	assert.False(t, s.Record(&models.RpcCallRecord{}))
+
+	// 重新打开时追加写入
+	s, err = NewJSONLStorage(path)
+	require.NoError(t, err)
+	s.Record(&models.RpcCallRecord{Method: "/test.Service/B"})
+	require.NoError(t, s.Close())
+
+	records = readJSONL(t, path)
+	require.Len(t, records, 3)
+	assert.Equal(t, "GET /users/:id", records[1].Method)
+	assert.Equal(t, "/test.Service/B", records[2].Method)
+}
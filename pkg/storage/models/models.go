@@ -6,14 +6,21 @@ import (
 	"gorm.io/gorm"
 )
 
-// RpcCallRecord 定义一条 gRPC 调用的记录
+// RpcCallRecord 定义一条 gRPC 或 REST 调用的记录
 type RpcCallRecord struct {
-	ID     uint   `gorm:"primaryKey;autoIncrement"`
-	Method string `gorm:"size:255;index"` // 方法名
-	// Request   string         `gorm:"type:text"`      // 请求内容 (json序列化)
-	// Response  string         `gorm:"type:text"`      // 响应内容 (json序列化)
-	Duration  int64          // 耗时，单位：毫秒
-	Error     string         `gorm:"type:text"` // 错误信息
-	Timestamp time.Time      `gorm:"index"`     // 调用时间
-	DeletedAt gorm.DeletedAt `gorm:"index"`     // 软删除支持
+	ID           uint           `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Protocol     string         `gorm:"size:16;index" json:"protocol"`           // 调用协议，grpc 或 http
+	Method       string         `gorm:"size:255;index" json:"method"`            // 方法名，REST 调用为 "GET /users/:id"
+	Request      string         `gorm:"type:text" json:"request,omitempty"`      // 请求内容 (json序列化)，未开启采集时为空
+	Response     string         `gorm:"type:text" json:"response,omitempty"`     // 响应内容 (json序列化)，未开启采集时为空
+	Peer         string         `gorm:"size:64" json:"peer,omitempty"`           // 调用方地址
+	TraceID      string         `gorm:"size:32;index" json:"trace_id,omitempty"` // 链路追踪 ID
+	Code         uint32         `gorm:"index" json:"code"`                       // gRPC 状态码，REST 调用为 HTTP 状态码
+	Duration     int64          `json:"duration"`                                // 耗时，单位：毫秒
+	Error        string         `gorm:"type:text" json:"error,omitempty"`        // 错误信息
+	Stream       bool           `json:"stream,omitempty"`                        // 是否为流式调用
+	SentMessages int64          `json:"sent_messages,omitempty"`                 // 流式调用发送的消息数
+	RecvMessages int64          `json:"recv_messages,omitempty"`                 // 流式调用接收的消息数
+	Timestamp    time.Time      `gorm:"index" json:"timestamp"`                  // 调用时间
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`                          // 软删除支持
 }
@@ -0,0 +1,95 @@
+package storage
+
+import "time"
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 1024
+	defaultRetentionTick = time.Hour
+)
+
+// Backpressure 是缓冲区满时的处理策略
+type Backpressure int
+
+const (
+	// BackpressureDrop 缓冲区满时丢弃新记录，不阻塞请求，默认策略
+	BackpressureDrop Backpressure = iota
+	// BackpressureBlock 缓冲区满时等待空位，设置了等待超时时超时后丢弃
+	BackpressureBlock
+)
+
+type options struct {
+	batchSize     int           // 单次批量写入的最大记录数
+	flushInterval time.Duration // 未攒满一批时的最长等待时间
+	queueSize     int           // 异步写入队列的长度
+	backpressure  Backpressure  // 队列满时的处理策略
+	blockTimeout  time.Duration // BackpressureBlock 的最长等待时间，0 表示一直等待
+
+	maxAge         time.Duration // 记录的最长保留时间，0 表示不限制
+	maxRecords     int           // 最多保留的记录数，0 表示不限制
+	retentionCheck time.Duration // 执行保留策略的间隔
+}
+
+type Option func(*options)
+
+func defaultOptions() options {
+	return options{
+		batchSize:      defaultBatchSize,
+		flushInterval:  defaultFlushInterval,
+		queueSize:      defaultQueueSize,
+		retentionCheck: defaultRetentionTick,
+	}
+}
+
+// WithBatchSize 设置单次批量写入的最大记录数
+func WithBatchSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// WithFlushInterval 设置异步写入的刷新间隔，队列中的记录最多等待该时间后写入
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.flushInterval = interval
+		}
+	}
+}
+
+// WithQueueSize 设置异步写入队列的长度
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.queueSize = size
+		}
+	}
+}
+
+// WithBackpressure 设置缓冲区满时的处理策略，timeout 为 BackpressureBlock 的最长等待时间，0 表示一直等待
+func WithBackpressure(policy Backpressure, timeout time.Duration) Option {
+	return func(o *options) {
+		o.backpressure = policy
+		o.blockTimeout = timeout
+	}
+}
+
+// WithRetention 设置记录的保留策略，超过 maxAge 或超出 maxRecords 条的旧记录会被定期删除，为 0 时不限制
+func WithRetention(maxAge time.Duration, maxRecords int) Option {
+	return func(o *options) {
+		o.maxAge = maxAge
+		o.maxRecords = maxRecords
+	}
+}
+
+// WithRetentionInterval 设置执行保留策略的间隔，默认一小时
+func WithRetentionInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.retentionCheck = interval
+		}
+	}
+}
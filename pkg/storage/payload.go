@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultMaxPayloadSize = 4096
+
+	redactedValue  = "[REDACTED]"
+	truncatedMark  = "...(truncated)"
+	marshalErrMark = "<unmarshalable payload>"
+)
+
+type captureOptions struct {
+	maxSize int
+	redact  map[string]struct{}
+}
+
+type CaptureOption func(*captureOptions)
+
+// WithMaxPayloadSize 设置单个请求或响应 JSON 的最大字节数，超出部分被截断，默认 4KB
+func WithMaxPayloadSize(size int) CaptureOption {
+	return func(o *captureOptions) {
+		if size > 0 {
+			o.maxSize = size
+		}
+	}
+}
+
+// WithRedactFields 设置需要脱敏的字段名，protobuf 消息匹配 proto 字段名（如 card_number），其他类型匹配 JSON 键名，任意嵌套层级生效
+func WithRedactFields(fields ...string) CaptureOption {
+	return func(o *captureOptions) {
+		for _, f := range fields {
+			o.redact[f] = struct{}{}
+		}
+	}
+}
+
+// Capture 把请求和响应编码为 JSON 写入调用记录，protobuf 消息使用 protojson 编码
+type Capture struct {
+	opts captureOptions
+}
+
+func NewCapture(opts ...CaptureOption) *Capture {
+	o := captureOptions{
+		maxSize: defaultMaxPayloadSize,
+		redact:  make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Capture{opts: o}
+}
+
+// Encode 把 msg 编码为脱敏、截断之后的 JSON，msg 为空时返回空字符串。
+// 截断之后的内容不再是合法的 JSON
+func (c *Capture) Encode(msg any) string {
+	if msg == nil {
+		return ""
+	}
+
+	var data []byte
+	var err error
+	if m, ok := msg.(proto.Message); ok {
+		data, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	} else {
+		data, err = json.Marshal(msg)
+	}
+	if err != nil {
+		return marshalErrMark
+	}
+
+	if len(c.opts.redact) > 0 {
+		if data, err = c.redact(data); err != nil {
+			return marshalErrMark
+		}
+	}
+	return truncate(data, c.opts.maxSize)
+}
+
+func (c *Capture) redact(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// 保留数字的原始形式，避免大整数丢失精度
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(c.redactValue(v))
+}
+
+func (c *Capture) redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, field := range val {
+			if _, ok := c.opts.redact[k]; ok {
+				val[k] = redactedValue
+			} else {
+				val[k] = c.redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = c.redactValue(item)
+		}
+	}
+	return v
+}
+
+// truncate 在不超过 maxSize 字节的 UTF-8 字符边界处截断
+func truncate(data []byte, maxSize int) string {
+	if len(data) <= maxSize {
+		return string(data)
+	}
+	cut := maxSize
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return string(data[:cut]) + truncatedMark
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCaptureEncode(t *testing.T) {
+	c := NewCapture(WithRedactFields("password", "token"))
+
+	assert.Empty(t, c.Encode(nil))
+
+	// protobuf 消息使用 protojson 编码，嵌套字段同样脱敏
+	msg, err := structpb.NewStruct(map[string]any{
+		"user":     "alice",
+		"password": "secret",
+		"sessions": []any{map[string]any{"token": "abc", "id": 1}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]","sessions":[{"token":"[REDACTED]","id":1}]}`, c.Encode(msg))
+
+	// 其他类型使用 encoding/json 编码
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+		ID       int64  `json:"id"`
+	}
+	assert.JSONEq(t, `{"user":"bob","password":"[REDACTED]","id":9007199254740993}`,
+		c.Encode(login{User: "bob", Password: "123", ID: 9007199254740993}))
+
+	assert.Equal(t, marshalErrMark, c.Encode(make(chan int)))
+}
+
+func TestCaptureTruncate(t *testing.T) {
+	c := NewCapture(WithMaxPayloadSize(10))
+
+	assert.Equal(t, `"short"`, c.Encode("short"))
+
+	out := c.Encode(strings.Repeat("中", 10))
+	assert.True(t, strings.HasSuffix(out, truncatedMark))
+	body := strings.TrimSuffix(out, truncatedMark)
+	assert.LessOrEqual(t, len(body), 10)
+	assert.True(t, utf8.ValidString(body))
+}
@@ -1,13 +1,21 @@
 package storage
 
 import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/taluos/Malt/pkg/errors"
 	"github.com/taluos/Malt/pkg/storage/models"
+
+	"gorm.io/gorm"
 )
 
 type QueryOptions struct {
 	Method    string
+	TraceID   string
 	HasError  *bool
 	StartTime *time.Time
 	EndTime   *time.Time
@@ -16,12 +24,149 @@ type QueryOptions struct {
 	OrderBy   string
 }
 
-func (s *SQLiteStorage) QueryRpcCallRecords(opts QueryOptions) ([]models.RpcCallRecord, error) {
+// MethodStats 是一个方法在查询范围内的调用统计，耗时单位为毫秒
+type MethodStats struct {
+	Method string
+	Count  int64
+	Errors int64
+	P50    int64
+	P95    int64
+	P99    int64
+}
+
+// ErrorRateBucket 是一个时间桶内的调用数和错误率
+type ErrorRateBucket struct {
+	Start     time.Time
+	Count     int64
+	Errors    int64
+	ErrorRate float64
+}
+
+func (s *gormStore) QueryRpcCallRecords(opts QueryOptions) ([]models.RpcCallRecord, error) {
+	if s.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	db := s.filter(opts)
+
+	if opts.OrderBy != "" {
+		db = db.Order(opts.OrderBy)
+	} else {
+		db = db.Order("timestamp desc")
+	}
+	if opts.Limit > 0 {
+		db = db.Limit(opts.Limit)
+	}
+	if opts.Offset > 0 {
+		db = db.Offset(opts.Offset)
+	}
+
+	var records []models.RpcCallRecord
+	err := db.Find(&records).Error
+	return records, err
+}
+
+// latencySampleLimit 计算分位数时每个方法最多读取的调用数
+const latencySampleLimit = 10000
+
+// errorCountExpr 统计出错调用数的 SQL 表达式
+const errorCountExpr = "SUM(CASE WHEN error <> '' THEN 1 ELSE 0 END)"
+
+// MethodLatencies 按方法统计调用数、错误数和 p50/p95/p99 耗时，忽略 opts 中的分页和排序。
+// 调用数和错误数在数据库中聚合；分位数只使用每个方法最近的 10000 次调用计算，
+// 避免一次读出整张表，需要精确的分位数时通过 StartTime 和 EndTime 缩小范围
+func (s *gormStore) MethodLatencies(opts QueryOptions) ([]MethodStats, error) {
+	if s.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+
+	var stats []MethodStats
+	err := s.filter(opts).
+		Select("method, COUNT(*) AS count, " + errorCountExpr + " AS errors").
+		Group("method").Order("method").Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		var durations []int64
+		err = s.filter(opts).Where("method = ?", stats[i].Method).
+			Order("timestamp desc").Limit(latencySampleLimit).Pluck("duration", &durations).Error
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+		stats[i].P50 = percentile(durations, 0.50)
+		stats[i].P95 = percentile(durations, 0.95)
+		stats[i].P99 = percentile(durations, 0.99)
+	}
+	return stats, nil
+}
+
+// ErrorRates 把调用按 bucket 长度划分时间桶，统计每个桶的错误率，没有调用的桶不返回。
+// 分桶和统计在数据库中完成，bucket 必须是整数秒
+func (s *gormStore) ErrorRates(opts QueryOptions, bucket time.Duration) ([]ErrorRateBucket, error) {
+	if s.db == nil {
+		return nil, errors.New("database not initialized")
+	}
+	if bucket <= 0 || bucket%time.Second != 0 {
+		return nil, errors.New("bucket must be a positive whole number of seconds")
+	}
+	expr, err := s.bucketExpr(int64(bucket / time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Bucket int64
+		Count  int64
+		Errors int64
+	}
+	err = s.filter(opts).
+		Select(expr + " AS bucket, COUNT(*) AS count, " + errorCountExpr + " AS errors").
+		Group("bucket").Order("bucket").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ErrorRateBucket, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, ErrorRateBucket{
+			Start:     time.Unix(row.Bucket, 0),
+			Count:     row.Count,
+			Errors:    row.Errors,
+			ErrorRate: float64(row.Errors) / float64(row.Count),
+		})
+	}
+	return result, nil
+}
+
+// bucketExpr 返回把调用时间按 seconds 秒截断为 Unix 时间戳的 SQL 表达式
+func (s *gormStore) bucketExpr(seconds int64) (string, error) {
+	var b strings.Builder
+	s.db.Dialector.QuoteTo(&b, "timestamp")
+	column, n := b.String(), strconv.FormatInt(seconds, 10)
+
+	switch s.db.Dialector.Name() {
+	case "sqlite":
+		return "(CAST(strftime('%s', " + column + ") AS INTEGER) / " + n + ") * " + n, nil
+	case "mysql":
+		return "(FLOOR(UNIX_TIMESTAMP(" + column + ")) DIV " + n + ") * " + n, nil
+	case "postgres":
+		return "CAST(FLOOR(EXTRACT(EPOCH FROM " + column + ") / " + n + ") * " + n + " AS BIGINT)", nil
+	default:
+		return "", errors.Errorf("error rates are not supported on %s", s.db.Dialector.Name())
+	}
+}
+
+// filter 按 opts 中的条件构造查询
+func (s *gormStore) filter(opts QueryOptions) *gorm.DB {
 	db := s.db.Model(&models.RpcCallRecord{})
 
 	if opts.Method != "" {
-		// db = db.Where("method = ?", opts.Method)
-		db = db.Model(models.RpcCallRecord{Method: opts.Method})
+		db = db.Where("method = ?", opts.Method)
+	}
+	if opts.TraceID != "" {
+		db = db.Where("trace_id = ?", opts.TraceID)
 	}
 	if opts.HasError != nil {
 		if *opts.HasError {
@@ -36,19 +181,20 @@ func (s *SQLiteStorage) QueryRpcCallRecords(opts QueryOptions) ([]models.RpcCall
 	if opts.EndTime != nil {
 		db = db.Where("timestamp <= ?", *opts.EndTime)
 	}
-	if opts.OrderBy != "" {
-		db = db.Order(opts.OrderBy)
-	} else {
-		db = db.Order("timestamp desc")
+	return db
+}
+
+// percentile 使用最近秩法计算已排序数据的分位数
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
 	}
-	if opts.Limit > 0 {
-		db = db.Limit(opts.Limit)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
 	}
-	if opts.Offset > 0 {
-		db = db.Offset(opts.Offset)
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
 	}
-
-	var records []models.RpcCallRecord
-	err := db.Find(&records).Error
-	return records, err
+	return sorted[rank]
 }
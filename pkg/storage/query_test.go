@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodLatencies(t *testing.T) {
+	s := newTempStorage(t)
+	defer s.Close()
+
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		record := models.RpcCallRecord{Method: "/test.Service/A", Duration: int64(i), Timestamp: now}
+		if i%10 == 0 {
+			record.Error = "failed"
+		}
+		require.NoError(t, s.Insert(&record))
+	}
+	require.NoError(t, s.Insert(&models.RpcCallRecord{Method: "/test.Service/B", Duration: 7, Timestamp: now}))
+
+	stats, err := s.MethodLatencies(QueryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []MethodStats{
+		{Method: "/test.Service/A", Count: 100, Errors: 10, P50: 50, P95: 95, P99: 99},
+		{Method: "/test.Service/B", Count: 1, P50: 7, P95: 7, P99: 7},
+	}, stats)
+
+	stats, err = s.MethodLatencies(QueryOptions{Method: "/test.Service/B"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "/test.Service/B", stats[0].Method)
+}
+
+func TestErrorRates(t *testing.T) {
+	s := newTempStorage(t)
+	defer s.Close()
+
+	start := time.Now().Truncate(time.Minute)
+	insert := func(offset time.Duration, errMsg string) {
+		require.NoError(t, s.Insert(&models.RpcCallRecord{Method: "/test.Service/A", Error: errMsg, Timestamp: start.Add(offset)}))
+	}
+	insert(time.Second, "")
+	insert(2*time.Second, "failed")
+	insert(time.Minute+time.Second, "")
+	insert(3*time.Minute, "failed")
+
+	buckets, err := s.ErrorRates(QueryOptions{}, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+
+	assert.True(t, buckets[0].Start.Equal(start))
+	assert.Equal(t, int64(2), buckets[0].Count)
+	assert.Equal(t, 0.5, buckets[0].ErrorRate)
+	assert.Equal(t, 0.0, buckets[1].ErrorRate)
+	assert.True(t, buckets[2].Start.Equal(start.Add(3*time.Minute)))
+	assert.Equal(t, 1.0, buckets[2].ErrorRate)
+
+	_, err = s.ErrorRates(QueryOptions{}, 0)
+	assert.Error(t, err)
+	// 时间桶在数据库中按秒计算
+	_, err = s.ErrorRates(QueryOptions{}, 500*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestPurge(t *testing.T) {
+	s := newTempStorage(t)
+	defer s.Close()
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Insert(&models.RpcCallRecord{Method: "/test.Service/Old", Timestamp: now.Add(-2 * time.Hour)}))
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Insert(&models.RpcCallRecord{Method: "/test.Service/New", Timestamp: now}))
+	}
+
+	deleted, err := s.Purge(time.Hour, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+
+	// 只保留最新的 3 条
+	deleted, err = s.Purge(0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	assert.Equal(t, 3, countRecords(t, s))
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	s := newTempStorage(t, WithRetention(0, 2), WithRetentionInterval(10*time.Millisecond), WithFlushInterval(time.Hour))
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		s.Record(&models.RpcCallRecord{Method: "/test.Service/A", Timestamp: time.Now()})
+	}
+	s.Flush()
+	assert.Eventually(t, func() bool { return countRecords(t, s) == 2 }, time.Second, 10*time.Millisecond)
+}
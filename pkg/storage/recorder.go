@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage/models"
+)
+
+// Recorder 保存 gRPC 和 REST 的调用记录。记录先放入有界缓冲区，由后台 goroutine 批量写入后端，
+// 缓冲区满时按背压策略丢弃记录或等待，慢速的后端不会拖慢请求处理
+type Recorder interface {
+	// Record 把记录放入缓冲区，记录被丢弃时返回 false
+	Record(record *models.RpcCallRecord) bool
+	// Flush 同步写入缓冲区中已有的记录
+	Flush()
+	// Close 写入缓冲区中剩余的记录之后关闭后端
+	Close() error
+}
+
+var (
+	_ Recorder = (*SQLiteStorage)(nil)
+	_ Recorder = (*GormStorage)(nil)
+	_ Recorder = (*JSONLStorage)(nil)
+)
+
+// batchSink 是后端的批量写入接口
+type batchSink interface {
+	insertBatch(records []*models.RpcCallRecord) error
+}
+
+// purger 是支持保留策略的后端
+type purger interface {
+	Purge(maxAge time.Duration, maxRecords int) (int64, error)
+}
+
+// asyncRecorder 实现 Recorder 的缓冲和背压，后端嵌入它之后只需要实现 batchSink
+type asyncRecorder struct {
+	sink batchSink
+	opts options
+
+	writerMu sync.Mutex
+	writer   atomic.Pointer[batchWriter] // 异步批量写入器，第一次调用 Record 时启动
+	closed   bool
+}
+
+func newAsyncRecorder(sink batchSink, opts ...Option) asyncRecorder {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return asyncRecorder{sink: sink, opts: o}
+}
+
+func (r *asyncRecorder) Record(record *models.RpcCallRecord) bool {
+	w := r.writer.Load()
+	if w == nil {
+		r.writerMu.Lock()
+		if w = r.writer.Load(); w == nil && !r.closed {
+			w = newBatchWriter(r.sink, r.opts)
+			r.writer.Store(w)
+		}
+		r.writerMu.Unlock()
+		if w == nil {
+			return false
+		}
+	}
+	return w.write(record)
+}
+
+func (r *asyncRecorder) Flush() {
+	if w := r.writer.Load(); w != nil {
+		w.flush()
+	}
+}
+
+// Dropped 返回因为缓冲区已满或已关闭而丢弃的记录数
+func (r *asyncRecorder) Dropped() int64 {
+	if w := r.writer.Load(); w != nil {
+		return w.dropped.Load()
+	}
+	return 0
+}
+
+// stop 停止接收新记录，并等待缓冲区中的记录写入完成
+func (r *asyncRecorder) stop() {
+	r.writerMu.Lock()
+	r.closed = true
+	r.writerMu.Unlock()
+	if w := r.writer.Load(); w != nil {
+		w.close()
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/storage/models"
+)
+
+// Purge 物理删除早于 maxAge 的记录，并只保留最新的 maxRecords 条，参数为 0 时不限制。
+// 返回删除的记录数
+func (s *gormStore) Purge(maxAge time.Duration, maxRecords int) (int64, error) {
+	if s.db == nil {
+		return 0, errors.New("database not initialized")
+	}
+
+	var deleted int64
+	if maxAge > 0 {
+		res := s.db.Unscoped().Where("timestamp < ?", time.Now().Add(-maxAge)).Delete(&models.RpcCallRecord{})
+		if res.Error != nil {
+			return deleted, errors.Wrapf(res.Error, "failed to purge expired records")
+		}
+		deleted += res.RowsAffected
+	}
+
+	if maxRecords > 0 {
+		// 找到第 maxRecords 新的记录，删除比它更旧的记录
+		var ids []uint
+		err := s.db.Unscoped().Model(&models.RpcCallRecord{}).
+			Order("id desc").Offset(maxRecords-1).Limit(1).Pluck("id", &ids).Error
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to find oldest retained record")
+		}
+		if len(ids) > 0 {
+			res := s.db.Unscoped().Where("id < ?", ids[0]).Delete(&models.RpcCallRecord{})
+			if res.Error != nil {
+				return deleted, errors.Wrapf(res.Error, "failed to purge overflow records")
+			}
+			deleted += res.RowsAffected
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taluos/Malt/pkg/log"
+	"github.com/taluos/Malt/pkg/storage/models"
+)
+
+// batchWriter 在单个 goroutine 中批量写入调用记录，攒满 batchSize 条或到达 flushInterval 时写入一次
+type batchWriter struct {
+	sink batchSink
+	opts options
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan *models.RpcCallRecord
+	flushes chan chan struct{}
+	stopped chan struct{}
+
+	dropped atomic.Int64
+}
+
+func newBatchWriter(sink batchSink, opts options) *batchWriter {
+	w := &batchWriter{
+		sink:    sink,
+		opts:    opts,
+		records: make(chan *models.RpcCallRecord, opts.queueSize),
+		flushes: make(chan chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// write 把记录放入队列，写入器已关闭，或者按背压策略放弃等待时丢弃记录并返回 false
+func (w *batchWriter) write(record *models.RpcCallRecord) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case w.records <- record:
+		return true
+	default:
+	}
+	if w.opts.backpressure == BackpressureBlock {
+		if w.opts.blockTimeout <= 0 {
+			w.records <- record
+			return true
+		}
+		timer := time.NewTimer(w.opts.blockTimeout)
+		defer timer.Stop()
+		select {
+		case w.records <- record:
+			return true
+		case <-timer.C:
+		}
+	}
+
+	// 只在第一次和之后每 1000 次丢弃时打印日志
+	if n := w.dropped.Add(1); n == 1 || n%1000 == 0 {
+		log.Warnf("[Storage] call record queue is full, dropped %d records", n)
+	}
+	return false
+}
+
+// flush 同步写入调用 flush 之前已经进入队列的记录
+func (w *batchWriter) flush() {
+	done := make(chan struct{})
+	select {
+	case w.flushes <- done:
+		<-done
+	case <-w.stopped:
+	}
+}
+
+// close 停止接收新记录，写入队列中剩余的记录之后返回
+func (w *batchWriter) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.records)
+	}
+	w.mu.Unlock()
+	<-w.stopped
+}
+
+func (w *batchWriter) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.opts.flushInterval)
+	defer ticker.Stop()
+
+	var retention <-chan time.Time
+	p, canPurge := w.sink.(purger)
+	if canPurge && (w.opts.maxAge > 0 || w.opts.maxRecords > 0) {
+		t := time.NewTicker(w.opts.retentionCheck)
+		defer t.Stop()
+		retention = t.C
+	}
+
+	batch := make([]*models.RpcCallRecord, 0, w.opts.batchSize)
+	save := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.sink.insertBatch(batch); err != nil {
+			log.Errorf("[Storage] save %d rpc call records error: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record, ok := <-w.records:
+			if !ok {
+				save()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= w.opts.batchSize {
+				save()
+			}
+		case done := <-w.flushes:
+			// 取出 flush 之前已经进入队列的记录
+			for n := len(w.records); n > 0; n-- {
+				record, ok := <-w.records
+				if !ok {
+					break
+				}
+				batch = append(batch, record)
+			}
+			save()
+			close(done)
+		case <-ticker.C:
+			save()
+		case <-retention:
+			if _, err := p.Purge(w.opts.maxAge, w.opts.maxRecords); err != nil {
+				log.Errorf("[Storage] purge rpc call records error: %v", err)
+			}
+		}
+	}
+}
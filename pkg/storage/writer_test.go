@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTempStorage(t *testing.T, opts ...Option) *SQLiteStorage {
+	s := NewSQLiteStorage(filepath.Join(t.TempDir(), "rpc_calls.db"), nil, opts...)
+	require.NoError(t, s.Init())
+	require.NoError(t, s.CreateTable(&models.RpcCallRecord{}))
+	return s
+}
+
+func countRecords(t *testing.T, s *SQLiteStorage) int {
+	records, err := s.QueryRpcCallRecords(QueryOptions{})
+	require.NoError(t, err)
+	return len(records)
+}
+
+func TestRecord(t *testing.T) {
+	s := newTempStorage(t, WithBatchSize(10), WithFlushInterval(time.Hour))
+	defer s.Close()
+
+	// 攒满一批之后立即写入
+	for i := 0; i < 10; i++ {
+		assert.True(t, s.Record(&models.RpcCallRecord{Method: "/test.Service/Batch", Timestamp: time.Now()}))
+	}
+	assert.Eventually(t, func() bool { return countRecords(t, s) == 10 }, time.Second, 10*time.Millisecond)
+
+	// 不足一批的记录在 Flush 时写入
+	for i := 0; i < 3; i++ {
+		s.Record(&models.RpcCallRecord{Method: "/test.Service/Batch", Timestamp: time.Now()})
+	}
+	s.Flush()
+	assert.Equal(t, 13, countRecords(t, s))
+}
+
+func TestRecordFlushInterval(t *testing.T) {
+	s := newTempStorage(t, WithFlushInterval(10*time.Millisecond))
+	defer s.Close()
+
+	s.Record(&models.RpcCallRecord{Method: "/test.Service/Tick", Timestamp: time.Now()})
+	assert.Eventually(t, func() bool { return countRecords(t, s) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestCloseFlushesRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rpc_calls.db")
+	s := NewSQLiteStorage(path, nil, WithFlushInterval(time.Hour))
+	require.NoError(t, s.Init())
+	require.NoError(t, s.CreateTable(&models.RpcCallRecord{}))
+
+	for i := 0; i < 5; i++ {
+		s.Record(&models.RpcCallRecord{Method: "/test.Service/Close", Timestamp: time.Now()})
+	}
+	require.NoError(t, s.Close())
+
+	// 关闭之后的记录被丢弃
+	assert.False(t, s.Record(&models.RpcCallRecord{Method: "/test.Service/Close"}))
+	assert.Equal(t, int64(1), s.Dropped())
+
+	reopened := NewSQLiteStorage(path, nil)
+	require.NoError(t, reopened.Init())
+	defer reopened.Close()
+	assert.Equal(t, 5, countRecords(t, reopened))
+}
+
+// blockingSink 在 release 关闭之前阻塞写入，用于模拟慢速的后端
+type blockingSink struct {
+	release chan struct{}
+	written atomic.Int64
+}
+
+func (s *blockingSink) insertBatch(records []*models.RpcCallRecord) error {
+	<-s.release
+	s.written.Add(int64(len(records)))
+	return nil
+}
+
+// fillQueue 让写入器阻塞在第一条记录上，并填满长度为 1 的队列
+func fillQueue(t *testing.T, r *asyncRecorder) {
+	require.True(t, r.Record(&models.RpcCallRecord{}))
+	assert.Eventually(t, func() bool { return len(r.writer.Load().records) == 0 }, time.Second, time.Millisecond)
+	require.True(t, r.Record(&models.RpcCallRecord{}))
+}
+
+func TestBackpressureDrop(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	r := newAsyncRecorder(sink, WithQueueSize(1), WithBatchSize(1))
+	fillQueue(t, &r)
+
+	// 后端阻塞时不等待，直接丢弃
+	assert.False(t, r.Record(&models.RpcCallRecord{}))
+	assert.Equal(t, int64(1), r.Dropped())
+
+	close(sink.release)
+	r.stop()
+	assert.Equal(t, int64(2), sink.written.Load())
+}
+
+func TestBackpressureBlock(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	r := newAsyncRecorder(sink, WithQueueSize(1), WithBatchSize(1), WithBackpressure(BackpressureBlock, 0))
+	fillQueue(t, &r)
+
+	done := make(chan bool)
+	go func() { done <- r.Record(&models.RpcCallRecord{}) }()
+	select {
+	case <-done:
+		t.Fatal("record should block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(sink.release)
+	assert.True(t, <-done)
+	r.stop()
+	assert.Equal(t, int64(3), sink.written.Load())
+}
+
+func TestBackpressureBlockTimeout(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	r := newAsyncRecorder(sink, WithQueueSize(1), WithBatchSize(1), WithBackpressure(BackpressureBlock, 10*time.Millisecond))
+	fillQueue(t, &r)
+
+	start := time.Now()
+	assert.False(t, r.Record(&models.RpcCallRecord{}))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	assert.Equal(t, int64(1), r.Dropped())
+
+	close(sink.release)
+	r.stop()
+}
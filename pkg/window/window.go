@@ -0,0 +1,113 @@
+package window
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket 是滑动窗口中的一个桶，Sum 为累加值，Count 为写入次数
+type Bucket struct {
+	Sum   float64
+	Count int64
+}
+
+func (b *Bucket) add(v float64) {
+	b.Sum += v
+	b.Count++
+}
+
+func (b *Bucket) reset() {
+	b.Sum = 0
+	b.Count = 0
+}
+
+// RollingWindow 基于时间的滑动窗口，由 size 个时长为 interval 的桶组成
+type RollingWindow struct {
+	mu       sync.RWMutex
+	size     int
+	buckets  []Bucket
+	interval time.Duration
+	offset   int
+	lastTime time.Time
+}
+
+// NewRollingWindow 创建滑动窗口，窗口总时长为 size*interval
+func NewRollingWindow(size int, interval time.Duration) *RollingWindow {
+	if size < 1 {
+		panic("window: size must be greater than 0")
+	}
+	if interval <= 0 {
+		panic("window: interval must be greater than 0")
+	}
+
+	return &RollingWindow{
+		size:     size,
+		buckets:  make([]Bucket, size),
+		interval: interval,
+		lastTime: time.Now(),
+	}
+}
+
+// Add 向当前桶写入 v
+func (rw *RollingWindow) Add(v float64) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.updateOffset()
+	rw.buckets[rw.offset].add(v)
+}
+
+// Reduce 依次处理窗口内所有未过期的桶，从最旧的桶开始
+func (rw *RollingWindow) Reduce(fn func(b *Bucket)) {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	span := rw.span()
+	count := rw.size - span
+	if count <= 0 {
+		return
+	}
+
+	start := (rw.offset + span + 1) % rw.size
+	for i := 0; i < count; i++ {
+		fn(&rw.buckets[(start+i)%rw.size])
+	}
+}
+
+// Reset 清空窗口
+func (rw *RollingWindow) Reset() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	for i := range rw.buckets {
+		rw.buckets[i].reset()
+	}
+	rw.offset = 0
+	rw.lastTime = time.Now()
+}
+
+// span 返回距离上一次写入经过的桶数，超过窗口时返回 size
+func (rw *RollingWindow) span() int {
+	offset := int(time.Since(rw.lastTime) / rw.interval)
+	if offset >= 0 && offset < rw.size {
+		return offset
+	}
+	return rw.size
+}
+
+// updateOffset 清空已经过期的桶并移动当前桶的位置
+func (rw *RollingWindow) updateOffset() {
+	span := rw.span()
+	if span <= 0 {
+		return
+	}
+
+	for i := 0; i < span; i++ {
+		rw.buckets[(rw.offset+i+1)%rw.size].reset()
+	}
+	rw.offset = (rw.offset + span) % rw.size
+
+	// 对齐到桶的边界
+	now := time.Now()
+	rw.lastTime = now.Add(-(now.Sub(rw.lastTime) % rw.interval))
+}
@@ -0,0 +1,68 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const interval = 50 * time.Millisecond
+
+func sum(rw *RollingWindow) (float64, int64) {
+	var (
+		s float64
+		c int64
+	)
+	rw.Reduce(func(b *Bucket) {
+		s += b.Sum
+		c += b.Count
+	})
+	return s, c
+}
+
+func TestRollingWindowAdd(t *testing.T) {
+	rw := NewRollingWindow(3, interval)
+
+	rw.Add(1)
+	rw.Add(2)
+	s, c := sum(rw)
+	assert.Equal(t, float64(3), s)
+	assert.Equal(t, int64(2), c)
+
+	time.Sleep(interval)
+	rw.Add(4)
+	s, c = sum(rw)
+	assert.Equal(t, float64(7), s)
+	assert.Equal(t, int64(3), c)
+}
+
+func TestRollingWindowExpire(t *testing.T) {
+	rw := NewRollingWindow(3, interval)
+
+	rw.Add(1)
+	time.Sleep(4 * interval)
+	s, c := sum(rw)
+	assert.Equal(t, float64(0), s)
+	assert.Equal(t, int64(0), c)
+
+	rw.Add(2)
+	s, c = sum(rw)
+	assert.Equal(t, float64(2), s)
+	assert.Equal(t, int64(1), c)
+}
+
+func TestRollingWindowReset(t *testing.T) {
+	rw := NewRollingWindow(3, interval)
+
+	rw.Add(1)
+	rw.Reset()
+	s, c := sum(rw)
+	assert.Equal(t, float64(0), s)
+	assert.Equal(t, int64(0), c)
+}
+
+func TestNewRollingWindowPanics(t *testing.T) {
+	assert.Panics(t, func() { NewRollingWindow(0, interval) })
+	assert.Panics(t, func() { NewRollingWindow(1, 0) })
+}
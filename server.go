@@ -13,4 +13,6 @@ type Server interface {
 	Start(ctx context.Context) error
 	//	Stop 停止服务器
 	Stop(ctx context.Context) error
+	// Ready 服务器开始监听后关闭
+	Ready() <-chan struct{}
 }
@@ -22,11 +22,17 @@ type Server interface {
 	Start(ctx context.Context) error
 	// Stop 停止服务器
 	Stop(ctx context.Context) error
+	// Ready 返回一个通道，服务器开始监听后关闭
+	Ready() <-chan struct{}
 }
 
 // RESTServer 扩展REST服务器接口
 type RESTServer interface {
 	Server
+	Endpoint() (*url.URL, error)
+	CloseListener() error
+	Drain()
+	Inflight() int64
 	Group(relativePath string, handlers ...any) rest.RouteGroup
 	Use(middleware ...any) rest.Server
 	Handle(httpMethod, relativePath string, handlers ...any) rest.Server
@@ -36,6 +42,9 @@ type RESTServer interface {
 type RPCServer interface {
 	Server
 	Endpoint() (*url.URL, error)
+	CloseListener() error
+	Drain()
+	Inflight() int64
 	Engine() any
 	RegisterService(desc any, impl any) rpc.Server
 }
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/gofiber/fiber/v3"
 	fiberServer "github.com/taluos/Malt/server/rest/rest-fiber"
@@ -42,6 +43,26 @@ func (s *fiberServerWrapper) Stop(ctx context.Context) error {
 	return s.app.Stop(ctx)
 }
 
+func (s *fiberServerWrapper) Ready() <-chan struct{} {
+	return s.app.Ready()
+}
+
+func (s *fiberServerWrapper) Drain() {
+	s.app.Drain()
+}
+
+func (s *fiberServerWrapper) Inflight() int64 {
+	return s.app.Inflight()
+}
+
+func (s *fiberServerWrapper) Endpoint() (*url.URL, error) {
+	return s.app.Endpoint()
+}
+
+func (s *fiberServerWrapper) CloseListener() error {
+	return s.app.CloseListener()
+}
+
 func (s *fiberServerWrapper) App() any {
 	return s.app.App
 }
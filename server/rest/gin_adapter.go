@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	ginServer "github.com/taluos/Malt/server/rest/rest-gin"
@@ -48,6 +49,31 @@ func (s *ginServerWrapper) Stop(ctx context.Context) error {
 	return s.server.Stop(ctx)
 }
 
+// Ready 实现Server.Ready
+func (s *ginServerWrapper) Ready() <-chan struct{} {
+	return s.server.Ready()
+}
+
+// Drain 实现Server.Drain
+func (s *ginServerWrapper) Drain() {
+	s.server.Drain()
+}
+
+// Inflight 实现Server.Inflight
+func (s *ginServerWrapper) Inflight() int64 {
+	return s.server.Inflight()
+}
+
+// Endpoint 实现Server.Endpoint
+func (s *ginServerWrapper) Endpoint() (*url.URL, error) {
+	return s.server.Endpoint()
+}
+
+// CloseListener 实现Server.CloseListener
+func (s *ginServerWrapper) CloseListener() error {
+	return s.server.CloseListener()
+}
+
 // Engine 实现Server.Engine
 func (s *ginServerWrapper) Engine() any {
 	return s.server.Engine
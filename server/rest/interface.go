@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/taluos/Malt/pkg/log"
 )
@@ -17,6 +18,21 @@ type Server interface {
 	// Stop 停止服务器
 	Stop(ctx context.Context) error
 
+	// Ready 返回一个通道，服务器开始监听后关闭
+	Ready() <-chan struct{}
+
+	// Endpoint 返回服务器的端点URL
+	Endpoint() (*url.URL, error)
+
+	// CloseListener 关闭 Endpoint 提前打开的监听，用于服务器不再启动的场景
+	CloseListener() error
+
+	// Drain 将服务器标记为不健康，准备下线
+	Drain()
+
+	// Inflight 返回正在处理的请求数
+	Inflight() int64
+
 	// Group 创建一个新的路由组
 	Group(relativePath string, handlers ...any) RouteGroup
 
@@ -43,11 +43,13 @@ func (j JWTStrategy) AuthFunc() fiber.Handler {
 		// 组合成 fullMethod
 		fullMethod := method + ":" + path // for example: GET:/api/v1/hello
 		authHeader := c.Get("Authorization")
-		if err := j.authenticator.HTTPAuthenticate(authHeader, "", fullMethod, ""); err != nil {
+		claims, err := j.authenticator.AuthenticateHeader(authHeader, "", fullMethod, "")
+		if err != nil {
 			internal.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, "Token is not validable."), nil)
-			c.Drop()
+			return c.Drop()
 		}
-		c.Next()
-		return nil
+		// claims 放入请求上下文，供后续中间件（例如按用户限流）使用
+		c.SetContext(authJWT.NewContext(c.Context(), claims))
+		return c.Next()
 	}
 }
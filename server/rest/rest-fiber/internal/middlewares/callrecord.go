@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/storage"
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	fiber "github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CallRecordMiddleware 把每个请求的调用记录交给 recorder，方法名为 "GET /users/:id"，
+// 状态码大于等于 400 的请求记录错误信息
+func CallRecordMiddleware(recorder storage.Recorder) fiber.Handler {
+	return func(c fiber.Ctx) (err error) {
+		start := time.Now()
+		record := func(code int, errMsg string) {
+			rec := models.RpcCallRecord{
+				Protocol:  "http",
+				Method:    c.Method() + " " + c.Route().Path,
+				Peer:      c.IP(),
+				Code:      uint32(code),
+				Duration:  time.Since(start).Milliseconds(),
+				Error:     errMsg,
+				Timestamp: time.Now(),
+			}
+			if sc := trace.SpanContextFromContext(c.Context()); sc.HasTraceID() {
+				rec.TraceID = sc.TraceID().String()
+			}
+			recorder.Record(&rec)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				record(http.StatusInternalServerError, fmt.Sprintf("panic: %v", p))
+				panic(p)
+			}
+		}()
+
+		err = c.Next()
+
+		status := c.Response().StatusCode()
+		var errMsg string
+		if err != nil {
+			// 错误由之后的 ErrorHandler 写入响应，这里按错误推断状态码
+			status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			}
+			errMsg = err.Error()
+		} else if status >= http.StatusBadRequest {
+			errMsg = http.StatusText(status)
+		}
+		record(status, errMsg)
+		return err
+	}
+}
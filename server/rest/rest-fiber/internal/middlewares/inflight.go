@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	fiber "github.com/gofiber/fiber/v3"
+)
+
+// InflightMiddleware counts the requests that are being processed.
+func InflightMiddleware(inflight *atomic.Int64) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		inflight.Add(1)
+		defer inflight.Add(-1)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/taluos/Malt/core/limit"
+	internal "github.com/taluos/Malt/server/rest/rest-fiber/internal"
+
+	fiber "github.com/gofiber/fiber/v3"
+)
+
+// LimitMiddleware 按 keys 指定的维度组合限流键，超过配额时返回 429，
+// 按用户限流时需要放在认证中间件之后
+func LimitMiddleware(limiter limit.Limiter, keys ...limit.KeyType) fiber.Handler {
+	keyFunc := LimitKeyFunc(keys...)
+	return func(c fiber.Ctx) error {
+		key := keyFunc(c)
+		if err := limiter.Allow(c.Context(), key); err != nil {
+			internal.WriteResponse(c, err, nil)
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// limitKeyValue 返回限流维度在当前请求中的取值，客户端 IP 只信任 WithTrustedProxies 配置的代理
+func limitKeyValue(c fiber.Ctx, t limit.KeyType) string {
+	if name, ok := t.Header(); ok {
+		return c.Get(name)
+	}
+
+	// 全局中间件执行时还没有匹配到业务路由，c.Route() 只能拿到中间件自身的路由，
+	// 因此使用请求路径
+	route := c.Path()
+
+	switch t {
+	case limit.KeyRoute:
+		return route
+	case limit.KeyMethod:
+		return c.Method() + ":" + route
+	case limit.KeyUser:
+		if user := limit.UserID(c.Context()); user != "" {
+			return user
+		}
+	}
+	return c.IP()
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/taluos/Malt/core/limit"
+	internal "github.com/taluos/Malt/server/rest/rest-fiber/internal"
+
+	fiber "github.com/gofiber/fiber/v3"
+)
+
+// RateLimitConfig 单机按 key 限流的配置
+type RateLimitConfig struct {
+	// Registry 保存各个 key 的令牌桶
+	Registry *limit.Registry
+	// Rate 默认配额，为零值时只限制 Routes 中的路由
+	Rate limit.Rate
+	// Routes 按路由模板覆盖默认配额，例如 /api/v1/users/:id，覆盖的路由使用独立的令牌桶
+	Routes map[string]limit.Rate
+	// KeyFunc 返回请求的限流键，默认按客户端 IP
+	KeyFunc func(c fiber.Ctx) string
+}
+
+// RateLimitMiddleware 按 KeyFunc 返回的 key 分别限流，响应中附带 RateLimit-* 头，
+// 超过配额时返回 429 和 Retry-After
+func RateLimitMiddleware(conf RateLimitConfig) fiber.Handler {
+	if conf.Registry == nil {
+		conf.Registry = limit.NewRegistry()
+	}
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = LimitKeyFunc(limit.KeyIP)
+	}
+	routes := make([]routeRate, 0, len(conf.Routes))
+	for route, rt := range conf.Routes {
+		routes = append(routes, routeRate{template: route, segments: splitPath(route), rate: rt})
+	}
+	// 静态段多的模板优先匹配，例如 /users/me 优先于 /users/:id
+	sort.Slice(routes, func(i, j int) bool {
+		si, sj := routes[i].static(), routes[j].static()
+		if si != sj {
+			return si > sj
+		}
+		return routes[i].template < routes[j].template
+	})
+
+	return func(c fiber.Ctx) error {
+		key := conf.KeyFunc(c)
+		rt := conf.Rate
+		// 全局中间件取不到业务路由，按请求路径匹配覆盖配额的路由模板
+		if route, ok := matchRoute(routes, c.Path()); ok {
+			key = "route=" + route.template + ":" + key
+			rt = route.rate
+		} else if rt == (limit.Rate{}) {
+			return c.Next()
+		}
+
+		res := conf.Registry.Take(key, rt)
+		res.WriteHeaders(func(k, v string) { c.Set(k, v) })
+		if !res.Allowed {
+			internal.WriteResponse(c, limit.ErrLimitExceeded, nil)
+			return nil
+		}
+		return c.Next()
+	}
+}
+
+// LimitKeyFunc 按 keys 指定的维度组合限流键
+func LimitKeyFunc(keys ...limit.KeyType) func(c fiber.Ctx) string {
+	return func(c fiber.Ctx) string {
+		return limit.BuildKey(keys, func(t limit.KeyType) string {
+			return limitKeyValue(c, t)
+		})
+	}
+}
+
+type routeRate struct {
+	template string
+	segments []string
+	rate     limit.Rate
+}
+
+// static 返回模板中静态段的数量
+func (r routeRate) static() int {
+	n := 0
+	for _, seg := range r.segments {
+		if !strings.HasPrefix(seg, ":") && seg != "*" && seg != "+" {
+			n++
+		}
+	}
+	return n
+}
+
+// matchRoute 返回第一个匹配 path 的路由模板，模板支持 :param 和结尾的 * 通配
+func matchRoute(routes []routeRate, path string) (routeRate, bool) {
+	if len(routes) == 0 {
+		return routeRate{}, false
+	}
+	segments := splitPath(path)
+	for _, route := range routes {
+		if matchSegments(route.segments, segments) {
+			return route, true
+		}
+	}
+	return routeRate{}, false
+}
+
+func matchSegments(template, path []string) bool {
+	for i, seg := range template {
+		if seg == "*" || seg == "+" {
+			return seg == "*" || i < len(path)
+		}
+		if i >= len(path) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if seg != path[i] {
+			return false
+		}
+	}
+	return len(template) == len(path)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
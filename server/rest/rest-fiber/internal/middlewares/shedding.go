@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/taluos/Malt/core/load"
+	internal "github.com/taluos/Malt/server/rest/rest-fiber/internal"
+
+	fiber "github.com/gofiber/fiber/v3"
+)
+
+// SheddingMiddleware 服务过载时直接返回 503，失败或 5xx 响应不计入通过数和延迟，
+// skipPaths 中的路径（例如健康检查）不参与降载
+func SheddingMiddleware(shedder load.Shedder, skipPaths ...string) fiber.Handler {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = struct{}{}
+	}
+	return func(c fiber.Ctx) error {
+		if _, ok := skip[c.Path()]; ok {
+			return c.Next()
+		}
+
+		promise, err := shedder.Allow()
+		if err != nil {
+			internal.WriteResponse(c, err, nil)
+			return nil
+		}
+
+		// handler panic 时释放 promise 并继续向外抛出，由外层的 recover 中间件处理
+		defer func() {
+			if r := recover(); r != nil {
+				promise.Fail()
+				panic(r)
+			}
+		}()
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= http.StatusInternalServerError {
+			promise.Fail()
+		} else {
+			promise.Pass()
+		}
+		return err
+	}
+}
@@ -3,8 +3,12 @@ package fiber
 import (
 	fiber "github.com/gofiber/fiber/v3"
 
+	"github.com/taluos/Malt/core/limit"
+	"github.com/taluos/Malt/core/load"
 	maltAgent "github.com/taluos/Malt/core/trace"
+	"github.com/taluos/Malt/pkg/storage"
 	auth "github.com/taluos/Malt/server/rest/rest-fiber/internal/auth"
+	middleware "github.com/taluos/Malt/server/rest/rest-fiber/internal/middlewares"
 )
 
 type serverOptions struct {
@@ -23,6 +27,16 @@ type serverOptions struct {
 
 	agent        *maltAgent.Agent
 	authOperator *auth.AuthOperator
+
+	enableShedding bool
+	sheddingOpts   []load.ShedderOption
+
+	limiter   limit.Limiter
+	limitKeys []limit.KeyType
+
+	rateLimit *middleware.RateLimitConfig
+
+	callRecorder storage.Recorder
 }
 
 type ServerOptions func(*serverOptions)
@@ -92,3 +106,69 @@ func WithAuthOperator(authOperator *auth.AuthOperator) ServerOptions {
 		o.authOperator = authOperator
 	}
 }
+
+// WithShedding 启用自适应降载，过载时返回 503
+func WithShedding(opts ...load.ShedderOption) ServerOptions {
+	return func(o *serverOptions) {
+		o.enableShedding = true
+		o.sheddingOpts = append(o.sheddingOpts, opts...)
+	}
+}
+
+// WithLimiter 启用限流，按 keys 指定的维度组合限流键，超过配额时返回 429
+func WithLimiter(limiter limit.Limiter, keys ...limit.KeyType) ServerOptions {
+	return func(o *serverOptions) {
+		o.limiter = limiter
+		o.limitKeys = keys
+	}
+}
+
+// WithRateLimit 启用单机按 key 限流，每个 key 使用独立的令牌桶，keys 为空时按客户端 IP 限流
+func WithRateLimit(rate limit.Rate, keys ...limit.KeyType) ServerOptions {
+	return func(o *serverOptions) {
+		conf := o.rateLimitConfig()
+		conf.Rate = rate
+		if len(keys) > 0 {
+			conf.KeyFunc = middleware.LimitKeyFunc(keys...)
+		}
+	}
+}
+
+// WithRateLimitRoute 按路由模板覆盖限流配额，例如 /api/v1/users/:id
+func WithRateLimitRoute(route string, rate limit.Rate) ServerOptions {
+	return func(o *serverOptions) {
+		conf := o.rateLimitConfig()
+		if conf.Routes == nil {
+			conf.Routes = make(map[string]limit.Rate)
+		}
+		conf.Routes[route] = rate
+	}
+}
+
+// WithRateLimitKeyFunc 自定义限流键
+func WithRateLimitKeyFunc(keyFunc func(c fiber.Ctx) string) ServerOptions {
+	return func(o *serverOptions) {
+		o.rateLimitConfig().KeyFunc = keyFunc
+	}
+}
+
+// WithRateLimitRegistry 使用自定义的令牌桶注册表，例如调整空闲 key 的淘汰策略
+func WithRateLimitRegistry(registry *limit.Registry) ServerOptions {
+	return func(o *serverOptions) {
+		o.rateLimitConfig().Registry = registry
+	}
+}
+
+// WithCallRecord 把每个请求的调用记录交给 recorder 异步写入，recorder 由调用方关闭
+func WithCallRecord(recorder storage.Recorder) ServerOptions {
+	return func(o *serverOptions) {
+		o.callRecorder = recorder
+	}
+}
+
+func (o *serverOptions) rateLimitConfig() *middleware.RateLimitConfig {
+	if o.rateLimit == nil {
+		o.rateLimit = &middleware.RateLimitConfig{}
+	}
+	return o.rateLimit
+}
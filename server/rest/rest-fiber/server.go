@@ -2,8 +2,15 @@ package fiber
 
 import (
 	"context"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
 
+	"github.com/taluos/Malt/core/load"
+	"github.com/taluos/Malt/core/resolver/discovery"
 	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/host"
 	"github.com/taluos/Malt/pkg/log"
 	"github.com/taluos/Malt/pkg/validations"
 	middleware "github.com/taluos/Malt/server/rest/rest-fiber/internal/middlewares"
@@ -17,8 +24,16 @@ import (
 type Server struct {
 	*fiber.App
 
-	rootCtx context.Context
-	trans   uTranslator.Translator
+	listener net.Listener
+	endpoint *url.URL
+	rootCtx  context.Context
+	trans    uTranslator.Translator
+
+	ready     chan struct{} // 开始监听后关闭
+	readyOnce sync.Once
+
+	inflight atomic.Int64 // 正在处理的请求数
+	draining atomic.Bool  // 是否处于下线排空阶段
 
 	opts *serverOptions
 }
@@ -53,23 +68,49 @@ func NewServer(opts ...ServerOptions) *Server {
 		o.middlewares = append(o.middlewares, middleware.TracingMiddleware(o.agent))
 	}
 
+	// 调用记录放在链路追踪之后，可以取到 trace ID，并记录被认证和限流拒绝的请求
+	if o.callRecorder != nil {
+		o.middlewares = append(o.middlewares, middleware.CallRecordMiddleware(o.callRecorder))
+	}
+
 	if o.authOperator != nil {
 		// 添加认证中间件
 		o.middlewares = append(o.middlewares, middleware.AuthenticMiddleware(o.authOperator))
 	}
 
+	// 限流放在认证之后，按用户限流时可以取到 JWT 中的用户 ID
+	if o.limiter != nil {
+		o.middlewares = append(o.middlewares, middleware.LimitMiddleware(o.limiter, o.limitKeys...))
+	}
+	if o.rateLimit != nil {
+		o.middlewares = append(o.middlewares, middleware.RateLimitMiddleware(*o.rateLimit))
+	}
+
 	// 创建fiber配置
 	config := fiber.Config{
 		AppName: o.name,
 	}
+	// 只信任配置的代理转发的客户端 IP，未配置时直接使用连接的对端地址
+	if len(o.trustedProxies) > 0 {
+		config.TrustProxy = true
+		config.TrustProxyConfig = fiber.TrustProxyConfig{Proxies: o.trustedProxies}
+		config.ProxyHeader = fiber.HeaderXForwardedFor
+	}
 
 	// 创建服务器实例
 	s := &Server{
-		App:  fiber.New(config),
-		opts: o,
+		App:   fiber.New(config),
+		ready: make(chan struct{}),
+		opts:  o,
 	}
 
 	// 应用中间件
+	s.Use(middleware.InflightMiddleware(&s.inflight))
+	if o.enableShedding {
+		// 过载时尽早拒绝请求，健康检查不参与降载
+		shedder := load.NewAdaptiveShedder(append([]load.ShedderOption{load.WithName(o.name)}, o.sheddingOpts...)...)
+		s.Use(middleware.SheddingMiddleware(shedder, "/health"))
+	}
 	for _, mw := range o.middlewares {
 		s.Use(mw)
 	}
@@ -77,6 +118,9 @@ func NewServer(opts ...ServerOptions) *Server {
 	// 配置健康检查
 	if s.opts.enableHealth {
 		s.Get("/health", func(c fiber.Ctx) error {
+			if s.draining.Load() {
+				return c.Status(fiber.StatusServiceUnavailable).SendString("draining")
+			}
 			return c.SendString("ok")
 		})
 	}
@@ -114,9 +158,19 @@ func NewServer(opts ...ServerOptions) *Server {
 func (s *Server) Start(ctx context.Context) error {
 	log.Infof("[FIBER] server is running on %s", s.opts.address)
 
+	// 如果还没有监听端口，则在这里完成监听并解析出真实的端点
+	if err := s.listenAndEndpoint(); err != nil {
+		return errors.Wrapf(err, "[FIBER] server listen failed")
+	}
+
 	s.rootCtx = ctx
 
-	err := s.Listen(s.opts.address)
+	err := s.Listener(s.listener, fiber.ListenConfig{
+		BeforeServeFunc: func(*fiber.App) error {
+			s.readyOnce.Do(func() { close(s.ready) })
+			return nil
+		},
+	})
 	if err != nil {
 		return errors.Wrapf(err, "[FIBER] server failed")
 	}
@@ -137,3 +191,66 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	return err
 }
+
+// Drain marks the server as unhealthy so that /health reports 503.
+func (s *Server) Drain() {
+	log.Infof("[FIBER] server draining on %s, %d requests in flight", s.opts.address, s.inflight.Load())
+	s.draining.Store(true)
+}
+
+// Inflight returns the number of requests that are being processed.
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
+// Ready returns a channel that is closed once the server is listening.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Endpoint return a real address to registry endpoint.
+func (s *Server) Endpoint() (*url.URL, error) {
+	if err := s.listenAndEndpoint(); err != nil {
+		return nil, err
+	}
+	return s.endpoint, nil
+}
+
+// CloseListener closes the listener opened by Endpoint when the server will not be started,
+// such as when the app fails to start.
+func (s *Server) CloseListener() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	s.listener, s.endpoint = nil, nil
+	return err
+}
+
+func (s *Server) listenAndEndpoint() error {
+	if s.listener == nil {
+		lis, err := net.Listen("tcp", s.opts.address)
+		if err != nil {
+			log.Errorf("[FIBER] Listen to the listener failed: %s", err)
+			return err
+		}
+		s.listener = lis
+	}
+
+	if s.endpoint != nil {
+		return nil
+	}
+
+	// 提取地址：支持 :0 随机端口和 0.0.0.0 监听
+	address, err := host.Extract(s.opts.address, s.listener)
+	if err != nil {
+		log.Errorf("[FIBER] Get address from listener failed: %s", err)
+		_ = s.listener.Close()
+		s.listener = nil
+		return err
+	}
+
+	s.endpoint = discovery.NewEndpoint("http", address, false)
+
+	return nil
+}
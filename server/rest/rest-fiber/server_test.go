@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/taluos/Malt/core/limit"
 	maltAgent "github.com/taluos/Malt/core/trace"
+	"github.com/taluos/Malt/pkg/storage/models"
 	"github.com/taluos/Malt/server/rest/rest-fiber/internal/auth"
 
 	fiber "github.com/gofiber/fiber/v3"
@@ -396,3 +399,116 @@ func TestConcurrentRequests(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+// TestLimiterMiddleware 测试限流中间件
+func TestLimiterMiddleware(t *testing.T) {
+	server := NewServer(
+		WithLimiter(limit.NewTokenLimiter(nil, 1, 2), limit.KeyMethod),
+	)
+	server.Get("/test", func(c fiber.Ctx) error {
+		return c.SendString("test")
+	})
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		resp, err := server.Test(&http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/test"},
+			Header: make(http.Header),
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		codes = append(codes, resp.StatusCode)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+// TestRateLimitMiddleware 测试单机按 key 限流中间件
+func TestRateLimitMiddleware(t *testing.T) {
+	server := NewServer(
+		WithRateLimitRoute("/users/:id", limit.Rate{Limit: 1, Burst: 1}),
+		WithRateLimitKeyFunc(func(c fiber.Ctx) string { return c.Get("X-Api-Key") }),
+	)
+	server.Get("/users/:id", func(c fiber.Ctx) error {
+		return c.SendString("user")
+	})
+	server.Get("/test", func(c fiber.Ctx) error {
+		return c.SendString("test")
+	})
+
+	do := func(path, apiKey string) *http.Response {
+		header := make(http.Header)
+		header.Set("X-Api-Key", apiKey)
+		resp, err := server.Test(&http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: path},
+			Header: header,
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := do("/users/1", "a")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(limit.HeaderLimit))
+	assert.Equal(t, "0", resp.Header.Get(limit.HeaderRemaining))
+
+	resp = do("/users/2", "a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(limit.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusOK, do("/users/1", "b").StatusCode)
+
+	// 没有默认配额时其他路由不限流
+	for i := 0; i < 3; i++ {
+		resp = do("/test", "a")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(limit.HeaderLimit))
+	}
+}
+
+// memoryRecorder 把调用记录保存在内存中
+type memoryRecorder struct {
+	mu      sync.Mutex
+	records []models.RpcCallRecord
+}
+
+func (r *memoryRecorder) Record(record *models.RpcCallRecord) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, *record)
+	return true
+}
+
+func (r *memoryRecorder) Flush() {}
+
+func (r *memoryRecorder) Close() error { return nil }
+
+func TestCallRecordMiddleware(t *testing.T) {
+	recorder := &memoryRecorder{}
+	server := NewServer(WithCallRecord(recorder))
+	server.Get("/users/:id", func(c fiber.Ctx) error {
+		return c.SendString("user")
+	})
+	server.Get("/fail", func(c fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadGateway, "upstream failed")
+	})
+
+	for _, path := range []string{"/users/1", "/fail"} {
+		resp, err := server.Test(&http.Request{Method: "GET", URL: &url.URL{Path: path}, Header: make(http.Header)})
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	require.Len(t, recorder.records, 2)
+	assert.Equal(t, "http", recorder.records[0].Protocol)
+	assert.Equal(t, "GET /users/:id", recorder.records[0].Method)
+	assert.Equal(t, uint32(fiber.StatusOK), recorder.records[0].Code)
+	assert.Empty(t, recorder.records[0].Error)
+
+	assert.Equal(t, "GET /fail", recorder.records[1].Method)
+	assert.Equal(t, uint32(fiber.StatusBadGateway), recorder.records[1].Code)
+	assert.Equal(t, "upstream failed", recorder.records[1].Error)
+}
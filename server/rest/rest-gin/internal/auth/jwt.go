@@ -48,10 +48,14 @@ func (j *JWTStrategy) AuthFunc() gin.HandlerFunc {
 		// 组合成 fullMethod
 		fullMethod := method + ":" + path
 		authHeader := c.GetHeader("Authorization")
-		if err := j.authenticator.HTTPAuthenticate(authHeader, "", fullMethod, ""); err != nil {
+		claims, err := j.authenticator.AuthenticateHeader(authHeader, "", fullMethod, "")
+		if err != nil {
 			internal.WriteResponse(c, errors.WithCode(code.ErrSignatureInvalid, "Token is not validable."), nil)
 			c.Abort()
+			return
 		}
+		// claims 放入请求上下文，供后续中间件（例如按用户限流）使用
+		c.Request = c.Request.WithContext(authJWT.NewContext(c.Request.Context(), claims))
 		c.Next()
 	}
 }
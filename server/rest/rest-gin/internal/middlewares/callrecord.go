@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/taluos/Malt/pkg/storage"
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CallRecordMiddleware 把每个请求的调用记录交给 recorder，方法名为 "GET /users/:id"，
+// 状态码大于等于 400 的请求记录错误信息
+func CallRecordMiddleware(recorder storage.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		record := func(code int, errMsg string) {
+			route := c.FullPath()
+			if route == "" {
+				// 未匹配到路由
+				route = c.Request.URL.Path
+			}
+			rec := models.RpcCallRecord{
+				Protocol:  "http",
+				Method:    c.Request.Method + " " + route,
+				Peer:      c.ClientIP(),
+				Code:      uint32(code),
+				Duration:  time.Since(start).Milliseconds(),
+				Error:     errMsg,
+				Timestamp: time.Now(),
+			}
+			if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+				rec.TraceID = sc.TraceID().String()
+			}
+			recorder.Record(&rec)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				record(http.StatusInternalServerError, fmt.Sprintf("panic: %v", p))
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		var errMsg string
+		if len(c.Errors) > 0 {
+			errMsg = c.Errors.Last().Error()
+		} else if status >= http.StatusBadRequest {
+			errMsg = http.StatusText(status)
+		}
+		record(status, errMsg)
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InflightMiddleware counts the requests that are being processed.
+func InflightMiddleware(inflight *atomic.Int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inflight.Add(1)
+		defer inflight.Add(-1)
+
+		c.Next()
+	}
+}
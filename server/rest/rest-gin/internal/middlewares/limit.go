@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/taluos/Malt/core/limit"
+	internal "github.com/taluos/Malt/server/rest/rest-gin/internal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LimitMiddleware 按 keys 指定的维度组合限流键，超过配额时返回 429，
+// 按用户限流时需要放在认证中间件之后
+func LimitMiddleware(limiter limit.Limiter, keys ...limit.KeyType) gin.HandlerFunc {
+	keyFunc := LimitKeyFunc(keys...)
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if err := limiter.Allow(c.Request.Context(), key); err != nil {
+			internal.WriteResponse(c, err, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// limitKeyValue 返回限流维度在当前请求中的取值，客户端 IP 只信任 WithTrustedProxies 配置的代理
+func limitKeyValue(c *gin.Context, t limit.KeyType) string {
+	if name, ok := t.Header(); ok {
+		return c.GetHeader(name)
+	}
+
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	switch t {
+	case limit.KeyRoute:
+		return route
+	case limit.KeyMethod:
+		return c.Request.Method + ":" + route
+	case limit.KeyUser:
+		if user := limit.UserID(c.Request.Context()); user != "" {
+			return user
+		}
+	}
+	return c.ClientIP()
+}
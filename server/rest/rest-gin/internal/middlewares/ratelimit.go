@@ -1,23 +1,60 @@
 package middleware
 
 import (
-	"net/http"
+	"github.com/taluos/Malt/core/limit"
+	internal "github.com/taluos/Malt/server/rest/rest-gin/internal"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
 )
 
-func RateLimitMiddleware(r rate.Limit, b int) gin.HandlerFunc {
-	limiter := rate.NewLimiter(r, b)
+// RateLimitConfig 单机按 key 限流的配置
+type RateLimitConfig struct {
+	// Registry 保存各个 key 的令牌桶
+	Registry *limit.Registry
+	// Rate 默认配额，为零值时只限制 Routes 中的路由
+	Rate limit.Rate
+	// Routes 按路由模板覆盖默认配额，例如 /api/v1/users/:id，覆盖的路由使用独立的令牌桶
+	Routes map[string]limit.Rate
+	// KeyFunc 返回请求的限流键，默认按客户端 IP
+	KeyFunc func(c *gin.Context) string
+}
+
+// RateLimitMiddleware 按 KeyFunc 返回的 key 分别限流，响应中附带 RateLimit-* 头，
+// 超过配额时返回 429 和 Retry-After
+func RateLimitMiddleware(conf RateLimitConfig) gin.HandlerFunc {
+	if conf.Registry == nil {
+		conf.Registry = limit.NewRegistry()
+	}
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = LimitKeyFunc(limit.KeyIP)
+	}
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"code": 429,
-				"msg":  "Too many requests",
-			})
+		key := conf.KeyFunc(c)
+		rt := conf.Rate
+		if override, ok := conf.Routes[c.FullPath()]; ok {
+			key = "route=" + c.FullPath() + ":" + key
+			rt = override
+		} else if rt == (limit.Rate{}) {
+			c.Next()
+			return
+		}
+
+		res := conf.Registry.Take(key, rt)
+		res.WriteHeaders(c.Header)
+		if !res.Allowed {
+			internal.WriteResponse(c, limit.ErrLimitExceeded, nil)
 			c.Abort()
 			return
 		}
 		c.Next()
 	}
 }
+
+// LimitKeyFunc 按 keys 指定的维度组合限流键
+func LimitKeyFunc(keys ...limit.KeyType) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return limit.BuildKey(keys, func(t limit.KeyType) string {
+			return limitKeyValue(c, t)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/taluos/Malt/core/load"
+	internal "github.com/taluos/Malt/server/rest/rest-gin/internal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SheddingMiddleware 服务过载时直接返回 503，5xx 响应不计入通过数和延迟，
+// skipPaths 中的路径（例如健康检查）不参与降载
+func SheddingMiddleware(shedder load.Shedder, skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = struct{}{}
+	}
+	return func(c *gin.Context) {
+		if _, ok := skip[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		promise, err := shedder.Allow()
+		if err != nil {
+			internal.WriteResponse(c, err, nil)
+			c.Abort()
+			return
+		}
+
+		// handler panic 时释放 promise 并继续向外抛出，由外层的 Recovery 处理
+		defer func() {
+			if r := recover(); r != nil {
+				promise.Fail()
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			promise.Fail()
+		} else {
+			promise.Pass()
+		}
+	}
+}
@@ -3,9 +3,16 @@ package httpserver
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/taluos/Malt/core/limit"
+	"github.com/taluos/Malt/core/load"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/storage/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -132,6 +139,49 @@ func TestServerHealthEndpoint(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestServerHealthDraining(t *testing.T) {
+	server := NewServer(
+		WithAddress("127.0.0.1:0"),
+		WithHealthz(true),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		err := server.Start(ctx)
+		if err != nil {
+			t.Errorf("Server start failed: %v", err)
+		}
+	}()
+
+	// 等待服务器开始监听
+	<-server.Ready()
+
+	endpoint, err := server.Endpoint()
+	require.NoError(t, err)
+
+	// 不复用连接，避免 Stop 等待空闲的 keep-alive 连接
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	resp, err := client.Get("http://" + endpoint.Host + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	server.Drain()
+
+	resp, err = client.Get("http://" + endpoint.Host + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int64(0), server.Inflight())
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stopCancel()
+	assert.NoError(t, server.Stop(stopCtx))
+}
+
 func TestServerWithoutHealth(t *testing.T) {
 	server := NewServer(
 		WithAddress("localhost:8083"),
@@ -203,6 +253,22 @@ func TestServerStartStop(t *testing.T) {
 	assert.Error(t, err) // 应该连接失败
 }
 
+func TestServerStopBeforeStart(t *testing.T) {
+	server := NewServer(WithAddress("127.0.0.1:0"))
+
+	// 先于 Start 执行的 Stop 之后，Start 不再提供服务而是直接返回
+	require.NoError(t, server.Stop(context.Background()))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start(context.Background()) }()
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
 func TestServerOptions(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -254,6 +320,16 @@ func TestServerOptions(t *testing.T) {
 			option: WithCertFile("/path/to/cert.pem"),
 			check:  func(o *serverOptions) bool { return o.certFile == "/path/to/cert.pem" },
 		},
+		{
+			name:   "WithShedding",
+			option: WithShedding(load.WithCpuThreshold(800)),
+			check:  func(o *serverOptions) bool { return o.enableShedding && len(o.sheddingOpts) == 1 },
+		},
+		{
+			name:   "WithLimiter",
+			option: WithLimiter(limit.NewTokenLimiter(nil, 1, 1), limit.KeyRoute, limit.KeyIP),
+			check:  func(o *serverOptions) bool { return o.limiter != nil && len(o.limitKeys) == 2 },
+		},
 		{
 			name:   "WithKeyFile",
 			option: WithKeyFile("/path/to/key.pem"),
@@ -350,3 +426,119 @@ func BenchmarkServerWithOptions(b *testing.B) {
 		_ = NewServer(opts...)
 	}
 }
+
+func TestServerLimiter(t *testing.T) {
+	server := NewServer(
+		WithMode(gin.TestMode),
+		WithLimiter(limit.NewPeriodLimiter(nil, time.Minute, 2), limit.KeyRoute, limit.KeyIP),
+	)
+	server.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	do := func(path, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 同一路由模板共用配额
+	assert.Equal(t, http.StatusOK, do("/users/1", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, do("/users/2", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, do("/users/3", "10.0.0.1:1234"))
+
+	// 不同客户端互不影响
+	assert.Equal(t, http.StatusOK, do("/users/1", "10.0.0.2:1234"))
+}
+
+func TestServerRateLimit(t *testing.T) {
+	server := NewServer(
+		WithMode(gin.TestMode),
+		WithTrustedProxies([]string{"10.0.0.100"}),
+		WithRateLimit(limit.Rate{Limit: 1, Burst: 2}),
+		WithRateLimitRoute("/login", limit.Rate{Limit: 1, Burst: 1}),
+	)
+	server.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	server.GET("/login", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	do := func(path, clientIP string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.100:1234"
+		req.Header.Set("X-Forwarded-For", clientIP)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/users/1", "1.1.1.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(limit.HeaderLimit))
+	assert.Equal(t, "1", w.Header().Get(limit.HeaderRemaining))
+
+	assert.Equal(t, http.StatusOK, do("/users/2", "1.1.1.1").Code)
+	w = do("/users/3", "1.1.1.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get(limit.HeaderRetryAfter))
+
+	// 代理转发的不同客户端使用独立的配额
+	assert.Equal(t, http.StatusOK, do("/users/1", "2.2.2.2").Code)
+
+	// 覆盖配额的路由使用独立的令牌桶
+	assert.Equal(t, http.StatusOK, do("/login", "1.1.1.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("/login", "1.1.1.1").Code)
+}
+
+// memoryRecorder 把调用记录保存在内存中
+type memoryRecorder struct {
+	mu      sync.Mutex
+	records []models.RpcCallRecord
+}
+
+func (r *memoryRecorder) Record(record *models.RpcCallRecord) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, *record)
+	return true
+}
+
+func (r *memoryRecorder) Flush() {}
+
+func (r *memoryRecorder) Close() error { return nil }
+
+func TestServerCallRecord(t *testing.T) {
+	recorder := &memoryRecorder{}
+	server := NewServer(WithMode(gin.TestMode), WithCallRecord(recorder))
+	server.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	server.GET("/fail", func(c *gin.Context) {
+		_ = c.Error(errors.New("boom"))
+		c.String(http.StatusInternalServerError, "fail")
+	})
+
+	for _, path := range []string{"/users/1", "/fail", "/missing"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		server.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, recorder.records, 3)
+	assert.Equal(t, "http", recorder.records[0].Protocol)
+	assert.Equal(t, "GET /users/:id", recorder.records[0].Method)
+	assert.Equal(t, uint32(http.StatusOK), recorder.records[0].Code)
+	assert.Equal(t, "10.0.0.1", recorder.records[0].Peer)
+	assert.Empty(t, recorder.records[0].Error)
+
+	assert.Equal(t, uint32(http.StatusInternalServerError), recorder.records[1].Code)
+	assert.Equal(t, "boom", recorder.records[1].Error)
+
+	// 未匹配到路由时使用请求路径
+	assert.Equal(t, "GET /missing", recorder.records[2].Method)
+	assert.Equal(t, http.StatusText(http.StatusNotFound), recorder.records[2].Error)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/taluos/Malt/pkg/errors"
 	"github.com/taluos/Malt/server/rest"
@@ -36,6 +37,26 @@ func (w *restServerWrapper) Stop(ctx context.Context) error {
 	return w.server.Stop(ctx)
 }
 
+func (w *restServerWrapper) Ready() <-chan struct{} {
+	return w.server.Ready()
+}
+
+func (w *restServerWrapper) Drain() {
+	w.server.Drain()
+}
+
+func (w *restServerWrapper) Inflight() int64 {
+	return w.server.Inflight()
+}
+
+func (w *restServerWrapper) Endpoint() (*url.URL, error) {
+	return w.server.Endpoint()
+}
+
+func (w *restServerWrapper) CloseListener() error {
+	return w.server.CloseListener()
+}
+
 // 嵌入REST服务器的所有方法
 func (w *restServerWrapper) Group(relativePath string, handlers ...any) rest.RouteGroup {
 	return w.server.Group(relativePath, handlers...)
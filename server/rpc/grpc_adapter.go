@@ -44,11 +44,31 @@ func (s *grpcServerWrapper) Stop(ctx context.Context) error {
 	return s.server.Stop(ctx)
 }
 
+// Ready 实现Server.Ready
+func (s *grpcServerWrapper) Ready() <-chan struct{} {
+	return s.server.Ready()
+}
+
+// Drain 实现Server.Drain
+func (s *grpcServerWrapper) Drain() {
+	s.server.Drain()
+}
+
+// Inflight 实现Server.Inflight
+func (s *grpcServerWrapper) Inflight() int64 {
+	return s.server.Inflight()
+}
+
 // Endpoint 实现Server.Endpoint
 func (s *grpcServerWrapper) Endpoint() (*url.URL, error) {
 	return s.server.Endpoint()
 }
 
+// CloseListener 实现Server.CloseListener
+func (s *grpcServerWrapper) CloseListener() error {
+	return s.server.CloseListener()
+}
+
 // Engine 实现Server.Engine
 func (s *grpcServerWrapper) Engine() any {
 	return s.server.Server
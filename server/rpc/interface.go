@@ -18,9 +18,21 @@ type Server interface {
 	// Stop 停止服务器
 	Stop(ctx context.Context) error
 
+	// Ready 返回一个通道，服务器开始监听后关闭
+	Ready() <-chan struct{}
+
 	// Endpoint 返回服务器的端点URL
 	Endpoint() (*url.URL, error)
 
+	// CloseListener 关闭 Endpoint 提前打开的监听，用于服务器不再启动的场景
+	CloseListener() error
+
+	// Drain 将服务器标记为不健康，准备下线
+	Drain()
+
+	// Inflight 返回正在处理的请求数
+	Inflight() int64
+
 	// Engine 返回底层的RPC引擎，允许直接访问底层实现
 	// 注意：这可能会导致与底层实现的耦合，应谨慎使用
 	Engine() any
@@ -38,6 +50,8 @@ func NewServer(method string, opts ...ServerOptions) Server {
 	switch method {
 	case grpcServerType:
 		return newGrpcServer(opts...)
+	case kitexServerType:
+		return newKitexServer(opts...)
 	default:
 		log.Errorf("[RPC-Server] unknown rpc server type: %s", method)
 		return nil
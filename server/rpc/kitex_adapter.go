@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/taluos/Malt/pkg/log"
+	kitexServer "github.com/taluos/Malt/server/rpc/rpc-kitex"
+
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+)
+
+// kitexServerWrapper 是基于Kitex的Server实现
+type kitexServerWrapper struct {
+	server *kitexServer.Server
+}
+
+// 确保kitexServerWrapper实现了Server接口
+var _ Server = (*kitexServerWrapper)(nil)
+
+// newKitexServer 创建一个新的基于Kitex的服务器
+func newKitexServer(opts ...ServerOptions) Server {
+	serverOpts := make([]kitexServer.ServerOptions, 0, len(opts))
+	for _, opt := range opts {
+		if so, ok := opt.(kitexServer.ServerOptions); ok {
+			serverOpts = append(serverOpts, so)
+		}
+	}
+	return &kitexServerWrapper{
+		server: kitexServer.NewServer(serverOpts...),
+	}
+}
+
+func (s *kitexServerWrapper) Type() string {
+	return "kitex"
+}
+
+// Start 实现Server.Start
+func (s *kitexServerWrapper) Start(ctx context.Context) error {
+	return s.server.Start(ctx)
+}
+
+// Stop 实现Server.Stop
+func (s *kitexServerWrapper) Stop(ctx context.Context) error {
+	return s.server.Stop(ctx)
+}
+
+// Ready 实现Server.Ready
+func (s *kitexServerWrapper) Ready() <-chan struct{} {
+	return s.server.Ready()
+}
+
+// Drain 实现Server.Drain
+func (s *kitexServerWrapper) Drain() {
+	s.server.Drain()
+}
+
+// Inflight 实现Server.Inflight
+func (s *kitexServerWrapper) Inflight() int64 {
+	return s.server.Inflight()
+}
+
+// Endpoint 实现Server.Endpoint
+func (s *kitexServerWrapper) Endpoint() (*url.URL, error) {
+	return s.server.Endpoint()
+}
+
+// CloseListener 实现Server.CloseListener
+func (s *kitexServerWrapper) CloseListener() error {
+	return s.server.CloseListener()
+}
+
+// Engine 实现Server.Engine
+func (s *kitexServerWrapper) Engine() any {
+	return s.server.Engine()
+}
+
+// RegisterService 实现Server.RegisterService
+// desc 为 kitex 生成代码中的 *serviceinfo.ServiceInfo，impl 为服务实现
+func (s *kitexServerWrapper) RegisterService(desc any, impl any) Server {
+	svcInfo, ok := desc.(*serviceinfo.ServiceInfo)
+	if !ok {
+		log.Errorf("[RPC-Server] kitex register service failed, unknown service info type: %T", desc)
+		return s
+	}
+	if err := s.server.RegisterService(svcInfo, impl); err != nil {
+		log.Errorf("[RPC-Server] kitex register service %s failed: %s", svcInfo.ServiceName, err)
+	}
+	return s
+}
@@ -14,28 +14,42 @@ const (
 )
 
 var (
+	_ balancer.Builder   = &builder{}
 	_ base.PickerBuilder = &balancerBuilder{}
 	_ balancer.Picker    = &balancerPicker{}
 )
 
-type balancerBuilder struct {
+// builder creates a balancer with its own selector for each ClientConn,
+// so the node statistics survive picker rebuilds.
+type builder struct {
 	builder selector.Builder
 }
 
+type balancerBuilder struct {
+	selector selector.Selector
+}
+
 // balancerPicker is a grpc picker.
 type balancerPicker struct {
 	selector selector.Selector
 }
 
 func InitBuilder() {
-	b := base.NewBalancerBuilder(
+	balancer.Register(&builder{builder: selector.GlobalSelector()})
+}
+
+// Build creates a balancer for cc.
+func (b *builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	return base.NewBalancerBuilder(
 		balancerName,
-		&balancerBuilder{
-			builder: selector.GlobalSelector(),
-		},
+		&balancerBuilder{selector: b.builder.Build()},
 		base.Config{HealthCheck: true},
-	)
-	balancer.Register(b)
+	).Build(cc, opts)
+}
+
+// Name returns the balancer name.
+func (b *builder) Name() string {
+	return balancerName
 }
 
 // Build creates a grpc Picker.
@@ -53,11 +67,8 @@ func (b *balancerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 			subConn: conn,
 		})
 	}
-	p := &balancerPicker{
-		selector: b.builder.Build(),
-	}
-	p.selector.Apply(nodes)
-	return p
+	b.selector.Apply(nodes)
+	return &balancerPicker{selector: b.selector}
 }
 
 // Pick pick instances.
@@ -96,3 +107,9 @@ type grpcNode struct {
 	selector.Node
 	subConn balancer.SubConn
 }
+
+// Equal reports whether n uses the same SubConn, the selector rebuilds the node otherwise.
+func (g *grpcNode) Equal(n selector.Node) bool {
+	o, ok := n.(*grpcNode)
+	return ok && o.subConn == g.subConn
+}
@@ -3,6 +3,7 @@ package serverinterceptors
 
 import (
 	"context"
+	"strings"
 
 	"github.com/taluos/Malt/pkg/auth-jwt"
 	"github.com/taluos/Malt/pkg/errors"
@@ -11,9 +12,15 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
-func SteamAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+// authorizationKey gRPC metadata 中的认证头，gRPC 会把 key 统一转为小写
+const authorizationKey = "authorization"
+
+// SteamAuthorizeInterceptor 校验流式请求的JWT，allowList 中的方法或服务跳过认证
+func SteamAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenticator, allowList ...string) grpc.StreamServerInterceptor {
 	if authenticator == nil {
 		var err error
 		authenticator, err = auth.NewAuthenticator(keyFunc)
@@ -22,17 +29,22 @@ func SteamAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenti
 			return nil
 		}
 	}
+	allowed := newMethodAllowList(allowList)
 	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if allowed.match(info.FullMethod) {
+			return handler(svr, stream)
+		}
 		// Nomal JWT auth, without user ID and role, just validate the token
-		if err := authenticator.RPCAuthenticate(stream.Context(), "", info.FullMethod, ""); err != nil {
-			log.Errorf("auth failed: %s", err)
-			return errors.WithCode(code.ErrInvalidAuthHeader, "auth failed")
+		ctx, err := authorize(stream.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
 		}
-		return handler(svr, stream)
+		return handler(svr, &authServerStream{ServerStream: stream, ctx: ctx})
 	}
 }
 
-func UnaryAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+// UnaryAuthorizeInterceptor 校验一元请求的JWT，allowList 中的方法或服务跳过认证
+func UnaryAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenticator, allowList ...string) grpc.UnaryServerInterceptor {
 	if authenticator == nil {
 		var err error
 		authenticator, err = auth.NewAuthenticator(keyFunc)
@@ -41,11 +53,77 @@ func UnaryAuthorizeInterceptor(keyFunc jwt.Keyfunc, authenticator *auth.Authenti
 			return nil
 		}
 	}
+	allowed := newMethodAllowList(allowList)
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
-		if err := authenticator.RPCAuthenticate(ctx, "", info.FullMethod, ""); err != nil {
-			log.Errorf("auth failed: %s", err)
-			return nil, errors.WithCode(code.ErrInvalidAuthHeader, "auth failed")
+		if allowed.match(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err = authorize(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
 		return handler(ctx, req)
 	}
 }
+
+// authorize 从 gRPC metadata 中取出 token 进行校验，并将 claims 放入上下文
+func authorize(ctx context.Context, authenticator *auth.Authenticator, fullMethod string) (context.Context, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationKey); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	claims, err := authenticator.AuthenticateHeader(header, "", fullMethod, "")
+	if err != nil {
+		log.Errorf("auth failed: %s", err)
+		return ctx, statusError(codes.Unauthenticated, errors.WithCode(code.ErrInvalidAuthHeader, "auth failed"))
+	}
+
+	return auth.NewContext(ctx, claims), nil
+}
+
+// methodAllowList 不需要认证的方法，支持完整方法名 /pkg.Service/Method 和服务名 pkg.Service
+type methodAllowList struct {
+	methods  map[string]struct{}
+	services map[string]struct{}
+}
+
+func newMethodAllowList(allowList []string) methodAllowList {
+	l := methodAllowList{
+		methods:  make(map[string]struct{}, len(allowList)),
+		services: make(map[string]struct{}, len(allowList)),
+	}
+	for _, item := range allowList {
+		if strings.HasPrefix(item, "/") {
+			l.methods[item] = struct{}{}
+		} else if item != "" {
+			l.services[item] = struct{}{}
+		}
+	}
+	return l
+}
+
+func (l methodAllowList) match(fullMethod string) bool {
+	if _, ok := l.methods[fullMethod]; ok {
+		return true
+	}
+	// /pkg.Service/Method -> pkg.Service
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	_, ok := l.services[service]
+	return ok
+}
+
+// authServerStream 替换流的上下文，使 handler 能读取到 claims
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,62 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taluos/Malt/pkg/auth-jwt"
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuthorizeInterceptor(t *testing.T) {
+	const method = "/test.Service/Method"
+
+	jwtInfo, err := JWT.NewJwtInfo(JWT.TestPrivateKey, "uuid", method, "admin")
+	require.NoError(t, err)
+
+	interceptor := UnaryAuthorizeInterceptor(func(token *jwt.Token) (any, error) {
+		return jwt.ParseECPublicKeyFromPEM([]byte(JWT.TestPublicKey))
+	}, nil, "grpc.health.v1.Health", "/test.Service/Public")
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		claims, ok := auth.FromContext(ctx)
+		if !ok {
+			return "", nil
+		}
+		return claims.GetUserID() + ":" + claims.GetRole(), nil
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("authorization", "Bearer "+jwtInfo.Token()))
+		resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "uuid:admin", resp)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("allowed service", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil,
+			&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "", resp)
+	})
+
+	t.Run("allowed method", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil,
+			&grpc.UnaryServerInfo{FullMethod: "/test.Service/Public"}, handler)
+		assert.NoError(t, err)
+	})
+}
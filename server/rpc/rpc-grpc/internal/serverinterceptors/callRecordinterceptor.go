@@ -7,17 +7,24 @@ import (
 	"strings"
 	"time"
 
-	"github.com/taluos/Malt/pkg/log"
 	"github.com/taluos/Malt/pkg/storage"
 	"github.com/taluos/Malt/pkg/storage/models"
 
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
-func UnarySQLiteInterceptor(db *storage.SQLiteStorage) grpc.UnaryServerInterceptor {
+// UnaryCallRecordInterceptor 记录一元调用，capture 不为空时同时记录请求和响应内容
+func UnaryCallRecordInterceptor(recorder storage.Recorder, capture *storage.Capture) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		startTime := time.Now()
+		record := newRpcRecord(ctx, info.FullMethod)
+		if capture != nil {
+			record.Request = capture.Encode(req)
+		}
 
 		var resp any
 		var err error
@@ -37,37 +44,78 @@ func UnarySQLiteInterceptor(db *storage.SQLiteStorage) grpc.UnaryServerIntercept
 		select {
 		case p := <-panicChan:
 			duration := time.Since(startTime)
-			saveRpcRecord(info.FullMethod, duration, db, fmt.Sprintf("panic: %v", p))
+			saveRpcRecord(recorder, record, duration, fmt.Sprintf("panic: %v", p))
 			panic(p)
 		case <-done:
 			duration := time.Since(startTime)
-			saveRpcRecord(info.FullMethod, duration, db, err)
+			if capture != nil && err == nil {
+				record.Response = capture.Encode(resp)
+			}
+			saveRpcRecord(recorder, record, duration, err)
 			return resp, err
 		case <-ctx.Done():
 			duration := time.Since(startTime)
-			saveRpcRecord(info.FullMethod, duration, db, ctx.Err())
-			return nil, status.Error(status.Code(ctx.Err()), ctx.Err().Error())
+			// handler 可能仍在运行并写入 err，这里使用局部变量
+			cerr := contextStatusError(ctx.Err())
+			saveRpcRecord(recorder, record, duration, cerr)
+			return nil, cerr
 		}
 	}
 }
 
-func saveRpcRecord(method string, duration time.Duration, db *storage.SQLiteStorage, err any) {
+// StreamCallRecordInterceptor 在流结束时记录调用，包括持续时间、最终错误和收发的消息数，不记录消息内容
+func StreamCallRecordInterceptor(recorder storage.Recorder) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		startTime := time.Now()
+		wrapped := newServerStream(stream, stream.Context())
 
-	errorStr := fmt.Sprintf("%v", err)
+		record := func(e any) {
+			rec := newRpcRecord(stream.Context(), info.FullMethod)
+			rec.Stream = true
+			rec.SentMessages = wrapped.sent.Load()
+			rec.RecvMessages = wrapped.recv.Load()
+			saveRpcRecord(recorder, rec, time.Since(startTime), e)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				record(fmt.Sprintf("panic: %v", p))
+				panic(p)
+			}
+		}()
 
-	record := models.RpcCallRecord{
-		Method:    method,
-		Duration:  duration.Milliseconds(),
-		Error:     errorStr,
-		Timestamp: time.Now(),
+		err = handler(svr, wrapped)
+		record(err)
+		return err
 	}
+}
 
-	// 异步插入
-	go func(rec models.RpcCallRecord) {
-		if dbErr := db.Insert(&rec); dbErr != nil {
-			// 这里可以加日志记录错误
-			log.Errorf("save rpc call record error: %v\n", dbErr.Error())
-			// errors.New(fmt.Sprintf("save rpc call record error: %v\n", dbErr.Error()))
-		}
-	}(record)
+// newRpcRecord 创建调用记录，并从 ctx 中取出调用方地址和链路追踪 ID
+func newRpcRecord(ctx context.Context, method string) models.RpcCallRecord {
+	record := models.RpcCallRecord{Protocol: "grpc", Method: method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		record.Peer = p.Addr.String()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		record.TraceID = sc.TraceID().String()
+	}
+	return record
+}
+
+// saveRpcRecord 补全耗时、状态码、错误和时间戳之后交给 recorder，err 为空时不记录错误信息，
+// err 不是 error 时视为 panic，状态码记为 Internal
+func saveRpcRecord(recorder storage.Recorder, record models.RpcCallRecord, duration time.Duration, err any) {
+	record.Duration = duration.Milliseconds()
+	record.Timestamp = time.Now()
+	switch e := err.(type) {
+	case nil:
+		record.Code = uint32(codes.OK)
+	case error:
+		record.Code = uint32(status.Code(e))
+		record.Error = e.Error()
+	default:
+		record.Code = uint32(codes.Internal)
+		record.Error = fmt.Sprintf("%v", e)
+	}
+
+	recorder.Record(&record)
 }
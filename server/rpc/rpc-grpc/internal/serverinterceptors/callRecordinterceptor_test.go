@@ -0,0 +1,117 @@
+package serverinterceptors
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/taluos/Malt/pkg/storage"
+	"github.com/taluos/Malt/pkg/storage/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestStorage(t *testing.T) *storage.SQLiteStorage {
+	db := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "rpc_calls.db"), nil)
+	require.NoError(t, db.Init())
+	require.NoError(t, db.CreateTable(&models.RpcCallRecord{}))
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestStreamCallRecordInterceptor(t *testing.T) {
+	db := newTestStorage(t)
+	interceptor := StreamCallRecordInterceptor(db)
+	stream := &mockServerStream{ctx: context.Background()}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(svr any, s grpc.ServerStream) error {
+		for i := 0; i < 3; i++ {
+			_ = s.RecvMsg(nil)
+		}
+		_ = s.SendMsg(nil)
+		return status.Error(codes.Internal, "boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	db.Flush()
+	records, err := db.QueryRpcCallRecords(storage.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	assert.Equal(t, "/test.Service/Stream", record.Method)
+	assert.True(t, record.Stream)
+	assert.Equal(t, int64(1), record.SentMessages)
+	assert.Equal(t, int64(3), record.RecvMessages)
+	assert.Contains(t, record.Error, "boom")
+	assert.Equal(t, uint32(codes.Internal), record.Code)
+}
+
+func TestUnaryCallRecordInterceptor(t *testing.T) {
+	db := newTestStorage(t)
+	interceptor := UnaryCallRecordInterceptor(db, nil)
+
+	_, err := interceptor(context.Background(), wrapperspb.String("hello"), &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+
+	db.Flush()
+	records, err := db.QueryRpcCallRecords(storage.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// 成功的调用不记录错误信息，未开启采集时不记录请求和响应
+	assert.False(t, records[0].Stream)
+	assert.Empty(t, records[0].Error)
+	assert.Equal(t, uint32(codes.OK), records[0].Code)
+	assert.Empty(t, records[0].Request)
+	assert.Empty(t, records[0].Response)
+}
+
+func TestUnaryCallRecordInterceptorCapture(t *testing.T) {
+	db := newTestStorage(t)
+	interceptor := UnaryCallRecordInterceptor(db, storage.NewCapture(storage.WithRedactFields("password")))
+
+	traceID := trace.TraceID{1, 2, 3}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1},
+	}))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+
+	req, err := structpb.NewStruct(map[string]any{"user": "alice", "password": "secret"})
+	require.NoError(t, err)
+	_, err = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Login"}, func(ctx context.Context, req any) (any, error) {
+		return wrapperspb.String("token"), nil
+	})
+	require.NoError(t, err)
+
+	_, err = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Login"}, func(ctx context.Context, req any) (any, error) {
+		return wrapperspb.String("token"), status.Error(codes.Unauthenticated, "denied")
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	db.Flush()
+	records, err := db.QueryRpcCallRecords(storage.QueryOptions{TraceID: traceID.String(), OrderBy: "id"})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.JSONEq(t, `{"user":"alice","password":"[REDACTED]"}`, records[0].Request)
+	assert.Equal(t, `"token"`, records[0].Response)
+	assert.Equal(t, "10.0.0.1:5000", records[0].Peer)
+	assert.Equal(t, "grpc", records[0].Protocol)
+
+	// 失败的调用不记录响应
+	assert.Empty(t, records[1].Response)
+	assert.Equal(t, uint32(codes.Unauthenticated), records[1].Code)
+}
@@ -0,0 +1,28 @@
+package serverinterceptors
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryInflightInterceptor counts the unary requests that are being processed.
+func UnaryInflightInterceptor(inflight *atomic.Int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		inflight.Add(1)
+		defer inflight.Add(-1)
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInflightInterceptor counts the streams that are still open.
+func StreamInflightInterceptor(inflight *atomic.Int64) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		inflight.Add(1)
+		defer inflight.Add(-1)
+
+		return handler(svr, stream)
+	}
+}
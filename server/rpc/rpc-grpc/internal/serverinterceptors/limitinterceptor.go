@@ -0,0 +1,71 @@
+package serverinterceptors
+
+import (
+	"context"
+	"net"
+
+	"github.com/taluos/Malt/core/limit"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryLimitInterceptor 按 keys 指定的维度组合限流键，超过配额时返回 RESOURCE_EXHAUSTED，
+// 按用户限流时需要放在认证拦截器之后
+func UnaryLimitInterceptor(limiter limit.Limiter, keys ...limit.KeyType) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := allowLimit(ctx, limiter, keys, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamLimitInterceptor 按 keys 指定的维度对新建的流限流
+func StreamLimitInterceptor(limiter limit.Limiter, keys ...limit.KeyType) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allowLimit(stream.Context(), limiter, keys, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(svr, stream)
+	}
+}
+
+func allowLimit(ctx context.Context, limiter limit.Limiter, keys []limit.KeyType, fullMethod string) error {
+	key := limit.BuildKey(keys, func(t limit.KeyType) string {
+		if name, ok := t.Header(); ok {
+			if vals := metadata.ValueFromIncomingContext(ctx, name); len(vals) > 0 {
+				return vals[0]
+			}
+			return ""
+		}
+		switch t {
+		case limit.KeyRoute, limit.KeyMethod:
+			return fullMethod
+		case limit.KeyUser:
+			if user := limit.UserID(ctx); user != "" {
+				return user
+			}
+		}
+		return peerIP(ctx)
+	})
+	if err := limiter.Allow(ctx, key); err != nil {
+		return statusError(codes.ResourceExhausted, err)
+	}
+	return nil
+}
+
+// peerIP 返回客户端 IP，取不到时返回空字符串
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
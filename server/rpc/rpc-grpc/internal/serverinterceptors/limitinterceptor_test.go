@@ -0,0 +1,74 @@
+package serverinterceptors
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/limit"
+	authJWT "github.com/taluos/Malt/pkg/auth-jwt"
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type fakeLimiter struct {
+	keys  []string
+	quota int
+}
+
+func (l *fakeLimiter) Allow(_ context.Context, key string) error {
+	l.keys = append(l.keys, key)
+	if len(l.keys) > l.quota {
+		return limit.ErrLimitExceeded
+	}
+	return nil
+}
+
+func TestUnaryLimitInterceptor(t *testing.T) {
+	limiter := &fakeLimiter{quota: 1}
+	interceptor := UnaryLimitInterceptor(limiter, limit.KeyMethod, limit.KeyUser)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234},
+	})
+	resp, err := interceptor(ctx, nil, info, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	// 认证过的请求按用户 ID 限流
+	ctx = authJWT.NewContext(ctx, &JWT.CustomClaims{UserID: "u1"})
+	_, err = interceptor(ctx, nil, info, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if assert.Len(t, st.Details(), 1) {
+		assert.Equal(t, strconv.Itoa(code.ErrTooManyRequests), st.Details()[0].(*errdetails.ErrorInfo).Reason)
+	}
+
+	assert.Equal(t, []string{
+		"method=/test.Service/Method:user=10.0.0.1",
+		"method=/test.Service/Method:user=u1",
+	}, limiter.keys)
+}
+
+func TestStreamLimitInterceptor(t *testing.T) {
+	interceptor := StreamLimitInterceptor(&fakeLimiter{})
+
+	err := interceptor(nil, &authServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(svr any, stream grpc.ServerStream) error {
+			t.Fatal("handler should not be called")
+			return nil
+		})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
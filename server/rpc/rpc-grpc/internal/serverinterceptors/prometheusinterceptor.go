@@ -32,6 +32,31 @@ var (
 		Help:      "rpc server requests code count.",
 		Labels:    []string{"method", "code"},
 	})
+
+	metricServerStreamDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "duration_ms",
+		Help:      "rpc server streams duration(ms).",
+		Labels:    []string{"method"},
+		Buckets:   []float64{10, 50, 100, 500, 1000, 5000, 10000, 60000},
+	})
+
+	metricServerStreamCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "code_total",
+		Help:      "rpc server streams code count.",
+		Labels:    []string{"method", "code"},
+	})
+
+	metricServerStreamMsgTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "streams",
+		Name:      "msg_total",
+		Help:      "rpc server streams messages count.",
+		Labels:    []string{"method", "direction"},
+	})
 )
 
 func UnaryPrometheusInterceptor(histogramVecOpts *metric.HistogramVecOpts, counterVecOpts *metric.CounterVecOpts) grpc.UnaryServerInterceptor {
@@ -58,3 +83,29 @@ func UnaryPrometheusInterceptor(histogramVecOpts *metric.HistogramVecOpts, count
 		return resp, err
 	}
 }
+
+// StreamPrometheusInterceptor 统计流的收发消息数、持续时间和最终状态码
+func StreamPrometheusInterceptor() grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		now := time.Now()
+
+		wrapped := newServerStream(stream, stream.Context())
+		wrapped.onSend = func(_ any, err error) {
+			if err == nil {
+				metricServerStreamMsgTotal.Inc(info.FullMethod, "sent")
+			}
+		}
+		wrapped.onRecv = func(_ any, err error) {
+			if err == nil {
+				metricServerStreamMsgTotal.Inc(info.FullMethod, "received")
+			}
+		}
+
+		err := handler(svr, wrapped)
+
+		metricServerStreamDur.Observe(int64(time.Since(now)/time.Millisecond), info.FullMethod)
+		metricServerStreamCodeTotal.Inc(info.FullMethod, status.Code(err).String())
+
+		return err
+	}
+}
@@ -0,0 +1,55 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatherCounter 从默认注册表中读取带有指定标签的计数器的值
+func gatherCounter(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if v, ok := labels[label.GetName()]; ok && v != label.GetValue() {
+					continue next
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestStreamPrometheusInterceptor(t *testing.T) {
+	const method = "/test.Service/PrometheusStream"
+	interceptor := StreamPrometheusInterceptor()
+	stream := &mockServerStream{ctx: context.Background()}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: method}, func(svr any, s grpc.ServerStream) error {
+		_ = s.RecvMsg(nil)
+		_ = s.SendMsg(nil)
+		_ = s.SendMsg(nil)
+		return status.Error(codes.Aborted, "aborted")
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	assert.Equal(t, float64(2), gatherCounter(t, "rpc_server_streams_msg_total",
+		map[string]string{"method": method, "direction": "sent"}))
+	assert.Equal(t, float64(1), gatherCounter(t, "rpc_server_streams_msg_total",
+		map[string]string{"method": method, "direction": "received"}))
+	assert.Equal(t, float64(1), gatherCounter(t, "rpc_server_streams_code_total",
+		map[string]string{"method": method, "code": codes.Aborted.String()}))
+}
@@ -0,0 +1,103 @@
+// authorization check by casbin
+package serverinterceptors
+
+import (
+	"context"
+	"strconv"
+
+	rbac "github.com/taluos/Malt/core/RBAC"
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/pkg/log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// UnaryRBACAction 一元请求默认使用的 casbin action
+	UnaryRBACAction = "unary"
+	// StreamRBACAction 流式请求默认使用的 casbin action
+	StreamRBACAction = "stream"
+
+	errorDomain = "Malt"
+)
+
+// UnaryRBACInterceptor 以 gRPC FullMethod 为 obj、action 为 act 做 casbin 鉴权
+// action 为空时使用 "unary"，allowList 中的方法或服务跳过鉴权
+func UnaryRBACInterceptor(authenticator *rbac.Authenticator, action string, allowList ...string) grpc.UnaryServerInterceptor {
+	if action == "" {
+		action = UnaryRBACAction
+	}
+	allowed := newMethodAllowList(allowList)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if allowed.match(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if err := authorizeRole(ctx, authenticator, info.FullMethod, action); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRBACInterceptor 以 gRPC FullMethod 为 obj、action 为 act 做 casbin 鉴权
+// action 为空时使用 "stream"，allowList 中的方法或服务跳过鉴权
+func StreamRBACInterceptor(authenticator *rbac.Authenticator, action string, allowList ...string) grpc.StreamServerInterceptor {
+	if action == "" {
+		action = StreamRBACAction
+	}
+	allowed := newMethodAllowList(allowList)
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if allowed.match(info.FullMethod) {
+			return handler(svr, stream)
+		}
+		if err := authorizeRole(stream.Context(), authenticator, info.FullMethod, action); err != nil {
+			return err
+		}
+		return handler(svr, stream)
+	}
+}
+
+// authorizeRole 从 metadata 中取出 token，校验其中的角色
+func authorizeRole(ctx context.Context, authenticator *rbac.Authenticator, fullMethod, action string) error {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationKey); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	token, err := JWT.ParseTokenFromHTTPContext(header)
+	if err != nil {
+		log.Errorf("rbac failed: %s", err)
+		return statusError(codes.Unauthenticated, errors.WithCode(code.ErrInvalidAuthHeader, "missing or invalid authorization token"))
+	}
+
+	if err = authenticator.Authorize(token, fullMethod, action); err != nil {
+		log.Errorf("rbac failed: %s", err)
+		if errors.IsCode(err, code.ErrInvalidAuthHeader) {
+			return statusError(codes.Unauthenticated, err)
+		}
+		return statusError(codes.PermissionDenied, err)
+	}
+
+	return nil
+}
+
+// statusError 将项目错误码放在 ErrorInfo 中，随 gRPC 状态一起返回给客户端
+func statusError(c codes.Code, err error) error {
+	coder := errors.ParseCoder(err)
+	st := status.New(c, coder.String())
+	if ds, derr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: strconv.Itoa(coder.Code()),
+		Domain: errorDomain,
+	}); derr == nil {
+		st = ds
+	}
+	return st.Err()
+}
@@ -0,0 +1,79 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	rbac "github.com/taluos/Malt/core/RBAC"
+	casbinAdapter "github.com/taluos/Malt/core/RBAC/Casbin"
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testRBACModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
+`
+
+func TestUnaryRBACInterceptor(t *testing.T) {
+	m, err := model.NewModelFromString(testRBACModel)
+	require.NoError(t, err)
+	e, err := casbin.NewEnforcer(m)
+	require.NoError(t, err)
+	_, err = e.AddPolicy("admin", "/test.Service/Method", UnaryRBACAction)
+	require.NoError(t, err)
+
+	authenticator, err := rbac.NewAuthenticator(JWT.TestPublicKey, &casbinAdapter.RBACEnforcer{Enforcer: e})
+	require.NoError(t, err)
+
+	interceptor := UnaryRBACInterceptor(authenticator, "", "grpc.health.v1.Health")
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	tokenCtx := func(role string) context.Context {
+		jwtInfo, err := JWT.NewJwtInfo(JWT.TestPrivateKey, "uuid", "/test.Service/Method", role)
+		require.NoError(t, err)
+		return metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("authorization", "Bearer "+jwtInfo.Token()))
+	}
+
+	t.Run("permitted", func(t *testing.T) {
+		resp, err := interceptor(tokenCtx("admin"), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		_, err := interceptor(tokenCtx("editor"), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("allowed service", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,51 @@
+package serverinterceptors
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// serverStream 包装 grpc.ServerStream，统计收发成功的消息数，并可以替换流的上下文
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	sent atomic.Int64
+	recv atomic.Int64
+
+	// onSend/onRecv 每次收发消息之后调用，可以为空
+	onSend func(msg any, err error)
+	onRecv func(msg any, err error)
+}
+
+func newServerStream(stream grpc.ServerStream, ctx context.Context) *serverStream {
+	return &serverStream{ServerStream: stream, ctx: ctx}
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	if s.onSend != nil {
+		s.onSend(m, err)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recv.Add(1)
+	}
+	if s.onRecv != nil {
+		s.onRecv(m, err)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/taluos/Malt/core/load"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnarySheddingInterceptor 服务过载时直接返回 RESOURCE_EXHAUSTED，不再进入 handler
+func UnarySheddingInterceptor(shedder load.Shedder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		promise, err := shedder.Allow()
+		if err != nil {
+			return nil, statusError(codes.ResourceExhausted, err)
+		}
+
+		defer failOnPanic(promise)
+		resp, err := handler(ctx, req)
+		finish(promise, err)
+		return resp, err
+	}
+}
+
+// StreamSheddingInterceptor 服务过载时拒绝新的流
+func StreamSheddingInterceptor(shedder load.Shedder) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		promise, err := shedder.Allow()
+		if err != nil {
+			return statusError(codes.ResourceExhausted, err)
+		}
+
+		defer failOnPanic(promise)
+		err = handler(svr, stream)
+		finish(promise, err)
+		return err
+	}
+}
+
+// finish 超时的请求不计入通过数和延迟
+func finish(promise load.Promise, err error) {
+	if status.Code(err) == codes.DeadlineExceeded || err == context.DeadlineExceeded {
+		promise.Fail()
+		return
+	}
+	promise.Pass()
+}
+
+// failOnPanic handler panic 时释放 promise 并继续向外抛出，由外层的 Recover 拦截器处理
+func failOnPanic(promise load.Promise) {
+	if r := recover(); r != nil {
+		promise.Fail()
+		panic(r)
+	}
+}
@@ -0,0 +1,84 @@
+package serverinterceptors
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/taluos/Malt/core/load"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeShedder struct {
+	drop   bool
+	passed int
+	failed int
+}
+
+func (s *fakeShedder) Allow() (load.Promise, error) {
+	if s.drop {
+		return nil, load.ErrServiceOverloaded
+	}
+	return s, nil
+}
+
+func (s *fakeShedder) Pass() { s.passed++ }
+func (s *fakeShedder) Fail() { s.failed++ }
+
+func TestUnarySheddingInterceptor(t *testing.T) {
+	shedder := &fakeShedder{}
+	interceptor := UnarySheddingInterceptor(shedder)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, 1, shedder.passed)
+
+	// 超时的请求不计入通过数
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.DeadlineExceeded, "timeout")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, shedder.failed)
+
+	// handler panic 时同样释放 promise，panic 继续向外抛出
+	assert.Panics(t, func() {
+		_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 2, shedder.failed)
+
+	shedder.drop = true
+	var called bool
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		called = true
+		return nil, nil
+	})
+	assert.False(t, called)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if assert.Len(t, st.Details(), 1) {
+		assert.Equal(t, strconv.Itoa(code.ErrServiceOverloaded), st.Details()[0].(*errdetails.ErrorInfo).Reason)
+	}
+}
+
+func TestStreamSheddingInterceptor(t *testing.T) {
+	shedder := &fakeShedder{drop: true}
+	interceptor := StreamSheddingInterceptor(shedder)
+
+	err := interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(svr any, stream grpc.ServerStream) error {
+		t.Fatal("handler should not be called")
+		return nil
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -84,6 +84,63 @@ func UnaryTimeoutInterceptor(timeout time.Duration, methodTimeouts ...MethodTime
 	}
 }
 
+// StreamTimeoutInterceptor 为流设置整体的截止时间，timeout <= 0 时不限制。
+// 流的处理函数无法被中断，超时之后收发消息直接返回 DEADLINE_EXCEEDED，
+// 长时间阻塞的处理函数需要通过 stream.Context() 感知超时
+func StreamTimeoutInterceptor(timeout time.Duration, methodTimeouts ...MethodTimeoutConf) grpc.StreamServerInterceptor {
+	timeouts := buildMethodTimeouts(methodTimeouts)
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t := getTimeoutByUnaryServerInfo(info.FullMethod, timeouts, timeout)
+		if t <= 0 {
+			return handler(svr, stream)
+		}
+
+		ctx, cancel := context.WithTimeout(stream.Context(), t)
+		defer cancel()
+
+		err := handler(svr, &timeoutServerStream{ServerStream: stream, ctx: ctx})
+		if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return contextStatusError(err)
+		}
+		return err
+	}
+}
+
+// timeoutServerStream 超时之后不再收发消息
+type timeoutServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *timeoutServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *timeoutServerStream) SendMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return contextStatusError(err)
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *timeoutServerStream) RecvMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return contextStatusError(err)
+	}
+	return s.ServerStream.RecvMsg(m)
+}
+
+// contextStatusError 把上下文的错误转换为对应的 gRPC 状态
+func contextStatusError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, context.Canceled.Error())
+	}
+	return err
+}
+
 func buildMethodTimeouts(timeouts []MethodTimeoutConf) methodTimeouts {
 	// 构建方法超时配置映射
 	mt := make(methodTimeouts, len(timeouts))
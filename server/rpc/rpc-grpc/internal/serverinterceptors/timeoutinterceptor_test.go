@@ -243,3 +243,50 @@ func TestUnaryTimeoutInterceptor_SpecifiedTimeout(t *testing.T) {
 		})
 	}
 }
+
+// mockServerStream 收发消息时只计数的 grpc.ServerStream
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent int
+	recv int
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *mockServerStream) SendMsg(any) error {
+	s.sent++
+	return nil
+}
+
+func (s *mockServerStream) RecvMsg(any) error {
+	s.recv++
+	return nil
+}
+
+func TestStreamTimeoutInterceptor(t *testing.T) {
+	interceptor := StreamTimeoutInterceptor(0, MethodTimeoutConf{FullMethod: "/test.Service/Stream", Timeout: 10 * time.Millisecond})
+	stream := &mockServerStream{ctx: context.Background()}
+
+	// 没有配置超时的方法不设置截止时间
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/test.Service/Other"}, func(svr any, s grpc.ServerStream) error {
+		_, ok := s.Context().Deadline()
+		assert.False(t, ok)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(svr any, s grpc.ServerStream) error {
+		assert.NoError(t, s.SendMsg(nil))
+		<-s.Context().Done()
+		// 超时之后不再收发消息
+		assert.EqualValues(t, deadlineExceededErr, s.SendMsg(nil))
+		assert.EqualValues(t, deadlineExceededErr, s.RecvMsg(nil))
+		return s.Context().Err()
+	})
+	assert.EqualValues(t, deadlineExceededErr, err)
+	assert.Equal(t, 1, stream.sent)
+	assert.Equal(t, 0, stream.recv)
+}
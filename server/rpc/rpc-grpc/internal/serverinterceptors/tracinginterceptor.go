@@ -5,10 +5,11 @@ import (
 
 	maltAgent "github.com/taluos/Malt/core/trace"
 
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func UnaryTracingInterceptor(agent *maltAgent.Agent) grpc.UnaryServerInterceptor {
@@ -20,10 +21,55 @@ func UnaryTracingInterceptor(agent *maltAgent.Agent) grpc.UnaryServerInterceptor
 		if !ok {
 			md = metadata.New(nil)
 		}
-		carrier := propagation.HeaderCarrier(md)
+		carrier := maltAgent.MetadataCarrier(md)
 		spanCtx, span := tr.Start(ctx, info.FullMethod, agent.Propagator(), carrier)
 		resp, err := handler(spanCtx, req)
 		defer tr.End(spanCtx, span, err)
 		return resp, err
 	}
 }
+
+// StreamTracingInterceptor 为每个流创建一个 span，收发的每条消息记录为 span 事件
+func StreamTracingInterceptor(agent *maltAgent.Agent) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		tr := maltAgent.NewTracer(trace.SpanKindServer,
+			maltAgent.WithTracerProvider(agent.TracerProvider()),
+			maltAgent.WithTracerName(info.FullMethod))
+		md, ok := metadata.FromIncomingContext(stream.Context())
+		if !ok {
+			md = metadata.New(nil)
+		}
+		carrier := maltAgent.MetadataCarrier(md)
+		spanCtx, span := tr.Start(stream.Context(), info.FullMethod, agent.Propagator(), carrier)
+
+		wrapped := newServerStream(stream, spanCtx)
+		wrapped.onSend = func(_ any, err error) {
+			if err == nil {
+				messageEvent(span, "SENT", wrapped.sent.Load())
+			}
+		}
+		wrapped.onRecv = func(_ any, err error) {
+			if err == nil {
+				messageEvent(span, "RECEIVED", wrapped.recv.Load())
+			}
+		}
+
+		err := handler(svr, wrapped)
+
+		span.SetAttributes(
+			attribute.Int64("rpc.grpc.sent_messages", wrapped.sent.Load()),
+			attribute.Int64("rpc.grpc.received_messages", wrapped.recv.Load()),
+			attribute.Int("rpc.grpc.status_code", int(status.Code(err))),
+		)
+		tr.End(spanCtx, span, err)
+		return err
+	}
+}
+
+// messageEvent 按 OpenTelemetry rpc 语义约定记录一条消息事件
+func messageEvent(span trace.Span, typ string, id int64) {
+	span.AddEvent("message", trace.WithAttributes(
+		attribute.String("message.type", typ),
+		attribute.Int64("message.id", id),
+	))
+}
@@ -6,9 +6,13 @@ import (
 	"time"
 
 	"github.com/taluos/Malt/api/metadata"
+	rbac "github.com/taluos/Malt/core/RBAC"
+	"github.com/taluos/Malt/core/limit"
+	"github.com/taluos/Malt/core/load"
 	metric "github.com/taluos/Malt/core/metrics"
 	maltAgent "github.com/taluos/Malt/core/trace"
 	auth "github.com/taluos/Malt/pkg/auth-jwt"
+	"github.com/taluos/Malt/pkg/storage"
 
 	"github.com/go-playground/validator/v10"
 	"google.golang.org/grpc"
@@ -21,6 +25,10 @@ type serverOptions struct {
 	endpoint *url.URL      `validate:"required"`       // 服务器端点URL: grpc://ip:port
 	timeout  time.Duration `validate:"required,gte=0"` // 超时时间
 
+	streamTimeout time.Duration    // 流的整体超时时间，为 0 时不限制
+	callRecorder  storage.Recorder // 调用记录存储
+	callCapture   *storage.Capture // 调用记录的请求和响应采集，为空时不采集
+
 	enableTracing     bool `validate:"required"` // 是否启用追踪
 	enableMetrics     bool `validate:"required"` // 是否启用指标
 	enableHealthCheck bool `validate:"required"` // 是否启用健康检查
@@ -38,8 +46,15 @@ type serverOptions struct {
 	metadata    *metadata.Server // 元数据服务器
 	healthCheck *health.Server   // 健康检查服务器
 
-	JWTauthenticator *auth.Authenticator // 认证器
-	agent            *maltAgent.Agent
+	JWTauthenticator  *auth.Authenticator // 认证器
+	authAllowList     []string            // 跳过认证的方法或服务
+	rbacAuthenticator *rbac.Authenticator // casbin 鉴权器
+	rbacAction        string              // casbin 鉴权使用的 action，为空时区分 unary/stream
+	enableShedding    bool                // 是否启用自适应降载
+	sheddingOpts      []load.ShedderOption
+	limiter           limit.Limiter   // 限流器
+	limitKeys         []limit.KeyType // 限流键的维度
+	agent             *maltAgent.Agent
 }
 
 func (o *serverOptions) Validate() error {
@@ -66,6 +81,43 @@ func WithAuthenticator(authenticator *auth.Authenticator) ServerOptions {
 	}
 }
 
+// WithAuthAllowList 追加跳过JWT认证的方法（/pkg.Service/Method）或服务（pkg.Service）
+func WithAuthAllowList(methods ...string) ServerOptions {
+	return func(s *serverOptions) {
+		s.authAllowList = append(s.authAllowList, methods...)
+	}
+}
+
+// WithRBAC 启用基于 casbin 的角色鉴权，obj 为 gRPC FullMethod
+func WithRBAC(authenticator *rbac.Authenticator) ServerOptions {
+	return func(s *serverOptions) {
+		s.rbacAuthenticator = authenticator
+	}
+}
+
+// WithRBACAction 设置鉴权使用的 action，默认一元请求为 "unary"，流式请求为 "stream"
+func WithRBACAction(action string) ServerOptions {
+	return func(s *serverOptions) {
+		s.rbacAction = action
+	}
+}
+
+// WithShedding 启用自适应降载，过载时返回 RESOURCE_EXHAUSTED
+func WithShedding(opts ...load.ShedderOption) ServerOptions {
+	return func(o *serverOptions) {
+		o.enableShedding = true
+		o.sheddingOpts = append(o.sheddingOpts, opts...)
+	}
+}
+
+// WithLimiter 启用限流，按 keys 指定的维度组合限流键，超过配额时返回 RESOURCE_EXHAUSTED
+func WithLimiter(limiter limit.Limiter, keys ...limit.KeyType) ServerOptions {
+	return func(o *serverOptions) {
+		o.limiter = limiter
+		o.limitKeys = keys
+	}
+}
+
 func WithAgent(agent *maltAgent.Agent) ServerOptions {
 	return func(s *serverOptions) {
 		s.agent = agent
@@ -120,6 +172,27 @@ func WithTimeout(timeout time.Duration) ServerOptions {
 	}
 }
 
+// WithStreamTimeout 设置流的整体超时时间，流通常是长连接，默认不限制
+func WithStreamTimeout(timeout time.Duration) ServerOptions {
+	return func(s *serverOptions) {
+		s.streamTimeout = timeout
+	}
+}
+
+// WithCallRecord 把一元调用和流的调用记录交给 recorder 异步写入，recorder 由调用方关闭
+func WithCallRecord(recorder storage.Recorder) ServerOptions {
+	return func(s *serverOptions) {
+		s.callRecorder = recorder
+	}
+}
+
+// WithCallRecordPayload 在调用记录中采集一元调用的请求和响应，需要同时设置 WithCallRecord
+func WithCallRecordPayload(opts ...storage.CaptureOption) ServerOptions {
+	return func(s *serverOptions) {
+		s.callCapture = storage.NewCapture(opts...)
+	}
+}
+
 func WithEnableTracing(enableTracing bool) ServerOptions {
 	return func(s *serverOptions) {
 		s.enableTracing = enableTracing
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"net"
 	"net/url"
+	"sync"
+	"sync/atomic"
 
 	"github.com/taluos/Malt/api/metadata"
+	"github.com/taluos/Malt/core/load"
 	"github.com/taluos/Malt/core/resolver/discovery"
 	"github.com/taluos/Malt/pkg/host"
 	"github.com/taluos/Malt/pkg/log"
@@ -24,6 +27,11 @@ type Server struct {
 	rootCtx      context.Context
 	opt          *serverOptions   // 服务器选项
 	metadata     *metadata.Server // 元数据服务器
+
+	ready     chan struct{} // 开始监听后关闭
+	readyOnce sync.Once
+
+	inflight atomic.Int64 // 正在处理的请求数
 }
 
 // NewServer 创建一个新的gRPC服务器实例
@@ -35,6 +43,8 @@ func NewServer(opts ...ServerOptions) *Server {
 		healthCheck: health.NewServer(),
 		timeout:     defaultTimeout,
 
+		authAllowList: append([]string{}, defaultAuthAllowList...),
+
 		enableTracing:     false,
 		enableMetrics:     false,
 		enableHealthCheck: true,
@@ -50,11 +60,24 @@ func NewServer(opts ...ServerOptions) *Server {
 		return nil
 	}
 
+	s := &Server{
+		opt:   o,
+		ready: make(chan struct{}),
+	}
+
 	uraryInts := []grpc.UnaryServerInterceptor{
+		serverinterceptors.UnaryInflightInterceptor(&s.inflight),
 		serverinterceptors.UnaryRecoverInterceptor,
-		serverinterceptors.UnaryTimeoutInterceptor(o.timeout),
 	}
 
+	// 过载时尽早拒绝请求，降载器以服务器名称作为指标标签
+	var shedder load.Shedder
+	if o.enableShedding {
+		shedder = load.NewAdaptiveShedder(append([]load.ShedderOption{load.WithName(o.name)}, o.sheddingOpts...)...)
+		uraryInts = append(uraryInts, serverinterceptors.UnarySheddingInterceptor(shedder))
+	}
+	uraryInts = append(uraryInts, serverinterceptors.UnaryTimeoutInterceptor(o.timeout))
+
 	if o.enableMetrics {
 		uraryInts = append(uraryInts,
 			serverinterceptors.UnaryPrometheusInterceptor(o.histogramVecOpts, o.counterVecOpts))
@@ -66,13 +89,59 @@ func NewServer(opts ...ServerOptions) *Server {
 			serverinterceptors.UnaryTracingInterceptor(o.agent))
 	}
 
+	if o.callRecorder != nil {
+		uraryInts = append(uraryInts, serverinterceptors.UnaryCallRecordInterceptor(o.callRecorder, o.callCapture))
+	}
+
+	// 配置了认证器时启用JWT认证，claims 会放入 handler 的上下文
+	if o.JWTauthenticator != nil {
+		uraryInts = append(uraryInts,
+			serverinterceptors.UnaryAuthorizeInterceptor(nil, o.JWTauthenticator, o.authAllowList...))
+	}
+
+	// 配置了鉴权器时按角色校验方法权限
+	if o.rbacAuthenticator != nil {
+		uraryInts = append(uraryInts,
+			serverinterceptors.UnaryRBACInterceptor(o.rbacAuthenticator, o.rbacAction, o.authAllowList...))
+	}
+
+	// 限流放在认证之后，按用户限流时可以取到 JWT 中的用户 ID
+	if o.limiter != nil {
+		uraryInts = append(uraryInts, serverinterceptors.UnaryLimitInterceptor(o.limiter, o.limitKeys...))
+	}
+
 	if len(o.unaryInterceptors) > 0 {
 		uraryInts = append(uraryInts, o.unaryInterceptors...)
 	}
 
 	streamInts := []grpc.StreamServerInterceptor{
+		serverinterceptors.StreamInflightInterceptor(&s.inflight),
 		serverinterceptors.StreamRecoverInterceptor,
 	}
+	if shedder != nil {
+		streamInts = append(streamInts, serverinterceptors.StreamSheddingInterceptor(shedder))
+	}
+	streamInts = append(streamInts, serverinterceptors.StreamTimeoutInterceptor(o.streamTimeout))
+	if o.enableMetrics {
+		streamInts = append(streamInts, serverinterceptors.StreamPrometheusInterceptor())
+	}
+	if o.enableTracing && o.agent != nil {
+		streamInts = append(streamInts, serverinterceptors.StreamTracingInterceptor(o.agent))
+	}
+	if o.callRecorder != nil {
+		streamInts = append(streamInts, serverinterceptors.StreamCallRecordInterceptor(o.callRecorder))
+	}
+	if o.JWTauthenticator != nil {
+		streamInts = append(streamInts,
+			serverinterceptors.SteamAuthorizeInterceptor(nil, o.JWTauthenticator, o.authAllowList...))
+	}
+	if o.rbacAuthenticator != nil {
+		streamInts = append(streamInts,
+			serverinterceptors.StreamRBACInterceptor(o.rbacAuthenticator, o.rbacAction, o.authAllowList...))
+	}
+	if o.limiter != nil {
+		streamInts = append(streamInts, serverinterceptors.StreamLimitInterceptor(o.limiter, o.limitKeys...))
+	}
 	if len(o.streamInterceptors) > 0 {
 		streamInts = append(streamInts, o.streamInterceptors...)
 	}
@@ -87,9 +156,8 @@ func NewServer(opts ...ServerOptions) *Server {
 		grpcOptions = append(grpcOptions, o.grpcOpts...)
 	}
 
-	s := &Server{
-		opt: o,
-	}
+	// 在服务器真正开始监听之前，健康检查保持 NOT_SERVING
+	s.opt.healthCheck.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 
 	// 创建grpc server
 	s.Server = grpc.NewServer(grpcOptions...)
@@ -130,6 +198,7 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Infof("[gRPC] server listening at %s", s.opt.address)
 
 	s.opt.healthCheck.Resume()
+	s.readyOnce.Do(func() { close(s.ready) })
 
 	err = s.Server.Serve(s.opt.listener)
 	if err != nil {
@@ -169,6 +238,22 @@ func (s *Server) Stop(ctx context.Context) error {
 	return nil
 }
 
+// Ready returns a channel that is closed once the server is listening.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Drain marks the server as NOT_SERVING so that clients stop sending new requests.
+func (s *Server) Drain() {
+	log.Infof("[gRPC] server draining, %d requests in flight", s.inflight.Load())
+	s.opt.healthCheck.Shutdown()
+}
+
+// Inflight returns the number of requests that are being processed.
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
 // Endpoint return a real address to registry endpoint.
 func (s *Server) Endpoint() (*url.URL, error) {
 	if err := s.listenAndEndpoint(); err != nil {
@@ -177,6 +262,17 @@ func (s *Server) Endpoint() (*url.URL, error) {
 	return s.opt.endpoint, nil
 }
 
+// CloseListener closes the listener opened by Endpoint when the server will not be started,
+// such as when the app fails to start.
+func (s *Server) CloseListener() error {
+	if s.opt.listener == nil {
+		return nil
+	}
+	err := s.opt.listener.Close()
+	s.opt.listener, s.opt.endpoint = nil, nil
+	return err
+}
+
 func (s *Server) listenAndEndpoint() error {
 
 	// 如果用户已经设置了listener，则直接使用用户设置的listener
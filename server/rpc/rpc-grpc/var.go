@@ -8,3 +8,11 @@ const (
 	defaultAddress    = "127.0.0.1:8080"
 	defaultTimeout    = 5 * time.Second
 )
+
+// defaultAuthAllowList 默认跳过JWT认证的服务：健康检查、反射以及元数据服务
+var defaultAuthAllowList = []string{
+	"grpc.health.v1.Health",
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+	"kratos.api.Metadata",
+}
@@ -0,0 +1,72 @@
+// auth check by jwt
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"github.com/taluos/Malt/pkg/auth-jwt"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/pkg/log"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+// AuthorizationKey 客户端通过 metainfo 透传 token 时使用的 key
+const AuthorizationKey = "authorization"
+
+// AuthorizeMiddleware 校验请求的JWT并将 claims 放入上下文，allowList 中的方法或服务跳过认证
+func AuthorizeMiddleware(authenticator *auth.Authenticator, allowList ...string) endpoint.Middleware {
+	allowed := newMethodAllowList(allowList)
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			method := fullMethod(ctx)
+			if allowed.match(method) {
+				return next(ctx, req, resp)
+			}
+			header, _ := metainfo.GetValue(ctx, AuthorizationKey)
+			claims, err := authenticator.AuthenticateHeader(header, "", method, "")
+			if err != nil {
+				log.Errorf("auth failed: %s", err)
+				return errors.WithCode(code.ErrInvalidAuthHeader, "auth failed")
+			}
+			return next(auth.NewContext(ctx, claims), req, resp)
+		}
+	}
+}
+
+// methodAllowList 不需要认证的方法，支持完整方法名 /service/method 和服务名 service
+type methodAllowList struct {
+	methods  map[string]struct{}
+	services map[string]struct{}
+}
+
+func newMethodAllowList(allowList []string) methodAllowList {
+	l := methodAllowList{
+		methods:  make(map[string]struct{}, len(allowList)),
+		services: make(map[string]struct{}, len(allowList)),
+	}
+	for _, item := range allowList {
+		if strings.HasPrefix(item, "/") {
+			l.methods[item] = struct{}{}
+		} else if item != "" {
+			l.services[item] = struct{}{}
+		}
+	}
+	return l
+}
+
+func (l methodAllowList) match(fullMethod string) bool {
+	if _, ok := l.methods[fullMethod]; ok {
+		return true
+	}
+	// /service/method -> service
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	_, ok := l.services[service]
+	return ok
+}
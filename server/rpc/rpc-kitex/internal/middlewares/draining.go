@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+// DrainingMiddleware rejects new requests once the server is draining.
+// kitex 没有健康检查服务，排空阶段直接拒绝新请求，客户端可以重试到其他实例
+func DrainingMiddleware(draining *atomic.Bool) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			if draining.Load() {
+				return errors.WithCode(code.ErrServiceDraining, "%s: server is draining", fullMethod(ctx))
+			}
+			return next(ctx, req, resp)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+// InflightMiddleware counts the requests that are being processed.
+func InflightMiddleware(inflight *atomic.Int64) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			inflight.Add(1)
+			defer inflight.Add(-1)
+
+			return next(ctx, req, resp)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	metric "github.com/taluos/Malt/core/metrics"
+	"github.com/taluos/Malt/pkg/errors"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+const (
+	// ServerNamespace defines a logical grouping of servers.
+	ServerNamespace = "kitex_server"
+)
+
+var (
+	metricServerReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "requests",
+		Name:      "requests_duration_seconds",
+		Help:      "kitex server requests duration(ms).",
+		Labels:    []string{"method"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	metricServerReqCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: ServerNamespace,
+		Subsystem: "requests",
+		Name:      "code_total",
+		Help:      "kitex server requests code count.",
+		Labels:    []string{"method", "code"},
+	})
+)
+
+func PrometheusMiddleware(histogramVecOpts *metric.HistogramVecOpts, counterVecOpts *metric.CounterVecOpts) endpoint.Middleware {
+
+	if histogramVecOpts != nil {
+		metricServerReqDur = metric.NewHistogramVec(histogramVecOpts)
+	}
+
+	if counterVecOpts != nil {
+		metricServerReqCodeTotal = metric.NewCounterVec(counterVecOpts)
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			now := time.Now()
+
+			err := next(ctx, req, resp)
+
+			method := fullMethod(ctx)
+			// 记录耗时
+			metricServerReqDur.Observe(int64(time.Since(now)/time.Millisecond), method)
+
+			// 记录状态码
+			metricServerReqCodeTotal.Inc(method, errorCode(err))
+
+			return err
+		}
+	}
+}
+
+func errorCode(err error) string {
+	if err == nil {
+		return "OK"
+	}
+	return strconv.Itoa(errors.ParseCoder(err).Code())
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/pkg/log"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+// RecoverMiddleware catches panics in processing requests and recovers.
+func RecoverMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp any) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Errorf("Panic occurred: %+v\n\n%s", r, debug.Stack())
+				err = errors.WithCode(code.ErrUnknow, "panic: %v", r)
+			}
+		}()
+
+		return next(ctx, req, resp)
+	}
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+)
+
+// TimeoutMiddleware sets timeout to incoming requests.
+// kitex 的响应由 handler 原地写入 resp，不能像 gRPC 一样在超时后放弃 handler 的 goroutine，
+// 这里只为 ctx 设置截止时间，由 handler 自行感知取消。
+func TimeoutMiddleware(timeout time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			if timeout <= 0 {
+				return next(ctx, req, resp)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			err := next(ctx, req, resp)
+			if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.WithCode(code.ErrDeadlineExceeded, "%s: %s", fullMethod(ctx), ctx.Err())
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"context"
+
+	maltAgent "github.com/taluos/Malt/core/trace"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TracingMiddleware(agent *maltAgent.Agent) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp any) error {
+			method := fullMethod(ctx)
+			tr := maltAgent.NewTracer(trace.SpanKindServer,
+				maltAgent.WithTracerProvider(agent.TracerProvider()),
+				maltAgent.WithTracerName(method))
+
+			// kitex 通过 metainfo 透传上游的链路信息
+			carrier := propagation.MapCarrier(metainfo.GetAllValues(ctx))
+			spanCtx, span := tr.Start(ctx, method, agent.Propagator(), carrier)
+			err := next(spanCtx, req, resp)
+			defer tr.End(spanCtx, span, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package kitex
+
+import (
+	"net"
+	"net/url"
+	"time"
+
+	metric "github.com/taluos/Malt/core/metrics"
+	maltAgent "github.com/taluos/Malt/core/trace"
+	auth "github.com/taluos/Malt/pkg/auth-jwt"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/server"
+)
+
+type serverOptions struct {
+	name     string        // 服务器名称
+	address  string        // 服务器监听地址: ip:port
+	endpoint *url.URL      // 服务器端点URL: kitex://ip:port
+	timeout  time.Duration // 超时时间
+
+	enableTracing  bool // 是否启用追踪
+	enableMetrics  bool // 是否启用指标
+	enableInsecure bool // 是否启用不安全连接
+
+	histogramVecOpts *metric.HistogramVecOpts
+	counterVecOpts   *metric.CounterVecOpts
+
+	middlewares []endpoint.Middleware // 中间件列表
+	kitexOpts   []server.Option       // kitex服务器选项
+
+	listener net.Listener // 网络监听器
+
+	JWTauthenticator *auth.Authenticator // 认证器
+	authAllowList    []string            // 跳过认证的方法或服务
+	agent            *maltAgent.Agent
+}
+
+// ServerOptions 定义了自定义服务器选项的方法
+type ServerOptions func(s *serverOptions)
+
+func WithName(name string) ServerOptions {
+	return func(s *serverOptions) {
+		s.name = name
+	}
+}
+
+func WithAuthenticator(authenticator *auth.Authenticator) ServerOptions {
+	return func(s *serverOptions) {
+		s.JWTauthenticator = authenticator
+	}
+}
+
+// WithAuthAllowList 追加跳过JWT认证的方法（/Service/Method）或服务（Service）
+func WithAuthAllowList(methods ...string) ServerOptions {
+	return func(s *serverOptions) {
+		s.authAllowList = append(s.authAllowList, methods...)
+	}
+}
+
+func WithAgent(agent *maltAgent.Agent) ServerOptions {
+	return func(s *serverOptions) {
+		s.agent = agent
+	}
+}
+
+func WithAddress(address string) ServerOptions {
+	return func(s *serverOptions) {
+		s.address = address
+	}
+}
+
+func WithEndpoint(endpoint *url.URL) ServerOptions {
+	return func(s *serverOptions) {
+		s.endpoint = endpoint
+	}
+}
+
+func WithListener(listener net.Listener) ServerOptions {
+	return func(s *serverOptions) {
+		s.listener = listener
+	}
+}
+
+func WithMiddlewares(middlewares ...endpoint.Middleware) ServerOptions {
+	return func(s *serverOptions) {
+		s.middlewares = middlewares
+	}
+}
+
+func WithOptions(opts ...server.Option) ServerOptions {
+	return func(s *serverOptions) {
+		s.kitexOpts = opts
+	}
+}
+
+func WithTimeout(timeout time.Duration) ServerOptions {
+	return func(s *serverOptions) {
+		s.timeout = timeout
+	}
+}
+
+func WithEnableTracing(enableTracing bool) ServerOptions {
+	return func(s *serverOptions) {
+		s.enableTracing = enableTracing
+	}
+}
+
+func WithEnableMetrics(enableMetrics bool) ServerOptions {
+	return func(s *serverOptions) {
+		s.enableMetrics = enableMetrics
+	}
+}
+
+func WithHistogramVecOpts(opts *metric.HistogramVecOpts) ServerOptions {
+	return func(s *serverOptions) {
+		s.histogramVecOpts = opts
+	}
+}
+
+func WithCounterVecOpts(opts *metric.CounterVecOpts) ServerOptions {
+	return func(s *serverOptions) {
+		s.counterVecOpts = opts
+	}
+}
+
+func WithEnableInsecure(enableInsecure bool) ServerOptions {
+	return func(s *serverOptions) {
+		s.enableInsecure = enableInsecure
+	}
+}
@@ -0,0 +1,276 @@
+package kitex
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	"github.com/taluos/Malt/core/resolver/discovery"
+	"github.com/taluos/Malt/pkg/host"
+	"github.com/taluos/Malt/pkg/log"
+	"github.com/taluos/Malt/server/rpc/rpc-kitex/internal/middlewares"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"github.com/cloudwego/kitex/server"
+)
+
+// Server 结构体定义了Kitex服务器的基本属性和配置
+type Server struct {
+	server.Server // kitex服务器实例，第一次使用时创建
+	opt           *serverOptions
+
+	initOnce sync.Once
+	initErr  error
+
+	ready     chan struct{} // 开始监听后关闭
+	readyOnce sync.Once
+	exit      chan error // 关闭后 kitex 的 Run 返回
+	exitOnce  sync.Once
+	done      chan struct{} // Run 返回后关闭
+
+	inflight atomic.Int64 // 正在处理的请求数
+	draining atomic.Bool  // 是否处于下线排空阶段
+}
+
+// NewServer 创建一个新的Kitex服务器实例
+func NewServer(opts ...ServerOptions) *Server {
+	o := &serverOptions{
+		name:    defaultServerName,
+		address: defaultAddress,
+		timeout: defaultTimeout,
+
+		enableTracing: false,
+		enableMetrics: false,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Server{
+		opt:   o,
+		ready: make(chan struct{}),
+		exit:  make(chan error),
+		done:  make(chan struct{}),
+	}
+}
+
+// engine 监听端口并创建 kitex 服务器
+// kitex 的选项在创建时就固定了，而监听器需要等到 Endpoint 或 Start 时才能确定，所以延迟创建
+func (s *Server) engine() (server.Server, error) {
+	s.initOnce.Do(func() {
+		if s.opt.address == "" && s.opt.listener == nil {
+			s.initErr = errors.New("[Kitex] server address cannot be empty")
+			return
+		}
+
+		if s.initErr = s.listenAndEndpoint(); s.initErr != nil {
+			log.Errorf("[Kitex] Get endpoint failed: %s", s.initErr)
+			return
+		}
+
+		mws := []endpoint.Middleware{
+			middlewares.DrainingMiddleware(&s.draining),
+			middlewares.InflightMiddleware(&s.inflight),
+			middlewares.RecoverMiddleware,
+			middlewares.TimeoutMiddleware(s.opt.timeout),
+		}
+
+		if s.opt.enableMetrics {
+			mws = append(mws,
+				middlewares.PrometheusMiddleware(s.opt.histogramVecOpts, s.opt.counterVecOpts))
+		}
+
+		if s.opt.enableTracing && s.opt.agent != nil {
+			mws = append(mws, middlewares.TracingMiddleware(s.opt.agent))
+		}
+
+		if s.opt.JWTauthenticator != nil {
+			mws = append(mws, middlewares.AuthorizeMiddleware(s.opt.JWTauthenticator, s.opt.authAllowList...))
+		}
+
+		if len(s.opt.middlewares) > 0 {
+			mws = append(mws, s.opt.middlewares...)
+		}
+
+		kitexOpts := []server.Option{
+			server.WithListener(s.opt.listener),
+			server.WithServerBasicInfo(&rpcinfo.EndpointBasicInfo{ServiceName: s.opt.name}),
+			// 退出由 Stop 控制，不使用 kitex 默认的信号监听
+			server.WithExitSignal(func() <-chan error { return s.exit }),
+		}
+		for _, mw := range mws {
+			kitexOpts = append(kitexOpts, server.WithMiddleware(mw))
+		}
+
+		// 将用户自己传入的kitex serverOptions合并到kitexOpts
+		if len(s.opt.kitexOpts) > 0 {
+			kitexOpts = append(kitexOpts, s.opt.kitexOpts...)
+		}
+
+		s.Server = server.NewServer(kitexOpts...)
+	})
+	if s.initErr != nil {
+		return nil, s.initErr
+	}
+
+	// kitex 服务器创建时已经绑定了监听器，CloseListener 之后不能重新监听
+	if s.opt.listener == nil {
+		return nil, errListenerClosed
+	}
+
+	return s.Server, nil
+}
+
+// Engine 返回底层的 kitex 服务器，必要时先完成监听
+func (s *Server) Engine() server.Server {
+	svr, err := s.engine()
+	if err != nil {
+		log.Errorf("[Kitex] create server failed: %s", err)
+	}
+	return svr
+}
+
+// RegisterService 注册 kitex 生成的服务
+func (s *Server) RegisterService(svcInfo *serviceinfo.ServiceInfo, handler any, opts ...server.RegisterOption) error {
+	svr, err := s.engine()
+	if err != nil {
+		return err
+	}
+	return svr.RegisterService(svcInfo, handler, opts...)
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	defer close(s.done)
+
+	// Stop 在启动之前已经调用，不再启动
+	select {
+	case <-s.exit:
+		log.Infof("[Kitex] server stopped before start")
+		return s.CloseListener()
+	default:
+	}
+
+	svr, err := s.engine()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("[Kitex] server listening at %s", s.opt.listener.Addr())
+
+	// 监听器已经创建，连接会在 Run 之前排队等待
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	if err = svr.Run(); err != nil {
+		log.Errorf("[Kitex] server serve failed: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	log.Infof("[Kitex] server stopping")
+	// 先关闭 exit，这样在 Start 之前调用 Stop 时，之后的 Start 也会直接退出
+	s.exitOnce.Do(func() { close(s.exit) })
+
+	// 尚未开始监听，没有需要等待的服务
+	select {
+	case <-s.ready:
+	default:
+		return nil
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		log.Warn("[Kitex] server couldn't stop gracefully in time")
+		return ctx.Err()
+	}
+
+	log.Infof("[Kitex] server stopped")
+
+	return nil
+}
+
+// Ready returns a channel that is closed once the server is listening.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Drain marks the server as draining, kitex has no health service to mark as NOT_SERVING,
+// so new requests are rejected with code.ErrServiceDraining while the requests in flight finish.
+func (s *Server) Drain() {
+	log.Infof("[Kitex] server draining, %d requests in flight", s.inflight.Load())
+	s.draining.Store(true)
+}
+
+// Inflight returns the number of requests that are being processed.
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
+// Endpoint return a real address to registry endpoint.
+func (s *Server) Endpoint() (*url.URL, error) {
+	if _, err := s.engine(); err != nil {
+		return nil, err
+	}
+	return s.opt.endpoint, nil
+}
+
+// CloseListener closes the listener opened by Endpoint when the server will not be started,
+// such as when the app fails to start. The server cannot be started after that.
+func (s *Server) CloseListener() error {
+	if s.opt.listener == nil {
+		return nil
+	}
+	err := s.opt.listener.Close()
+	s.opt.listener, s.opt.endpoint = nil, nil
+	return err
+}
+
+func (s *Server) listenAndEndpoint() error {
+
+	// 如果用户已经设置了listener，则直接使用用户设置的listener
+	if s.opt.listener == nil {
+		lis, err := net.Listen("tcp", s.opt.address)
+		if err != nil {
+			log.Errorf("[Kitex] Listen to the listener failed: %s", err)
+			return err
+		}
+		s.opt.listener = lis
+	}
+
+	if s.opt.endpoint != nil {
+		return nil
+	}
+
+	// 提取地址
+	address, err := host.Extract(s.opt.address, s.opt.listener)
+	if err != nil {
+		log.Errorf("[Kitex] Get address from listener failed: %s", err)
+		closeErr := s.opt.listener.Close()
+		if closeErr != nil {
+			log.Errorf("[Kitex] Close listener failed: %s", closeErr)
+			return closeErr
+		}
+		return err
+	}
+
+	s.opt.endpoint = discovery.NewEndpoint(kitexScheme, address, s.opt.enableInsecure)
+
+	return nil
+}
+
+func (s *Server) Name() string {
+	return s.opt.name
+}
+
+func (s *Server) Address() string {
+	return s.opt.address
+}
@@ -0,0 +1,189 @@
+package kitex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taluos/Malt/pkg/auth-jwt"
+	JWT "github.com/taluos/Malt/pkg/auth-jwt/JWT"
+	"github.com/taluos/Malt/pkg/errors"
+	"github.com/taluos/Malt/pkg/errors/code"
+	"github.com/taluos/Malt/server/rpc/rpc-kitex/internal/middlewares"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/client/genericclient"
+	"github.com/cloudwego/kitex/pkg/generic"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/server"
+	"github.com/cloudwego/kitex/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const echoIDL = `
+namespace go echo
+
+struct EchoRequest {
+	1: string message
+}
+
+struct EchoResponse {
+	1: string message
+}
+
+service Echo {
+	EchoResponse Echo(1: EchoRequest req)
+}
+`
+
+// echoService 原样返回 JSON 泛化调用的请求，认证通过时返回 claims 中的用户
+type echoService struct {
+	delay time.Duration
+}
+
+func (s *echoService) GenericCall(ctx context.Context, method string, request any) (any, error) {
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+	if claims, ok := auth.FromContext(ctx); ok {
+		return `{"message":"` + claims.GetUserID() + `"}`, nil
+	}
+	return request, nil
+}
+
+func newEchoGeneric(t *testing.T) generic.Generic {
+	t.Helper()
+	p, err := generic.NewThriftContentProvider(echoIDL, nil)
+	require.NoError(t, err)
+	g, err := generic.JSONThriftGeneric(p)
+	require.NoError(t, err)
+	return g
+}
+
+// startEchoServer 启动 echo 服务，返回监听地址
+func startEchoServer(t *testing.T, svc *echoService, opts ...ServerOptions) (*Server, string) {
+	t.Helper()
+	g := newEchoGeneric(t)
+	opts = append([]ServerOptions{
+		WithName("echo"),
+		WithAddress("127.0.0.1:0"),
+		WithOptions(server.WithGeneric(g)),
+	}, opts...)
+	s := NewServer(opts...)
+	require.NoError(t, s.RegisterService(generic.ServiceInfoWithGeneric(g), svc))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start(context.Background()) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, s.Stop(ctx))
+		assert.NoError(t, <-errCh)
+	})
+
+	select {
+	case <-s.Ready():
+	case err := <-errCh:
+		t.Fatalf("server start failed: %v", err)
+	}
+	endpoint, err := s.Endpoint()
+	require.NoError(t, err)
+	return s, endpoint.Host
+}
+
+func newEchoClient(t *testing.T, address string) genericclient.Client {
+	t.Helper()
+	// 使用 TTHeader 透传 metainfo，与 Malt 的 kitex 客户端一致
+	cli, err := genericclient.NewClient("echo", newEchoGeneric(t),
+		client.WithHostPorts(address),
+		client.WithTransportProtocol(transport.TTHeader),
+		client.WithMetaHandler(transmeta.ClientTTHeaderHandler))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	s, address := startEchoServer(t, &echoService{})
+	cli := newEchoClient(t, address)
+
+	resp, err := cli.GenericCall(context.Background(), "Echo", `{"message":"hello"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message":"hello"}`, resp.(string))
+	assert.Zero(t, s.Inflight())
+
+	// 排空之后以 code.ErrServiceDraining 拒绝新请求
+	s.Drain()
+	_, err = cli.GenericCall(context.Background(), "Echo", `{"message":"hello"}`)
+	assert.ErrorContains(t, err, errors.ParseCoder(errors.WithCode(code.ErrServiceDraining, "")).String())
+}
+
+func TestServerTimeout(t *testing.T) {
+	_, address := startEchoServer(t, &echoService{delay: 200 * time.Millisecond}, WithTimeout(50*time.Millisecond))
+	cli := newEchoClient(t, address)
+
+	_, err := cli.GenericCall(context.Background(), "Echo", `{"message":"hello"}`)
+	assert.ErrorContains(t, err, errors.ParseCoder(errors.WithCode(code.ErrDeadlineExceeded, "")).String())
+}
+
+func TestServerStopBeforeStart(t *testing.T) {
+	s := NewServer(WithAddress("127.0.0.1:0"))
+	_, err := s.Endpoint()
+	require.NoError(t, err)
+	require.NoError(t, s.Stop(context.Background()))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start(context.Background()) }()
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(func(token *jwt.Token) (any, error) {
+		return jwt.ParseECPublicKeyFromPEM([]byte(JWT.TestPublicKey))
+	})
+	require.NoError(t, err)
+
+	t.Run("token", func(t *testing.T) {
+		_, address := startEchoServer(t, &echoService{}, WithAuthenticator(authenticator))
+		cli := newEchoClient(t, address)
+
+		_, err := cli.GenericCall(context.Background(), "Echo", `{"message":"hello"}`)
+		assert.ErrorContains(t, err, errors.ParseCoder(errors.WithCode(code.ErrInvalidAuthHeader, "")).String())
+
+		jwtInfo, err := JWT.NewJwtInfo(JWT.TestPrivateKey, "uuid", "/Echo/Echo", "admin")
+		require.NoError(t, err)
+		ctx := metainfo.WithValue(context.Background(), middlewares.AuthorizationKey, "Bearer "+jwtInfo.Token())
+		resp, err := cli.GenericCall(ctx, "Echo", `{"message":"hello"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message":"uuid"}`, resp.(string))
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		_, address := startEchoServer(t, &echoService{},
+			WithAuthenticator(authenticator), WithAuthAllowList("Echo"))
+		cli := newEchoClient(t, address)
+
+		resp, err := cli.GenericCall(context.Background(), "Echo", `{"message":"hello"}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message":"hello"}`, resp.(string))
+	})
+}
+
+func TestServerStartAfterCloseListener(t *testing.T) {
+	s := NewServer(WithAddress("127.0.0.1:0"))
+	_, err := s.Endpoint()
+	require.NoError(t, err)
+	require.NoError(t, s.CloseListener())
+
+	assert.ErrorIs(t, s.Start(context.Background()), errListenerClosed)
+	_, err = s.Endpoint()
+	assert.ErrorIs(t, err, errListenerClosed)
+}
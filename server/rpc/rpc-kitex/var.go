@@ -0,0 +1,18 @@
+package kitex
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultServerName = "my kitex server"
+	defaultAddress    = "127.0.0.1:8888"
+	defaultTimeout    = 5 * time.Second
+
+	// kitexScheme 注册到注册中心的端点 scheme
+	kitexScheme = "kitex"
+)
+
+// errListenerClosed CloseListener 之后再启动服务器时返回
+var errListenerClosed = errors.New("[Kitex] server listener closed")
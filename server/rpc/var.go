@@ -1,5 +1,6 @@
 package rpc
 
 const (
-    grpcServerType = "grpc"  // 修正拼写
+	grpcServerType  = "grpc" // 修正拼写
+	kitexServerType = "kitex"
 )
@@ -38,10 +38,26 @@ func (w *rpcServerWrapper) Stop(ctx context.Context) error {
 	return w.server.Stop(ctx)
 }
 
+func (w *rpcServerWrapper) Ready() <-chan struct{} {
+	return w.server.Ready()
+}
+
+func (w *rpcServerWrapper) Drain() {
+	w.server.Drain()
+}
+
+func (w *rpcServerWrapper) Inflight() int64 {
+	return w.server.Inflight()
+}
+
 func (w *rpcServerWrapper) Endpoint() (*url.URL, error) {
 	return w.server.Endpoint()
 }
 
+func (w *rpcServerWrapper) CloseListener() error {
+	return w.server.CloseListener()
+}
+
 func (w *rpcServerWrapper) Engine() any {
 	return w.server.Engine()
 }
@@ -8,4 +8,5 @@ const (
 	defaultName           = "Malt"
 	defaltTimeout         = 10 * time.Second
 	defalregistrarTimeout = 10 * time.Second
+	drainLogInterval      = time.Second
 )